@@ -0,0 +1,98 @@
+// nla-backup 管理词典数据库的备份: 创建备份、列出备份目录与按保留规则清理过期备份
+//
+// 备份目录记录在数据库中, 清理时只删除目录中记录的备份文件, 备份目录下的其他文件不受影响
+//
+// 用法:
+//
+//	nla-backup -db gse_dict_db -dir backups snapshot
+//	nla-backup -db gse_dict_db -dir backups list
+//	nla-backup -db gse_dict_db -dir backups -daily 7 -weekly 4 [-dry-run] prune
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/miajio/nla/pkg/badger"
+)
+
+// config 运行配置
+type config struct {
+	db     string
+	dir    string
+	daily  int
+	weekly int
+	dryRun bool
+	wait   time.Duration
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.db, "db", "gse_dict_db", "词典数据库路径")
+	flag.StringVar(&cfg.dir, "dir", "backups", "备份文件目录")
+	flag.IntVar(&cfg.daily, "daily", 7, "保留最近几天的每日备份")
+	flag.IntVar(&cfg.weekly, "weekly", 4, "保留最近几周的每周备份")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "prune时只列出将被删除的备份")
+	flag.DurationVar(&cfg.wait, "wait", 0, "数据库被其他进程占用时的最长等待时间")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] snapshot|list|prune\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(cfg, flag.Arg(0)); err != nil {
+		log.Fatalf("backup fail: %v", err)
+	}
+}
+
+// run 打开数据库并执行命令
+func run(cfg config, command string) error {
+	db, err := badger.Default(cfg.db, badger.WithLockWait(cfg.wait))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m := badger.NewBackupManager(db, cfg.dir, badger.WithRetention(badger.Retention{Daily: cfg.daily, Weekly: cfg.weekly}))
+	switch command {
+	case "snapshot":
+		record, err := m.Snapshot()
+		if err != nil {
+			return err
+		}
+		printRecord(record)
+	case "list":
+		records, err := m.Catalog()
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			printRecord(record)
+		}
+	case "prune":
+		pruned, err := m.Prune(cfg.dryRun)
+		for _, record := range pruned {
+			printRecord(record)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "pruned %d backups\n", len(pruned))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// printRecord 输出一条备份记录
+func printRecord(record badger.BackupRecord) {
+	fmt.Printf("%s\t%s\t%d\n", record.Name, record.Time.Format(time.RFC3339), record.Size)
+}
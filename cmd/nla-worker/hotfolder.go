@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watch 监视输入目录, 逐个处理新文件: 每行一条文本, 结果以JSON行写入输出目录的同名.jsonl文件,
+// 处理成功的源文件移入done目录, 失败的移入error目录并在旁边写入.err错误说明
+// 以"."开头或以.tmp、.part结尾的文件视为正在写入, 修改时间在settle之内的文件等待下一轮
+func (w *worker) watch(ctx context.Context) error {
+	for _, dir := range []string{w.cfg.watch, w.cfg.outDir, w.cfg.doneDir, w.cfg.errorDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	log.Printf("watch %s, output %s", w.cfg.watch, w.cfg.outDir)
+
+	for {
+		files, err := w.readyFiles()
+		if err != nil {
+			return fmt.Errorf("list %s fail: %v", w.cfg.watch, err)
+		}
+		for _, name := range files {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.processFile(ctx, name)
+		}
+		if !w.cfg.follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.cfg.poll):
+		}
+	}
+}
+
+// readyFiles 输入目录中已写完的文件, 按修改时间升序
+func (w *worker) readyFiles() ([]string, error) {
+	entries, err := os.ReadDir(w.cfg.watch)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") ||
+			strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".part") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) < w.cfg.settle {
+			continue
+		}
+		files = append(files, file{name, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.name
+	}
+	return names, nil
+}
+
+// processFile 处理一个文件并按结果移动源文件
+func (w *worker) processFile(ctx context.Context, name string) {
+	src := filepath.Join(w.cfg.watch, name)
+	n, err := w.convertFile(ctx, src, filepath.Join(w.cfg.outDir, strings.TrimSuffix(name, filepath.Ext(name))+".jsonl"))
+	if ctx.Err() != nil {
+		// 中断时保留源文件, 下次启动重新处理
+		return
+	}
+
+	dir := w.cfg.doneDir
+	if err != nil {
+		log.Printf("process %s fail: %v", name, err)
+		dir = w.cfg.errorDir
+		if werr := os.WriteFile(filepath.Join(dir, name+".err"), []byte(err.Error()+"\n"), 0o644); werr != nil {
+			log.Printf("write error note for %s fail: %v", name, werr)
+		}
+	} else {
+		log.Printf("processed %s: %d records", name, n)
+	}
+	if err := os.Rename(src, filepath.Join(dir, name)); err != nil {
+		log.Printf("move %s fail: %v", name, err)
+	}
+}
+
+// convertFile 逐行处理源文件, 结果先写入临时文件, 全部成功后改名为目标文件
+func (w *worker) convertFile(ctx context.Context, src, dst string) (int, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	bw := bufio.NewWriter(out)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var offset int64
+	n := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		line := scanner.Text()
+		lineOffset := offset
+		offset += int64(len(line)) + 1
+		text := strings.TrimRight(line, "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		record, err := w.process(ctx, lineOffset, text)
+		if err != nil {
+			return n, fmt.Errorf("line at offset %d: %v", lineOffset, err)
+		}
+		if err := enc.Encode(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	if err := bw.Flush(); err != nil {
+		return n, err
+	}
+	if err := out.Sync(); err != nil {
+		return n, err
+	}
+	if err := out.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp, dst)
+}
@@ -0,0 +1,382 @@
+// nla-worker 流式处理文本: 从主题日志中逐行读取原始文本, 按配置的阶段(分词 → 关键词抽取 → 审核)处理,
+// 将结果以JSON行写入输出主题
+//
+// 主题为按行追加的日志文件, 消费进度(字节偏移)按消费组保存在数据库中, 输出落盘后才提交进度,
+// 因此进程崩溃后重启会从上次提交的位置继续, 同一条消息可能被处理多次(至少一次), 下游可按offset去重
+// 不直接消费Kafka或NATS: 本模块未引入消息队列客户端, 接入消息队列时由采集程序将消息逐行追加到主题日志,
+// 结果主题同样是日志文件, 由转发程序写回消息队列
+//
+// -plugins指定插件阶段配置文件(pipeline.StageConfig的JSON数组), 引用通过pipeline.RegisterExtractor等注册的插件,
+// 插件在分词之后、关键词抽取之前依次运行; 私有插件可在自行构建的worker中以空导入的方式注册
+//
+// 指定-watch时以热文件夹模式运行: 监视输入目录, 处理放入的文件, 结果写入输出目录, 源文件移入done或error目录
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/middleware"
+	"github.com/miajio/nla/pkg/participle"
+	"github.com/miajio/nla/pkg/pipeline"
+)
+
+// offsetKeyPrefix 消费进度的键前缀, 键为前缀+消费组
+const offsetKeyPrefix = participle.MetaKeyPrefix + "worker:offset:"
+
+// Record 输出记录
+type Record struct {
+	Offset   int64                      `json:"offset"`             // 消息在输入主题中的字节偏移, 可用于去重
+	Text     string                     `json:"text"`               // 原始文本
+	Tokens   []string                   `json:"tokens,omitempty"`   // 分词结果
+	Keywords []participle.WordFrequency `json:"keywords,omitempty"` // 关键词
+	Verdict  *middleware.Verdict        `json:"verdict,omitempty"`  // 审核结论
+
+	Extracted map[string]any    `json:"extracted,omitempty"` // 插件抽取结果, 键为阶段名称
+	Errors    map[string]string `json:"errors,omitempty"`    // 插件阶段错误, 键为阶段名称
+}
+
+// config 运行配置
+type config struct {
+	db        string
+	in        string
+	out       string
+	group     string
+	stages    string
+	blocklist string
+	plugins   string
+	topN      int
+	follow    bool
+	poll      time.Duration
+	commit    int
+
+	watch    string        // 热文件夹: 输入目录
+	outDir   string        // 热文件夹: 输出目录
+	doneDir  string        // 热文件夹: 处理成功的源文件目录
+	errorDir string        // 热文件夹: 处理失败的源文件目录
+	settle   time.Duration // 热文件夹: 文件最后修改后等待多久才处理
+}
+
+// worker 流式处理
+type worker struct {
+	cfg       config
+	db        *badger.Engine
+	engine    *participle.Engine
+	moderator *middleware.Moderator
+	segment   bool
+	extract   bool
+	plugins   []pipeline.Stage
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.db, "db", "gse_dict_db", "词典数据库路径")
+	flag.StringVar(&cfg.in, "in", "", "输入主题日志, 每行一条原始文本")
+	flag.StringVar(&cfg.out, "out", "", "输出主题日志, 每行一条JSON记录, 追加写入")
+	flag.StringVar(&cfg.group, "group", "default", "消费组, 不同消费组的进度相互独立")
+	flag.StringVar(&cfg.stages, "stages", "segment", "处理阶段, 逗号分隔: segment, extract, moderate")
+	flag.StringVar(&cfg.blocklist, "blocklist", "", "moderate阶段的屏蔽词文件, 每行一个词")
+	flag.StringVar(&cfg.plugins, "plugins", "", "插件阶段配置文件, pipeline.StageConfig的JSON数组")
+	flag.IntVar(&cfg.topN, "topn", 5, "extract阶段保留的关键词数量")
+	flag.BoolVar(&cfg.follow, "follow", true, "读到主题末尾后继续等待新消息")
+	flag.DurationVar(&cfg.poll, "poll", time.Second, "等待新消息的轮询间隔")
+	flag.IntVar(&cfg.commit, "commit", 100, "每处理多少条消息提交一次进度")
+	flag.StringVar(&cfg.watch, "watch", "", "热文件夹模式的输入目录, 指定后忽略-in与-out")
+	flag.StringVar(&cfg.outDir, "outdir", "", "热文件夹模式的输出目录, 默认为输入目录下的out")
+	flag.StringVar(&cfg.doneDir, "donedir", "", "处理成功的源文件目录, 默认为输入目录下的done")
+	flag.StringVar(&cfg.errorDir, "errordir", "", "处理失败的源文件目录, 默认为输入目录下的error")
+	flag.DurationVar(&cfg.settle, "settle", 2*time.Second, "文件最后修改后等待多久才处理, 避免读到未写完的文件")
+	flag.Parse()
+	if cfg.watch != "" {
+		if cfg.outDir == "" {
+			cfg.outDir = filepath.Join(cfg.watch, "out")
+		}
+		if cfg.doneDir == "" {
+			cfg.doneDir = filepath.Join(cfg.watch, "done")
+		}
+		if cfg.errorDir == "" {
+			cfg.errorDir = filepath.Join(cfg.watch, "error")
+		}
+	} else if cfg.in == "" || cfg.out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("worker fail: %v", err)
+	}
+}
+
+// run 打开数据库与引擎并处理主题或热文件夹
+func run(ctx context.Context, cfg config) error {
+	db, err := badger.Default(cfg.db)
+	if err != nil {
+		return fmt.Errorf("open db fail: %v", err)
+	}
+
+	engine, err := participle.NewWithContext(ctx, db)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("create engine fail: %v", err)
+	}
+	// 关闭引擎时一并关闭数据库
+	defer engine.Close()
+
+	w := &worker{cfg: cfg, db: db, engine: engine}
+	for _, stage := range strings.Split(cfg.stages, ",") {
+		switch strings.TrimSpace(stage) {
+		case "segment":
+			w.segment = true
+		case "extract":
+			w.extract = true
+		case "moderate":
+			words, err := readLines(cfg.blocklist)
+			if err != nil {
+				return fmt.Errorf("read blocklist fail: %v", err)
+			}
+			w.moderator = middleware.NewModerator(engine, words, middleware.FlagPII())
+		case "":
+		default:
+			return fmt.Errorf("unknown stage: %s", stage)
+		}
+	}
+	if cfg.plugins != "" {
+		if w.plugins, err = loadPlugins(cfg.plugins); err != nil {
+			return fmt.Errorf("load plugins fail: %v", err)
+		}
+	}
+	if cfg.watch != "" {
+		return w.watch(ctx)
+	}
+	return w.consume(ctx)
+}
+
+// consume 从上次提交的位置开始消费输入主题
+func (w *worker) consume(ctx context.Context) error {
+	in, err := os.Open(w.cfg.in)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(w.cfg.out, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	offset, err := w.loadOffset()
+	if err != nil {
+		return fmt.Errorf("load offset fail: %v", err)
+	}
+	if _, err := in.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	log.Printf("consume %s from offset %d, group %s", w.cfg.in, offset, w.cfg.group)
+
+	br := bufio.NewReader(in)
+	bw := bufio.NewWriter(out)
+	committed, pending := offset, 0
+	commit := func() error {
+		if offset == committed {
+			return nil
+		}
+		// 输出落盘后才提交进度, 保证至少一次
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := out.Sync(); err != nil {
+			return err
+		}
+		if err := w.saveOffset(offset); err != nil {
+			return fmt.Errorf("save offset fail: %v", err)
+		}
+		committed, pending = offset, 0
+		return nil
+	}
+
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for {
+		if ctx.Err() != nil {
+			return errors.Join(commit(), ctx.Err())
+		}
+
+		line, err := br.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			// 末尾不完整的一行留到写完后再读
+			if _, err := in.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+			br.Reset(in)
+			if err := commit(); err != nil {
+				return err
+			}
+			if !w.cfg.follow {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.cfg.poll):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		msgOffset := offset
+		offset += int64(len(line))
+		text := strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		record, err := w.process(ctx, msgOffset, text)
+		if err != nil {
+			if ctx.Err() != nil {
+				// 中断的消息不输出, 只提交之前的进度, 重启后重新处理
+				offset = msgOffset
+				return errors.Join(commit(), ctx.Err())
+			}
+			return fmt.Errorf("process offset %d fail: %v", msgOffset, err)
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+		if pending++; pending >= w.cfg.commit {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// process 按配置的阶段处理一条消息, ctx传给插件阶段
+func (w *worker) process(ctx context.Context, offset int64, text string) (Record, error) {
+	record := Record{Offset: offset, Text: text}
+	if len(w.plugins) > 0 {
+		if err := w.runPlugins(ctx, &record); err != nil {
+			return record, err
+		}
+	} else if w.segment {
+		record.Tokens = w.engine.Segment(text)
+	}
+	if w.extract {
+		keywords, err := w.engine.ExtractKeywords(text, w.cfg.topN)
+		if err != nil {
+			return record, err
+		}
+		record.Keywords = keywords
+	}
+	if w.moderator != nil {
+		verdict := w.moderator.Check(text)
+		verdict.Field = "text"
+		record.Verdict = &verdict
+	}
+	return record, nil
+}
+
+// runPlugins 分词后依次运行插件阶段
+// 每条消息都须输出记录, 因此ErrorSkip与ErrorContinue均记录错误并继续, 只有ErrorFail中止处理;
+// ctx结束时阶段的错误来自中断而不是消息本身, 返回ctx的错误, 不输出记录
+func (w *worker) runPlugins(ctx context.Context, record *Record) error {
+	doc := &pipeline.Document{
+		ID:        int(record.Offset),
+		Text:      record.Text,
+		Tokens:    w.engine.SegmentTokens(record.Text),
+		Extracted: make(map[string]any),
+		Errors:    make(map[string]error),
+	}
+	for _, stage := range w.plugins {
+		if err := stage.Run(ctx, doc); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if stage.OnError == pipeline.ErrorFail {
+				return fmt.Errorf("stage %s fail: %v", stage.Name, err)
+			}
+			if record.Errors == nil {
+				record.Errors = make(map[string]string)
+			}
+			record.Errors[stage.Name] = err.Error()
+		}
+	}
+	if w.segment {
+		record.Tokens = make([]string, len(doc.Tokens))
+		for i, token := range doc.Tokens {
+			record.Tokens[i] = token.Text
+		}
+	}
+	if len(doc.Extracted) > 0 {
+		record.Extracted = doc.Extracted
+	}
+	return nil
+}
+
+// loadPlugins 读取插件阶段配置并创建阶段
+func loadPlugins(path string) ([]pipeline.Stage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfgs []pipeline.StageConfig
+	if err := json.Unmarshal(data, &cfgs); err != nil {
+		return nil, err
+	}
+	return pipeline.StagesFromConfig(cfgs)
+}
+
+// loadOffset 读取消费组已提交的进度
+func (w *worker) loadOffset() (int64, error) {
+	data, err := w.db.Get([]byte(offsetKeyPrefix + w.cfg.group))
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// saveOffset 提交消费组的进度
+func (w *worker) saveOffset(offset int64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(offset))
+	return w.db.Set([]byte(offsetKeyPrefix+w.cfg.group), buf[:])
+}
+
+// readLines 读取文件中的非空行
+func readLines(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
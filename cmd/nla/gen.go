@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/miajio/nla/pkg/address"
+)
+
+// Sample 生成的样本: 输入文本与预期结果
+type Sample struct {
+	Kind     string         `json:"kind"`     // 样本类型
+	Input    string         `json:"input"`    // 输入文本
+	Expected map[string]any `json:"expected"` // 预期结果
+}
+
+// division 一组省、市、区县
+type division struct {
+	Province, City, County string
+}
+
+// 国标编码中省、市、区县前缀的长度
+const (
+	gbProvinceLen = 5
+	gbCityLen     = 7
+)
+
+var (
+	surnames    = []rune("王李张刘陈杨黄赵吴周徐孙马朱胡郭何高林罗郑梁谢宋唐许韩冯邓曹")
+	givenNames  = []rune("伟芳娜敏静丽强磊军洋勇艳杰娟涛明超秀霞平刚桂英华玉兰")
+	roads       = []string{"人民路", "解放路", "中山路", "建设路", "深南大道", "长安街", "文三路", "南京东路"}
+	places      = []string{"科技园", "时代广场", "国际大厦", "花园小区", "创业中心", "大学城"}
+	separators  = []string{"", "，", ",", " ", "；"}
+	brands      = []string{"小米", "华为", "李宁", "安踏", "海尔", "美的", "三只松鼠"}
+	products    = []string{"蓝牙耳机", "运动鞋", "保温杯", "电饭煲", "坚果礼盒", "充电宝"}
+	promotions  = []string{"限时秒杀", "买一送一", "包邮", "新品首发", "官方旗舰店"}
+	slangPrices = map[string]string{"米": "元", "达不溜": "元"}
+	slangTerms  = map[string]string{"煮啵": "主播", "啵啵间": "直播间", "浮力": "福利", "飞走": "发货"}
+)
+
+// genTestdata 执行gen testdata命令
+func genTestdata(args []string) error {
+	fs := flag.NewFlagSet("gen testdata", flag.ExitOnError)
+	kind := fs.String("kind", "all", "样本类型: address, product, chat, all")
+	n := fs.Int("n", 100, "每种类型的样本数量")
+	seed := fs.Uint64("seed", 1, "随机种子, 相同种子生成相同样本")
+	out := fs.String("out", "testdata", "输出目录")
+	dict := fs.String("dict", "examples/dict", "地区字典目录")
+	fs.Parse(args)
+
+	switch *kind {
+	case "all", "address", "product", "chat":
+	default:
+		return fmt.Errorf("unknown kind %q", *kind)
+	}
+
+	r := rand.New(rand.NewPCG(*seed, *seed))
+	generators := map[string]func(r *rand.Rand) Sample{
+		"product": genProduct,
+		"chat":    genChat,
+	}
+	if *kind == "all" || *kind == "address" {
+		divisions, err := loadDivisions(*dict)
+		if err != nil {
+			return fmt.Errorf("load regions fail: %v", err)
+		}
+		generators["address"] = func(r *rand.Rand) Sample { return genAddress(r, divisions) }
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	for _, name := range []string{"address", "product", "chat"} {
+		gen, ok := generators[name]
+		if !ok || (*kind != "all" && *kind != name) {
+			continue
+		}
+		path := filepath.Join(*out, name+".jsonl")
+		if err := writeSamples(path, *n, func() Sample { return gen(r) }); err != nil {
+			return fmt.Errorf("write samples fail: %v", err)
+		}
+		fmt.Printf("已生成 %d 条 %s 样本: %s\n", *n, name, path)
+	}
+	return nil
+}
+
+// writeSamples 以JSON Lines格式写入样本
+func writeSamples(path string, n int, gen func() Sample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(gen()); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// gbPrefix 返回地区国标编码的前n位, 编码过短时返回错误
+func gbPrefix(r address.Region, n int) (string, error) {
+	if len(r.GB) < n {
+		return "", fmt.Errorf("region %s has invalid gb code %q", r.Name, r.GB)
+	}
+	return r.GB[:n], nil
+}
+
+// loadDivisions 按国标编码组合省、市、区县
+// 编码第4-5位为省, 第6-7位为市, 第8-9位为区县; 直辖市下的区县没有对应的市
+func loadDivisions(dir string) ([]division, error) {
+	var lists [3][]address.Region
+	for i, name := range []string{"province.json", "city.json", "county.json"} {
+		regions, err := address.LoadRegions(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = regions
+	}
+
+	provinces := make(map[string]string)
+	for _, p := range lists[0] {
+		code, err := gbPrefix(p, gbProvinceLen)
+		if err != nil {
+			return nil, err
+		}
+		provinces[code] = p.Name
+	}
+	cities := make(map[string]string)
+	for _, c := range lists[1] {
+		code, err := gbPrefix(c, gbCityLen)
+		if err != nil {
+			return nil, err
+		}
+		cities[code] = c.Name
+	}
+
+	var divisions []division
+	for _, county := range lists[2] {
+		code, err := gbPrefix(county, gbCityLen)
+		if err != nil {
+			return nil, err
+		}
+		province, ok := provinces[code[:gbProvinceLen]]
+		if !ok {
+			continue
+		}
+		divisions = append(divisions, division{Province: province, City: cities[code], County: county.Name})
+	}
+	if len(divisions) == 0 {
+		return nil, fmt.Errorf("no county in %s matches a province", dir)
+	}
+	return divisions, nil
+}
+
+// pick 随机选取一个元素
+func pick[T any](r *rand.Rand, items []T) T {
+	return items[r.IntN(len(items))]
+}
+
+// genAddress 生成收件地址样本
+func genAddress(r *rand.Rand, divisions []division) Sample {
+	d := pick(r, divisions)
+	name := string(pick(r, surnames)) + string(pick(r, givenNames))
+	if r.IntN(2) == 0 {
+		name += string(pick(r, givenNames))
+	}
+	contact := fmt.Sprintf("1%d%09d", 3+r.IntN(7), r.IntN(1000000000))
+	detail := fmt.Sprintf("%s%d号%s%d栋%d室", pick(r, roads), 1+r.IntN(999), pick(r, places), 1+r.IntN(30), 101+r.IntN(2000))
+
+	fields := []string{name, contact, d.Province + d.City + d.County + detail}
+	r.Shuffle(len(fields), func(i, j int) { fields[i], fields[j] = fields[j], fields[i] })
+
+	return Sample{
+		Kind:  "address",
+		Input: strings.Join(fields, pick(r, separators)),
+		Expected: map[string]any{
+			"name":     name,
+			"contact":  contact,
+			"province": d.Province,
+			"city":     d.City,
+			"county":   d.County,
+			"detailed": detail,
+		},
+	}
+}
+
+// genProduct 生成商品标题样本
+func genProduct(r *rand.Rand) Sample {
+	brand, product, promotion := pick(r, brands), pick(r, products), pick(r, promotions)
+	price := fmt.Sprintf("%d.9", 9+r.IntN(290))
+
+	return Sample{
+		Kind:  "product",
+		Input: fmt.Sprintf("【%s】%s%s 仅需%s元", promotion, brand, product, price),
+		Expected: map[string]any{
+			"brand":     brand,
+			"product":   product,
+			"promotion": promotion,
+			"price":     price,
+		},
+	}
+}
+
+// genChat 生成带黑话的直播聊天样本, 预期结果为黑话与规范说法的对应关系
+func genChat(r *rand.Rand) Sample {
+	terms := make(map[string]string)
+	var parts []string
+
+	slang := pick(r, []string{"煮啵", "啵啵间", "浮力"})
+	terms[slang] = slangTerms[slang]
+	parts = append(parts, fmt.Sprintf("欢迎来到%s", slang))
+
+	unit := pick(r, []string{"米", "达不溜"})
+	terms[unit] = slangPrices[unit]
+	parts = append(parts, fmt.Sprintf("今天只要%d.9%s", 1+r.IntN(99), unit))
+
+	if r.IntN(2) == 0 {
+		terms["飞走"] = slangTerms["飞走"]
+		parts = append(parts, fmt.Sprintf("%d个太阳内飞走", 1+r.IntN(7)))
+	}
+
+	return Sample{
+		Kind:     "chat",
+		Input:    strings.Join(parts, "，"),
+		Expected: map[string]any{"slang": terms},
+	}
+}
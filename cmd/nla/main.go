@@ -0,0 +1,28 @@
+// nla 命令行工具
+//
+// gen testdata 生成带预期结果的合成样本(收件地址、商品标题、带黑话的直播聊天), 以JSON Lines格式写入输出目录,
+// 用于各模块的基准测试与演示, 无需使用真实数据; 相同种子生成相同样本
+//
+// 用法:
+//
+//	nla gen testdata [-kind all|address|product|chat] [-n 100] [-seed 1] [-out testdata] [-dict examples/dict]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// usage 命令用法
+const usage = "usage: nla gen testdata [flags]\n"
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "gen" || os.Args[2] != "testdata" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := genTestdata(os.Args[3:]); err != nil {
+		log.Fatalf("gen testdata fail: %v", err)
+	}
+}
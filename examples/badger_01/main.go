@@ -20,7 +20,7 @@ func main() {
 		return
 	}
 
-	kb, _ := be.GetKey(nil)
+	kb, _ := be.Keys(nil)
 	for _, k := range kb {
 		val, _ := be.Get(k)
 		fmt.Printf("%s: %s\n", k, val)
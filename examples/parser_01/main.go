@@ -1,220 +1,20 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
-	"regexp"
-	"strings"
 
-	"github.com/dgraph-io/badger/v4"
-	"github.com/go-ego/gse"
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
 )
 
-// DictEntry 表示词典中的一个词条
-type DictEntry struct {
-	Word      string  `json:"word"`      // 词
-	Frequency float64 `json:"frequency"` // 词频
-	Pos       string  `json:"pos"`       // 词性
-}
-
-// Dictionary 管理分词字典
-type Dictionary struct {
-	segmenter gse.Segmenter // GSE分词器
-	db        *badger.DB    // Badger数据库实例
-}
-
-// NewDictionary 创建一个新的词典实例
-func NewDictionary(dbPath string) (*Dictionary, error) {
-	// 创建数据库目录
-	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(dbPath, 0755); err != nil {
-			return nil, fmt.Errorf("无法创建数据库目录: %v", err)
-		}
-	}
-
-	// 打开Badger数据库
-	opts := badger.DefaultOptions(dbPath)
-	db, err := badger.Open(opts)
-	if err != nil {
-		return nil, fmt.Errorf("无法打开Badger数据库: %v", err)
-	}
-
-	// 初始化GSE分词器
-	seg, err := gse.New()
-	if err != nil {
-		return nil, fmt.Errorf("无法初始化GSE分词器: %v", err)
-	}
-	// 从数据库加载已有词典
-	if err := loadDictionaryFromDB(db, &seg); err != nil {
-		return nil, fmt.Errorf("从数据库加载词典失败: %v", err)
-	}
-
-	return &Dictionary{
-		segmenter: seg,
-		db:        db,
-	}, nil
-}
-
-// 从数据库加载词典到GSE分词器
-func loadDictionaryFromDB(db *badger.DB, seg *gse.Segmenter) error {
-	words := make([]string, 0)
-
-	err := db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = true
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			// _ := item.Key()
-			err := item.Value(func(val []byte) error {
-				var entry DictEntry
-				if err := json.Unmarshal(val, &entry); err != nil {
-					return err
-				}
-
-				// 构建GSE格式的词条
-				wordWithFreq := fmt.Sprintf("%s %d %s", entry.Word, entry.Frequency, entry.Pos)
-				words = append(words, wordWithFreq)
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-
-	if err != nil {
-		return err
-	}
-
-	// 如果有词条，加载到GSE分词器
-	if len(words) > 0 {
-		dictData := strings.Join(words, "\n")
-		seg.LoadDictStr(dictData)
-	}
-
-	return nil
-}
-
-// AddWord 添加一个新词到词典
-func (d *Dictionary) AddWord(word string, frequency float64, pos string) error {
-	entry := DictEntry{
-		Word:      word,
-		Frequency: frequency,
-		Pos:       pos,
-	}
-
-	// 序列化词条
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return fmt.Errorf("序列化词条失败: %v", err)
-	}
-
-	// 存储到数据库
-	err = d.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(word), data)
-	})
-	if err != nil {
-		return fmt.Errorf("保存词条到数据库失败: %v", err)
-	}
-
-	// 更新GSE分词器
-	d.segmenter.AddToken(
-		word,
-		frequency,
-		pos,
-	)
-
-	return nil
-}
-
-// LearnFromText 从文本中学习新词汇
-func (d *Dictionary) LearnFromText(text string) error {
-	// 分词
-	words := d.segmenter.Cut(text, true)
-
-	// 分析新词
-	for _, word := range words {
-		// 跳过单字词和标点符号
-		if len(word) <= 1 || isPunctuation(word) {
-			continue
-		}
-
-		// 检查是否已存在
-		exists := false
-		err := d.db.View(func(txn *badger.Txn) error {
-			_, err := txn.Get([]byte(word))
-			if err == nil {
-				exists = true
-			} else if err == badger.ErrKeyNotFound {
-				exists = false
-			} else {
-				return err
-			}
-			return nil
-		})
-
-		if err != nil {
-			return fmt.Errorf("检查词条是否存在失败: %v", err)
-		}
-
-		// 如果不存在，添加到词典
-		if !exists {
-			// 默认频率为1000，词性为"nz"（其他专名）
-			if err := d.AddWord(word, 1000, "nz"); err != nil {
-				return fmt.Errorf("添加新词失败: %v", err)
-			}
-			fmt.Printf("学习到新词: %s\n", word)
-		}
-	}
-
-	return nil
-}
-
-// Segment 对文本进行分词
-func (d *Dictionary) Segment(text string) []string {
-	return d.segmenter.Cut(text, true)
-}
-
-// Close 关闭词典
-func (d *Dictionary) Close() error {
-	return d.db.Close()
-}
-
-// 检查是否为标点符号
-func isPunctuation(s string) bool {
-	// 检查是否为空字符串
-	if s == "" {
-		return false
-	}
-
-	// 使用正则表达式检查是否为标点符号或特殊符号
-	// 匹配中文标点符号
-	chinesePunctuation := regexp.MustCompile(`[\p{P}\p{S}\p{Z}]+`)
-
-	// 匹配所有Unicode标点符号和符号类别
-	// \p{P} 标点符号: Pc, Pd, Ps, Pe, Pi, Pf, Po
-	// \p{S} 符号: Sm, Sc, Sk, So
-	// \p{Z} 分隔符: Zs, Zl, Zp
-
-	// 如果整个字符串都是特殊符号，则返回true
-	return chinesePunctuation.MatchString(s)
-}
-
 func main() {
 	// 创建词典实例
-	// dir, err := os.UserHomeDir()
-	// if err != nil {
-	// 	log.Fatalf("无法获取用户主目录: %v", err)
-	// }
-
-	// dbPath := filepath.Join(dir, "gse_dict_db")
-	dict, err := NewDictionary("gse_dict_db")
+	db, err := badger.Default("gse_dict_db")
+	if err != nil {
+		log.Fatalf("创建数据库失败: %v", err)
+	}
+	dict, err := participle.New(db)
 	if err != nil {
 		log.Fatalf("创建词典失败: %v", err)
 	}
@@ -238,9 +38,13 @@ func main() {
 `
 
 	// 从文本学习新词
-	if err := dict.LearnFromText(text); err != nil {
+	learned, err := dict.LearnFromText(text)
+	if err != nil {
 		log.Printf("学习新词失败: %v", err)
 	}
+	for _, entry := range learned {
+		fmt.Printf("学习到新词: %s\n", entry.Content)
+	}
 
 	// 再次分词，查看学习效果
 	newText := `平常中藏财气《西游记》中有这样一段故事，我至今印象深刻：
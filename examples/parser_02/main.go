@@ -1,316 +1,20 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
-	"regexp"
-	"strings"
-	"unicode"
-	"unicode/utf8"
 
-	"github.com/dgraph-io/badger/v4"
-	"github.com/go-ego/gse"
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
 )
 
-// DictEntry 表示词典中的一个词条
-type DictEntry struct {
-	Content   string  `json:"content"`
-	Frequency float64 `json:"frequency"`
-	Pos       string  `json:"pos"` // 词性
-}
-
-// TrieNode 前缀树节点
-type TrieNode struct {
-	Children map[string]*TrieNode // 子节点，使用完整字符作为键
-	IsEnd    bool                 // 是否是一个词的结尾
-	Entry    *DictEntry           // 如果是词尾，存储词条信息
-}
-
-// NewTrieNode 创建一个新的前缀树节点
-func NewTrieNode() *TrieNode {
-	return &TrieNode{
-		Children: make(map[string]*TrieNode),
-		IsEnd:    false,
-		Entry:    nil,
-	}
-}
-
-// 按Unicode字符分割字符串
-func SplitString(s string) []string {
-	var result []string
-	for len(s) > 0 {
-		_, size := utf8.DecodeRuneInString(s)
-		result = append(result, s[:size])
-		s = s[size:]
-	}
-	return result
-}
-
-// Engine 分词引擎
-type Engine struct {
-	segmenter gse.Segmenter
-	db        *badger.DB
-	root      *TrieNode // 前缀树根节点
-}
-
-// NewEngine 创建一个新的分词引擎
-func NewEngine(dbPath string) (*Engine, error) {
-	// 创建数据库目录
-	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(dbPath, 0755); err != nil {
-			return nil, fmt.Errorf("无法创建数据库目录: %v", err)
-		}
-	}
-
-	// 打开Badger数据库
-	opts := badger.DefaultOptions(dbPath)
-	db, err := badger.Open(opts)
-	if err != nil {
-		return nil, fmt.Errorf("无法打开Badger数据库: %v", err)
-	}
-
-	// 初始化前缀树根节点
-	root := NewTrieNode()
-
-	// 从数据库加载已有词典到前缀树
-	if err := loadDictionaryFromDB(db, root); err != nil {
-		return nil, fmt.Errorf("从数据库加载词典失败: %v", err)
-	}
-
-	// 初始化GSE分词器
-	seg, err := gse.New()
-	if err != nil {
-		return nil, fmt.Errorf("无法初始化GSE分词器: %v", err)
-	}
-
-	// 从前缀树加载词典到GSE
-	loadDictionaryFromTrie(root, &seg)
-
-	return &Engine{
-		segmenter: seg,
-		db:        db,
-		root:      root,
-	}, nil
-}
-
-// 从数据库加载词典到前缀树
-func loadDictionaryFromDB(db *badger.DB, root *TrieNode) error {
-	err := db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = true
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := item.Key()
-			content := string(key)
-
-			err := item.Value(func(val []byte) error {
-				var entry DictEntry
-				if err := json.Unmarshal(val, &entry); err != nil {
-					return err
-				}
-
-				// 将词条添加到前缀树
-				node := root
-				chars := SplitString(content)
-
-				for _, char := range chars {
-					if _, ok := node.Children[char]; !ok {
-						node.Children[char] = NewTrieNode()
-					}
-					node = node.Children[char]
-				}
-
-				node.IsEnd = true
-				node.Entry = &entry
-				return nil
-			})
-
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
-
-	return err
-}
-
-// 将词条插入前缀树并保存到数据库
-func (d *Engine) insertIntoTrieAndDB(content string, entry DictEntry) error {
-	// 添加到前缀树
-	node := d.root
-	chars := SplitString(content)
-
-	for _, char := range chars {
-		if _, ok := node.Children[char]; !ok {
-			node.Children[char] = NewTrieNode()
-		}
-		node = node.Children[char]
-	}
-
-	node.IsEnd = true
-	node.Entry = &entry
-
-	// 保存到数据库
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return err
-	}
-
-	return d.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(content), data)
-	})
-}
-
-// 从前缀树加载词典到GSE
-func loadDictionaryFromTrie(root *TrieNode, seg *gse.Segmenter) {
-	contents := make([]string, 0)
-
-	// 遍历前缀树，收集所有词条
-	var collectContents func(node *TrieNode, prefix string)
-	collectContents = func(node *TrieNode, prefix string) {
-		if node.IsEnd && node.Entry != nil {
-			contents = append(contents, fmt.Sprintf("%s %f %s", node.Entry.Content, node.Entry.Frequency, node.Entry.Pos))
-		}
-
-		for char, child := range node.Children {
-			collectContents(child, prefix+char)
-		}
-	}
-
-	collectContents(root, "")
-
-	// 如果有词条，加载到GSE分词器
-	if len(contents) > 0 {
-		dictData := strings.Join(contents, "\n")
-		seg.LoadDictStr(dictData)
-	}
-}
-
-// AddWord 添加一个新词到词典
-func (d *Engine) AddWord(content string, frequency float64, pos string) error {
-	entry := DictEntry{
-		Content:   content,
-		Frequency: frequency,
-		Pos:       pos,
-	}
-
-	// 添加到前缀树并保存到数据库
-	if err := d.insertIntoTrieAndDB(content, entry); err != nil {
-		return fmt.Errorf("保存词条到前缀树和数据库失败: %v", err)
-	}
-
-	// 更新GSE分词器
-	d.segmenter.AddToken(content, frequency, pos)
-
-	return nil
-}
-
-// LearnFromText 从文本中学习新词汇
-func (d *Engine) LearnFromText(text string) error {
-	// 分词
-	contents := d.segmenter.Cut(text, true)
-
-	// 分析新词
-	for _, content := range contents {
-		// 跳过特殊符号和单字词
-		if len(content) <= 1 || IsSpecialChar(content) {
-			continue
-		}
-
-		// 检查是否已存在于前缀树中
-		if !d.containsWord(content) {
-			// 默认频率为1000.0，词性为"nz"（其他专名）
-			if err := d.AddWord(content, 1000.0, "nz"); err != nil {
-				return fmt.Errorf("添加新词失败: %v", err)
-			}
-			fmt.Printf("学习到新词: %s\n", content)
-		}
-	}
-
-	return nil
-}
-
-// containsWord 检查前缀树中是否包含指定的词
-func (d *Engine) containsWord(content string) bool {
-	node := d.root
-	chars := SplitString(content)
-
-	for _, char := range chars {
-		if _, ok := node.Children[char]; !ok {
-			return false
-		}
-		node = node.Children[char]
-	}
-	return node.IsEnd
-}
-
-// Segment 对文本进行分词
-func (d *Engine) Segment(text string) []string {
-	return d.segmenter.Cut(text, true)
-}
-
-// Close 关闭词典
-func (d *Engine) Close() error {
-	return d.db.Close()
-}
-
-// IsSpecialChar 判断字符串是否为特殊符号
-func IsSpecialChar(s string) bool {
-	// 检查是否为空字符串
-	if s == "" {
-		return false
-	}
-
-	// 使用正则表达式检查是否为标点符号或特殊符号
-	chinesePunctuation := regexp.MustCompile(`[\p{P}\p{S}\p{Z}]+`)
-
-	// 如果整个字符串都是特殊符号，则返回true
-	return chinesePunctuation.MatchString(s)
-}
-
-// IsSpecialCharAlt 另一种实现方式，使用unicode包
-func IsSpecialCharAlt(s string) bool {
-	// 检查是否为空字符串
-	if s == "" {
-		return false
-	}
-
-	// 遍历字符串中的每个Unicode码点
-	for _, r := range s {
-		// 检查是否为标点符号
-		if unicode.IsPunct(r) {
-			return true
-		}
-		// 检查是否为符号
-		if unicode.IsSymbol(r) {
-			return true
-		}
-		// 检查是否为分隔符
-		if unicode.IsSpace(r) {
-			return true
-		}
-	}
-
-	return false
-}
-
 func main() {
-
 	// 创建词典实例
-	// dir, err := os.UserHomeDir()
-	// if err != nil {
-	// 	log.Fatalf("无法获取用户主目录: %v", err)
-	// }
-
-	// dbPath := filepath.Join(dir, "gse_dict_db")
-	dict, err := NewEngine("gse_dict_db")
+	db, err := badger.Default("gse_dict_db")
+	if err != nil {
+		log.Fatalf("创建数据库失败: %v", err)
+	}
+	dict, err := participle.New(db)
 	if err != nil {
 		log.Fatalf("创建词典失败: %v", err)
 	}
@@ -334,9 +38,13 @@ func main() {
 `
 
 	// 从文本学习新词
-	if err := dict.LearnFromText(text); err != nil {
+	learned, err := dict.LearnFromText(text)
+	if err != nil {
 		log.Printf("学习新词失败: %v", err)
 	}
+	for _, entry := range learned {
+		fmt.Printf("学习到新词: %s\n", entry.Content)
+	}
 
 	// 再次分词，查看学习效果
 	newText := `平常中藏财气《西游记》中有这样一段故事，我至今印象深刻：
@@ -37,9 +37,13 @@ func main() {
 `
 
 	// 从文本学习新词
-	if err := dict.LearnFromText(text); err != nil {
+	learned, err := dict.LearnFromText(text)
+	if err != nil {
 		log.Printf("学习新词失败: %v", err)
 	}
+	for _, entry := range learned {
+		fmt.Printf("学习到新词: %s\n", entry.Content)
+	}
 
 	// 再次分词，查看学习效果
 	// 	newText := `平常中藏财气《西游记》中有这样一段故事，我至今印象深刻：
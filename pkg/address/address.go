@@ -0,0 +1,201 @@
+// Package address 基于分词引擎的中文地址解析与脱敏
+//
+// 本包属于v1稳定接口: 导出的名称与行为在v1内保持兼容
+package address
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// Info 表示分析后的地址信息
+type Info struct {
+	Name      string `json:"name"`
+	Honorific string `json:"honorific,omitempty"` // 称谓, 如先生、女士
+	Note      string `json:"note,omitempty"`      // 姓名后括号中的备注
+	Contact   string `json:"contact"`
+	Province  string `json:"province"`
+	City      string `json:"city"`
+	County    string `json:"county"`
+	Detailed  string `json:"detailed"`
+
+	Components Detail `json:"components"` // 详细地址结构
+}
+
+// Parser 收件信息解析器, 从自由文本中拆分姓名、联系方式、省市区与详细地址
+// 解析器只读, 可在多个goroutine中并发使用
+type Parser struct {
+	engine    *participle.Engine
+	provinces []Region
+	cities    []Region
+	counties  []Region
+	aliases   []Alias
+}
+
+// Option 解析器配置项
+type Option func(*Parser)
+
+// WithAliases 解析前将地区别名替换为规范名称, 如"鹏城"替换为"深圳市"
+func WithAliases(aliases []Alias) Option {
+	return func(p *Parser) {
+		p.aliases = append([]Alias(nil), aliases...)
+		sortAliases(p.aliases)
+	}
+}
+
+// NewParser 创建收件信息解析器, engine用于分词, 地区按给定顺序匹配
+func NewParser(engine *participle.Engine, provinces, cities, counties []Region, opts ...Option) *Parser {
+	p := &Parser{engine: engine, provinces: provinces, cities: cities, counties: counties}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// placeholderDetails 表示详细地址未提供的占位词
+var placeholderDetails = []string{"地址内详", "内详"}
+
+// IsAddress 判断字符串是否包含省、市或区县名称
+func (p *Parser) IsAddress(s string) bool {
+	return containsRegion(s, p.provinces) || containsRegion(s, p.cities) || containsRegion(s, p.counties)
+}
+
+// Parse 解析一条收件信息
+func (p *Parser) Parse(input string) Info {
+	info, _ := p.ParseContext(context.Background(), input)
+	return info
+}
+
+// ParseWithBudget 在时间预算内解析一条收件信息, 见ParseContext
+// 预算耗尽时返回已完成步骤的结果与context.DeadlineExceeded
+func (p *Parser) ParseWithBudget(ctx context.Context, input string, budget time.Duration) (Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	return p.ParseContext(ctx, input)
+}
+
+// ParseContext 解析一条收件信息
+// 依次拆分姓名与联系方式、匹配省市区、解析详细地址结构, 每步之后检查ctx,
+// ctx结束时返回已完成步骤的结果与ctx的错误: 未匹配省市区时Detailed为完整的地址部分
+func (p *Parser) ParseContext(ctx context.Context, input string) (Info, error) {
+	return p.parse(ctx, NormalizeAliases(input, p.aliases))
+}
+
+// parse 解析已替换别名的收件信息
+func (p *Parser) parse(ctx context.Context, input string) (Info, error) {
+	input, note := extractNote(input)
+	info := p.analyzeFields(input)
+	info.Name, info.Honorific = splitHonorific(info.Name)
+	info.Note = note
+	info.Detailed = strings.TrimSpace(info.Detailed)
+	if err := ctx.Err(); err != nil {
+		return info, err
+	}
+
+	region := p.parseRegion(info.Detailed)
+	info.Province, info.City, info.County = region.Province, region.City, region.County
+	info.Detailed = region.Detailed
+	for _, placeholder := range placeholderDetails {
+		info.Detailed = strings.TrimSpace(strings.ReplaceAll(info.Detailed, placeholder, ""))
+	}
+	if err := ctx.Err(); err != nil {
+		return info, err
+	}
+
+	info.Components = p.ParseDetail(info.Detailed)
+	return info, nil
+}
+
+// ParseAll 解析可能包含多条收件信息的输入
+// 输入中出现多个联系号码或多个地址时按记录拆分, multiple为true提示调用方结果来自拆分
+func (p *Parser) ParseAll(input string) (infos []Info, multiple bool) {
+	input = NormalizeAliases(input, p.aliases)
+	records := p.SplitRecords(input)
+	for _, record := range records {
+		info, _ := p.parse(context.Background(), record)
+		infos = append(infos, info)
+	}
+	return infos, len(records) > 1
+}
+
+// anchor 记录锚点: 联系号码、省份或城市在输入中的位置
+type anchor struct {
+	kind       int // 0: 联系号码 1: 省份 2: 城市
+	start, end int
+}
+
+// SplitRecords 按联系号码与省市拆分包含多条收件信息的输入
+// 同类锚点在一条记录中重复出现时开始新记录, 记录边界优先取锚点前最近的分隔符
+func (p *Parser) SplitRecords(input string) []string {
+	var anchors []anchor
+	for _, loc := range reEmbeddedNumber.FindAllStringIndex(input, -1) {
+		anchors = append(anchors, anchor{kind: 0, start: loc[0], end: loc[1]})
+	}
+	for kind, regions := range [][]Region{p.provinces, p.cities} {
+		for _, region := range regions {
+			for offset := 0; ; {
+				i := strings.Index(input[offset:], region.Name)
+				if i < 0 {
+					break
+				}
+				start := offset + i
+				offset = start + len(region.Name)
+				anchors = append(anchors, anchor{kind: kind + 1, start: start, end: offset})
+			}
+		}
+	}
+	sort.Slice(anchors, func(i, j int) bool { return anchors[i].start < anchors[j].start })
+
+	var records []string
+	var seen [3]bool
+	recordStart, prevEnd := 0, 0
+	for _, a := range anchors {
+		if a.start < prevEnd {
+			continue
+		}
+		if seen[a.kind] {
+			boundary := a.start
+			if i := lastSpecialChar(input[prevEnd:a.start]); i >= 0 {
+				boundary = prevEnd + i
+			}
+			if record := strings.TrimSpace(input[recordStart:boundary]); record != "" {
+				records = append(records, record)
+			}
+			recordStart = boundary
+			seen = [3]bool{}
+		}
+		seen[a.kind] = true
+		prevEnd = a.end
+	}
+	if record := strings.TrimSpace(input[recordStart:]); record != "" {
+		records = append(records, record)
+	}
+	return records
+}
+
+// lastSpecialChar 返回字符串中最后一个特殊字符或换行之后的位置, 不存在时返回-1
+func lastSpecialChar(s string) int {
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if r == '\n' || r == '\r' || participle.IsPunct(r) {
+			return i
+		}
+		i -= size
+	}
+	return -1
+}
+
+// parseRegion 从地址中匹配省、市、区县, 剩余部分作为详细地址
+func (p *Parser) parseRegion(addressPart string) Info {
+	var info Info
+	info.Province, addressPart = matchRegion(addressPart, p.provinces)
+	info.City, addressPart = matchRegion(addressPart, p.cities)
+	info.County, addressPart = matchRegion(addressPart, p.counties)
+	info.Detailed = strings.TrimSpace(addressPart)
+	return info
+}
@@ -0,0 +1,105 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// expiringContext 前n次调用Err返回nil, 之后返回context.DeadlineExceeded, 用于在指定步骤之间模拟预算耗尽
+type expiringContext struct {
+	context.Context
+	n int
+}
+
+func (c *expiringContext) Err() error {
+	if c.n > 0 {
+		c.n--
+		return nil
+	}
+	return context.DeadlineExceeded
+}
+
+func TestParseContextStages(t *testing.T) {
+	p := testParser(t, false)
+	const input = "张三，13800138000，广东省深圳市南山区深南大道10000号腾讯大厦A座"
+
+	full, err := p.ParseContext(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		checks int
+		want   Info
+	}{
+		{0, Info{Name: "张三", Contact: "13800138000", Detailed: "广东省深圳市南山区深南大道10000号腾讯大厦A座"}},
+		{1, Info{Name: "张三", Contact: "13800138000", Province: "广东省", City: "深圳市", County: "南山区", Detailed: "深南大道10000号腾讯大厦A座"}},
+		{2, full},
+	}
+	for _, tt := range tests {
+		info, err := p.ParseContext(&expiringContext{Context: context.Background(), n: tt.checks}, input)
+		if wantErr := tt.checks < 2; (err != nil) != wantErr || (wantErr && !errors.Is(err, context.DeadlineExceeded)) {
+			t.Errorf("checks %d: err %v", tt.checks, err)
+		}
+		if info != tt.want {
+			t.Errorf("checks %d: info %+v, want %+v", tt.checks, info, tt.want)
+		}
+	}
+
+	if full.Components.Road == "" {
+		t.Errorf("full parse has no components: %+v", full)
+	}
+	if info, err := p.ParseWithBudget(context.Background(), input, time.Nanosecond); !errors.Is(err, context.DeadlineExceeded) || info.Contact == "" {
+		t.Errorf("exhausted budget: %+v, %v", info, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		aliases bool
+		want    Info
+	}{
+		{
+			input: "李四 13912345678 浙江省杭州市西湖区文三路478号华星时代广场B座12楼1203室",
+			want: Info{
+				Name: "李四", Contact: "13912345678", Province: "浙江省", City: "杭州市", County: "西湖区",
+				Detailed:   "文三路478号华星时代广场B座12楼1203室",
+				Components: Detail{Road: "文三路", Number: "478号", Place: "华星时代广场", Building: "B座", Floor: "12楼", Room: "1203室"},
+			},
+		},
+		{
+			input: "北京市朝阳区建国路88号SOHO现代城A座1001室 赵六 15000000000",
+			want: Info{
+				Name: "赵六", Contact: "15000000000", Province: "北京市", County: "朝阳区",
+				Detailed:   "建国路88号SOHO现代城A座1001室",
+				Components: Detail{Road: "建国路", Number: "88号", Place: "SOHO现代城", Building: "A座", Room: "1001室"},
+			},
+		},
+		{
+			input: "陈女士（前台代收）18612345678广东省广州市天河区天河路385号太古汇2座",
+			want: Info{
+				Name: "陈", Honorific: "女士", Note: "前台代收", Contact: "18612345678",
+				Province: "广东省", City: "广州市", County: "天河区", Detailed: "天河路385号太古汇2座",
+				Components: Detail{Road: "天河路", Number: "385号", Place: "太古汇", Building: "2座"},
+			},
+		},
+		{
+			input:   "鹏城南山区科技园深南大道10000号 张先生 13800138000",
+			aliases: true,
+			want: Info{
+				Name: "张", Honorific: "先生", Contact: "13800138000", City: "深圳市", County: "南山区",
+				Detailed:   "科技园深南大道10000号",
+				Components: Detail{Area: "科技园", Road: "深南大道", Number: "10000号"},
+			},
+		},
+		{input: "", want: Info{}},
+	}
+	for _, tt := range tests {
+		if got := testParser(t, tt.aliases).Parse(tt.input); got != tt.want {
+			t.Errorf("Parse(%q)\n got %+v\nwant %+v", tt.input, got, tt.want)
+		}
+	}
+}
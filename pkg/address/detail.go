@@ -0,0 +1,141 @@
+package address
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detail 详细地址结构
+type Detail struct {
+	Area     string `json:"area,omitempty"`     // 道路前的片区, 如科技园南区
+	Road     string `json:"road,omitempty"`     // 道路, 如深南大道
+	Number   string `json:"number,omitempty"`   // 门牌号, 如10000号
+	Place    string `json:"place,omitempty"`    // 门牌号后的建筑或小区名称, 如腾讯大厦
+	Building string `json:"building,omitempty"` // 楼栋, 如A座、2号楼
+	Unit     string `json:"unit,omitempty"`     // 单元
+	Floor    string `json:"floor,omitempty"`    // 楼层
+	Room     string `json:"room,omitempty"`     // 房间号
+}
+
+var (
+	// roadSuffixes 道路后缀, 较长的在前
+	roadSuffixes = []string{"大道", "大街", "胡同", "路", "街", "巷", "弄", "道"}
+	// buildingSuffixes 楼栋后缀
+	buildingSuffixes = []string{"号楼", "栋", "座", "幢"}
+	// reDigits 纯数字或字母数字编号
+	reDigits = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	// reDashRoom "楼栋-单元-房间"的简写, 如3-2-501
+	reDashRoom = regexp.MustCompile(`^(\d{1,3})-(\d{1,2})-(\d{1,5})$`)
+)
+
+// ParseDetail 按"片区 道路 门牌号 建筑 楼栋 单元 楼层 房间"的语法拆解详细地址
+// 基于分词结果进行匹配, 例如"深南"+"大道"合并为道路, "3"+"单元"合并为单元, "3-2-501"拆为楼栋3、单元2与房间501
+func (p *Parser) ParseDetail(detail string) Detail {
+	var result Detail
+	var area, place []string
+
+	tokens := tokenSpans(detail, p.engine.Segment(detail))
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		numeric := reDigits.MatchString(token)
+
+		if n := matchDashRoom(tokens[i:], &result); n > 0 {
+			i += n - 1
+			continue
+		}
+
+		switch {
+		case numeric && (next == "号" || next == "号院") && result.Number == "":
+			result.Number = token + next
+			i++
+		case numeric && slices.Contains(buildingSuffixes, next):
+			result.Building = token + next
+			i++
+		case hasAnySuffix(token, buildingSuffixes) && reDigits.MatchString(trimAnySuffix(token, buildingSuffixes)):
+			result.Building = token
+		case numeric && next == "单元":
+			result.Unit = token + next
+			i++
+		case numeric && (next == "楼" || next == "层"):
+			result.Floor = token + next
+			i++
+		case numeric && (next == "室" || next == "房"):
+			result.Room = token + next
+			i++
+		case numeric && i == len(tokens)-1 && (result.Unit != "" || result.Floor != "" || result.Building != ""):
+			result.Room = token
+		case result.Road == "" && result.Number == "" && hasAnySuffix(token, roadSuffixes):
+			// 仅由方位字与道路后缀组成的词需要与前一个词合并, 如"中关村"+"南大街"
+			if strings.Trim(trimAnySuffix(token, roadSuffixes), "东西南北中") == "" && len(area) > 0 {
+				token = area[len(area)-1] + token
+				area = area[:len(area)-1]
+			}
+			result.Road = token
+		case result.Road == "" && result.Number == "":
+			area = append(area, token)
+		default:
+			place = append(place, token)
+		}
+	}
+
+	result.Area = strings.Join(area, "")
+	result.Place = strings.Join(place, "")
+	return result
+}
+
+// matchDashRoom 匹配以tokens开头的"楼栋-单元-房间"简写并写入result, 返回占用的词数, 不匹配时返回0
+// 分词器通常将"3-2-501"切为"3"、"-"、"2"、"-"、"501", 也可能整体切出; 其后紧跟的"室"或"房"并入房间
+func matchDashRoom(tokens []string, result *Detail) int {
+	n := 1
+	if len(tokens) >= 5 && tokens[1] == "-" && tokens[3] == "-" {
+		n = 5
+	}
+	m := reDashRoom.FindStringSubmatch(strings.Join(tokens[:n], ""))
+	if m == nil {
+		return 0
+	}
+	result.Building, result.Unit, result.Room = m[1], m[2], m[3]
+	if n < len(tokens) && (tokens[n] == "室" || tokens[n] == "房") {
+		result.Room += tokens[n]
+		n++
+	}
+	return n
+}
+
+// tokenSpans 将分词结果映射回原文中的片段并去除空白
+// 分词器会将英文转为小写, 因此按长度截取原文
+func tokenSpans(text string, tokens []string) []string {
+	spans := make([]string, 0, len(tokens))
+	offset := 0
+	for _, token := range tokens {
+		end := offset + len(token)
+		if end > len(text) {
+			break
+		}
+		if span := strings.TrimSpace(text[offset:end]); span != "" {
+			spans = append(spans, span)
+		}
+		offset = end
+	}
+	return spans
+}
+
+// hasAnySuffix 判断字符串是否以任一后缀结尾
+func hasAnySuffix(s string, suffixes []string) bool {
+	return trimAnySuffix(s, suffixes) != s
+}
+
+// trimAnySuffix 去除第一个匹配的后缀
+func trimAnySuffix(s string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return strings.TrimSuffix(s, suffix)
+		}
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package address
+
+import "testing"
+
+func TestParseDetailDashRoom(t *testing.T) {
+	p := testParser(t, false)
+	tests := []struct {
+		detail string
+		want   Detail
+	}{
+		{"福华路88号3-2-501", Detail{Road: "福华路", Number: "88号", Building: "3", Unit: "2", Room: "501"}},
+		{"滨江大道903号4-2-2503室", Detail{Road: "滨江大道", Number: "903号", Building: "4", Unit: "2", Room: "2503室"}},
+		{"科技园3-2-501", Detail{Area: "科技园", Building: "3", Unit: "2", Room: "501"}},
+	}
+	for _, tt := range tests {
+		if got := p.ParseDetail(tt.detail); got != tt.want {
+			t.Errorf("ParseDetail(%q) = %+v, want %+v", tt.detail, got, tt.want)
+		}
+	}
+}
+
+func TestParseDetail(t *testing.T) {
+	p := testParser(t, false)
+	tests := []struct {
+		detail string
+		want   Detail
+	}{
+		{"文三路478号华星时代广场B座12楼1203室", Detail{Road: "文三路", Number: "478号", Place: "华星时代广场", Building: "B座", Floor: "12楼", Room: "1203室"}},
+		{"世纪大道100号", Detail{Road: "世纪大道", Number: "100号"}},
+		{"幸福小区5栋2单元3楼302", Detail{Area: "幸福小区", Building: "5栋", Unit: "2单元", Floor: "3楼", Room: "302"}},
+		{"人民路", Detail{Road: "人民路"}},
+		{"", Detail{}},
+	}
+	for _, tt := range tests {
+		if got := p.ParseDetail(tt.detail); got != tt.want {
+			t.Errorf("ParseDetail(%q) = %+v, want %+v", tt.detail, got, tt.want)
+		}
+	}
+}
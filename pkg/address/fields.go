@@ -0,0 +1,323 @@
+package address
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// field 地址字段类型
+type field int
+
+const (
+	fieldName    field = iota // 姓名
+	fieldContact              // 联系方式
+	fieldAddress              // 地址
+	fieldCount
+)
+
+// honorifics 姓名后的常见称谓
+var honorifics = []string{"先生", "女士", "小姐", "老师", "师傅", "同学", "经理", "总"}
+
+var (
+	// reContact 未分隔输入中的联系方式, 假设联系方式为连续的数字
+	reContact = regexp.MustCompile(`\d+`)
+	// rePhone 手机号
+	rePhone = regexp.MustCompile(`^(\+?86)?1\d{10}$`)
+	// reNumber 其他联系号码, 如座机
+	reNumber = regexp.MustCompile(`^(?:\d{7,20}|0\d{2,3}-\d{7,8})$`)
+	// reEmbeddedNumber 字段中夹带的联系号码
+	reEmbeddedNumber = regexp.MustCompile(`0\d{2,3}-\d{7,8}|\+?\d{7,20}`)
+	// reHanName 纯中文姓名
+	reHanName = regexp.MustCompile(`^\p{Han}{1,4}$`)
+	// reTrailingHan 末尾的中文片段
+	reTrailingHan = regexp.MustCompile(`\p{Han}{2,4}$`)
+	// reNameNote 称谓后紧跟的括号备注, 如"李女士（勿打电话）"
+	reNameNote = regexp.MustCompile(`(\p{Han}{1,3}(?:` + strings.Join(honorifics, "|") + `)收?)\s*[（(]([^）)]*)[）)]`)
+	// addressKeywords 地址常见用字
+	addressKeywords = []string{"省", "市", "区", "县", "镇", "乡", "村", "路", "街", "道", "号", "栋", "楼", "室", "园", "小区"}
+)
+
+// surnames 常见单姓
+const surnames = "王李张刘陈杨黄赵吴周徐孙马朱胡郭何高林罗郑梁谢宋唐许韩冯邓曹彭曾肖田董袁潘于蒋蔡余杜叶程苏魏吕丁任沈姚卢姜崔钟谭陆汪范金石廖贾夏韦付方白邹孟熊秦邱江尹薛闫段雷侯龙史陶黎贺顾毛郝龚邵万钱严覃武戴莫孔向汤常温康施文牛樊葛邢安齐易乔伍庞颜倪庄聂章鲁岳翟殷詹申欧耿关兰焦俞左柳甘祝包宁尚符舒阮柯纪梅童凌毕单季裴霍涂成苗谷盛曲翁冉骆蓝路游辛靳管柴蒙鲍华喻祁蒲房滕屈饶解牟艾尤阳时穆农司卓古吉缪简车项连芦麦褚娄窦戚岑景党宫费卜冷晏席卫米柏宗瞿桂全佟应臧闵苟邬边卞姬师和仇栾隋商刁沙荣巫寇桑郎甄丛仲虞敖巩明佘池查麻苑迟邝"
+
+// compoundSurnames 常见复姓
+var compoundSurnames = []string{"欧阳", "司马", "上官", "诸葛", "东方", "皇甫", "尉迟", "公孙", "慕容", "长孙", "宇文", "司徒", "夏侯", "令狐"}
+
+// addressChars 名字中少见而地址中常见的字
+const addressChars = "省市区县镇乡村路街道巷号楼栋座室园厦场店部门院苑里城湾坊期层"
+
+// numberSuffixes 门牌、楼栋与房间号的后缀, 其后紧跟的姓名常被分词器与后缀合为一词, 如"670号林峰"切为"号林峰"
+var numberSuffixes = []string{"号", "室", "栋", "单元"}
+
+// analyzeFields 分析姓名、联系方式与地址, 地址部分未拆分省市区, 暂存于Detailed
+func (p *Parser) analyzeFields(input string) Info {
+	// 已包含分隔符的输入走结构化快速路径
+	if segments := splitFields(input); len(segments) > 1 {
+		return p.analyzeSeparated(segments)
+	}
+
+	contact := reContact.FindString(input)
+	if contact != "" {
+		input = strings.ReplaceAll(input, contact, "")
+	}
+
+	// 基于任意符号进行分割
+	parts := splitBySpecialChar(input)
+
+	name := ""
+	addressPart := ""
+	if len(parts) > 0 {
+		if !p.IsAddress(parts[0]) {
+			name = parts[0]
+			addressPart = strings.Join(parts[1:], "")
+		} else {
+			// 末尾片段像姓名时作为姓名, 否则并入地址并尝试从地址末尾拆分姓名
+			addressPart = strings.Join(parts, "")
+			if last := parts[len(parts)-1]; len(parts) > 1 && nameScore(last) > 0 {
+				addressPart = strings.Join(parts[:len(parts)-1], "")
+				name = last
+			} else {
+				addressPart, name = p.splitTrailingName(addressPart)
+			}
+		}
+	}
+
+	return Info{Name: strings.TrimSpace(name), Contact: contact, Detailed: addressPart}
+}
+
+// analyzeSeparated 分析已按分隔符拆分的地址信息
+func (p *Parser) analyzeSeparated(segments []string) Info {
+	scores := make([][fieldCount]float64, len(segments))
+	for i, segment := range segments {
+		scores[i] = p.scoreField(segment)
+	}
+	assigned := assignFields(scores)
+
+	// 未分配的片段按原顺序并入地址
+	var address strings.Builder
+	for i, segment := range segments {
+		if i == assigned[fieldName] || i == assigned[fieldContact] {
+			continue
+		}
+		address.WriteString(segment)
+	}
+
+	addressPart, name := address.String(), ""
+	if i := assigned[fieldName]; i >= 0 {
+		name = segments[i]
+	} else {
+		addressPart, name = p.splitTrailingName(addressPart)
+	}
+
+	info := Info{Name: name, Detailed: addressPart}
+	if i := assigned[fieldContact]; i >= 0 {
+		info.Contact = segments[i]
+	}
+	return info
+}
+
+// extractNote 提取姓名后的括号备注, 返回去除备注后的输入与备注内容
+// 地址中的括号(如"科技园(南区)")不受影响
+func extractNote(input string) (string, string) {
+	m := reNameNote.FindStringSubmatchIndex(input)
+	if m == nil {
+		return input, ""
+	}
+	note := strings.TrimSpace(input[m[4]:m[5]])
+	return input[:m[0]] + input[m[2]:m[3]] + input[m[1]:], note
+}
+
+// splitHonorific 去除姓名后的"收"字并拆分称谓, 如"张先生收"拆为"张"与"先生"
+func splitHonorific(name string) (string, string) {
+	name = strings.TrimSuffix(name, "收")
+	for _, honorific := range honorifics {
+		if surname := strings.TrimSuffix(name, honorific); surname != name && surname != "" {
+			return surname, honorific
+		}
+	}
+	return name, ""
+}
+
+// splitFields 按分隔符拆分输入, 并将夹带的联系号码拆为独立字段
+func splitFields(input string) []string {
+	var segments []string
+	for _, part := range splitBySpecialChar(input) {
+		last := 0
+		for _, loc := range reEmbeddedNumber.FindAllStringIndex(part, -1) {
+			if loc[0] > last {
+				segments = append(segments, part[last:loc[0]])
+			}
+			segments = append(segments, part[loc[0]:loc[1]])
+			last = loc[1]
+		}
+		if last < len(part) {
+			segments = append(segments, part[last:])
+		}
+	}
+	return segments
+}
+
+// scoreField 计算字段片段属于各字段类型的得分
+func (p *Parser) scoreField(segment string) [fieldCount]float64 {
+	var score [fieldCount]float64
+
+	switch {
+	case rePhone.MatchString(segment):
+		score[fieldContact] = 1
+	case reNumber.MatchString(segment):
+		score[fieldContact] = 0.8
+	}
+
+	if p.IsAddress(segment) {
+		score[fieldAddress] = 1
+	} else {
+		for _, keyword := range addressKeywords {
+			if strings.Contains(segment, keyword) {
+				score[fieldAddress] = 0.5
+				break
+			}
+		}
+	}
+
+	if score[fieldAddress] < 1 {
+		score[fieldName] = nameScore(segment)
+	}
+
+	return score
+}
+
+// nameScore 计算字符串为姓名的得分
+// 以常见姓氏开头且名字部分不含地址用字时得分较高, 如"张伟"; "南区"虽以"南"开头但含"区", 得分为0
+func nameScore(s string) float64 {
+	if !reHanName.MatchString(s) {
+		return 0
+	}
+
+	given := ""
+	for _, surname := range compoundSurnames {
+		if strings.HasPrefix(s, surname) {
+			given = strings.TrimPrefix(s, surname)
+			break
+		}
+	}
+	if given == "" {
+		first, size := utf8.DecodeRuneInString(s)
+		if !strings.ContainsRune(surnames, first) {
+			return 0
+		}
+		given = s[size:]
+	}
+
+	score := 0.6
+	if n := utf8.RuneCountInString(given); n >= 1 && n <= 2 {
+		score += 0.3
+	}
+	for _, r := range given {
+		if strings.ContainsRune(addressChars, r) {
+			score -= 0.5
+		}
+	}
+	return max(score, 0)
+}
+
+// splitTrailingName 从地址末尾拆分出姓名, 如"科技园张伟"拆为"科技园"与"张伟", "3-2-501李四"拆为"3-2-501"与"李四"
+// 候选姓名需以常见姓氏开头, 且与前文的分界与分词结果一致, 避免将"科技园南区"中的"南区"视为姓名;
+// 前文以数字或门牌、房间号后缀结尾时不要求分词边界, 如"670号林峰"拆为"670号"与"林峰"
+func (p *Parser) splitTrailingName(address string) (string, string) {
+	tail := reTrailingHan.FindString(address)
+	if tail == "" {
+		return address, ""
+	}
+
+	// 分词边界: 候选姓名必须从某个词的开头开始
+	boundaries := make(map[int]bool)
+	offset := 0
+	for _, token := range p.engine.Segment(address) {
+		boundaries[offset] = true
+		offset += len(token)
+	}
+
+	bestScore, bestStart := 0.0, -1
+	for start := len(address) - len(tail); start < len(address); {
+		candidate := address[start:]
+		prefix := address[:start]
+		if n := utf8.RuneCountInString(candidate); n >= 2 && n <= 4 && prefix != "" && (boundaries[start] || endsWithNumber(prefix)) {
+			score := nameScore(candidate)
+			// 前文以地址用字或门牌、房间号结尾时, 地址在此处结束的可能性较大
+			if last, _ := utf8.DecodeLastRuneInString(prefix); strings.ContainsRune(addressChars, last) || unicode.IsDigit(last) {
+				score += 0.3
+			}
+			if score > bestScore {
+				bestScore, bestStart = score, start
+			}
+		}
+		_, size := utf8.DecodeRuneInString(address[start:])
+		start += size
+	}
+
+	if bestStart < 0 || bestScore < 1 {
+		return address, ""
+	}
+	return address[:bestStart], address[bestStart:]
+}
+
+// endsWithNumber 判断字符串是否以数字或门牌、房间号后缀结尾
+func endsWithNumber(s string) bool {
+	last, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsDigit(last) || hasAnySuffix(s, numberSuffixes)
+}
+
+// assignFields 为每个字段类型选择得分总和最高的片段, 片段最多分配给一个字段
+// 返回每个字段类型对应的片段下标, -1表示未分配
+func assignFields(scores [][fieldCount]float64) [fieldCount]int {
+	var best, current [fieldCount]int
+	bestScore := -1.0
+	used := make([]bool, len(scores))
+
+	var search func(f field, total float64)
+	search = func(f field, total float64) {
+		if f == fieldCount {
+			if total > bestScore {
+				bestScore = total
+				best = current
+			}
+			return
+		}
+
+		current[f] = -1
+		search(f+1, total)
+		for i, score := range scores {
+			if used[i] || score[f] == 0 {
+				continue
+			}
+			used[i] = true
+			current[f] = i
+			search(f+1, total+score[f])
+			used[i] = false
+		}
+	}
+	search(fieldName, 0)
+	return best
+}
+
+// splitBySpecialChar 基于特殊字符与换行分割字符串并去除空片段
+// 多行粘贴的收件信息每行通常是一个字段, 换行总是作为分隔符;
+// "-"不作为分隔符, 以保留固定电话区号与门牌号如"3-2-501"
+func splitBySpecialChar(s string) []string {
+	var result []string
+	for _, part := range participle.SplitBySpecialChar(s, participle.BreakFunc(isFieldBreak), participle.NonBreaking("-")) {
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// isFieldBreak 判断字符是否为字段分隔符: 换行、标点、符号或空格
+func isFieldBreak(r rune) bool {
+	return r == '\n' || r == '\r' || participle.IsPunct(r)
+}
@@ -0,0 +1,50 @@
+package address
+
+import "testing"
+
+func TestTrailingName(t *testing.T) {
+	p := testParser(t, false)
+	tests := []struct {
+		input string
+		name  string
+		room  string
+	}{
+		{"广东省深圳市福田区福华路88号3-2-501李四13800138000", "李四", "501"},
+		{"广东省深圳市南山区科技园张伟13800138000", "张伟", ""},
+		{"广东省深圳市福田区福华路88号3-2-501室王芳13800138000", "王芳", "501室"},
+		{"广东省深圳市南山区科技园南区13800138000", "", ""},
+		{"广东省深圳市福田区福华路88号万达广场13800138000", "", ""},
+	}
+	for _, tt := range tests {
+		info := p.Parse(tt.input)
+		if info.Name != tt.name || info.Components.Room != tt.room {
+			t.Errorf("Parse(%q) name %q room %q, want %q %q", tt.input, info.Name, info.Components.Room, tt.name, tt.room)
+		}
+	}
+}
+
+func TestTrailingNameAfterNumber(t *testing.T) {
+	// 分词器将门牌号后缀与姓名合为一词"号林峰"时, 仍按字拆出姓名并保留门牌号
+	info := testParser(t, false).Parse("黑龙江省鸡西市恒山区科技园青年路670号林峰18819753678")
+	if info.Name != "林峰" || info.Detailed != "科技园青年路670号" || info.Components.Number != "670号" || info.Components.Place != "" {
+		t.Errorf("name %q detailed %q number %q place %q", info.Name, info.Detailed, info.Components.Number, info.Components.Place)
+	}
+}
+
+func TestMultiLineFields(t *testing.T) {
+	p := testParser(t, false)
+	tests := []struct {
+		input, name, contact, detailed string
+	}{
+		{"广东省深圳市南山区科技园科技大厦\n欧阳娜娜\n13800138000", "欧阳娜娜", "13800138000", "科技园科技大厦"},
+		{"13800138000\r\n广东省深圳市南山区高新南一道9号金色家园2503\r\n黄晓明", "黄晓明", "13800138000", "高新南一道9号金色家园2503"},
+		{"黄晓明\n广东省深圳市南山区科技园科技大厦 13800138000", "黄晓明", "13800138000", "科技园科技大厦"},
+	}
+	for _, tt := range tests {
+		info := p.Parse(tt.input)
+		if info.Name != tt.name || info.Contact != tt.contact || info.Detailed != tt.detailed {
+			t.Errorf("Parse(%q) = name %q contact %q detailed %q, want %q %q %q",
+				tt.input, info.Name, info.Contact, info.Detailed, tt.name, tt.contact, tt.detailed)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package address
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"张三13800138000广东省深圳市南山区科技园",
+		"李女士（勿打电话），0755-12345678，鹏城福田区福华路88号3-2-501",
+		"\xff\xfe广东省\xe4\xb8深圳市",
+		"省市区县镇乡村路街道号栋楼室",
+		"（（（））），，，。。。、、、",
+		"13800138000 13800138001 13800138002",
+		strings.Repeat("广东省深圳市", 500),
+		strings.Repeat("张", 4096),
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, aliases := range []bool{false, true} {
+			p := testParser(t, aliases)
+			info := p.Parse(input)
+			if info.Contact != "" && !strings.Contains(input, strings.TrimPrefix(info.Contact, "+")) {
+				t.Fatalf("contact %q not in input %q", info.Contact, input)
+			}
+			Anonymize(info, MaskPartial)
+			Anonymize(info, MaskStrict)
+			p.ParseAll(input)
+			p.ParseWithBudget(context.Background(), input, time.Nanosecond)
+		}
+	})
+}
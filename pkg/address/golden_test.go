@@ -0,0 +1,158 @@
+package address
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// goldenCorpus 带期望输出的地址语料, 语料格式或生成方式变化时递增版本号
+// 每行一个JSON对象: input为输入, aliases表示使用地区别名, want为人工核对的期望解析结果,
+// known_bad非空表示解析器当前无法得到want, 内容为原因
+const goldenCorpus = "testdata/corpus_v1.jsonl"
+
+// update 以当前解析结果覆盖语料中的期望输出, 解析器行为有意变化时使用: go test ./pkg/address -run Golden -update
+// 已知失败的记录保留人工核对的期望输出, 解析结果与之一致时清除known_bad
+var update = flag.Bool("update", false, "rewrite expected output in "+goldenCorpus)
+
+var (
+	parserOnce sync.Once
+	parsers    [2]*Parser // 0: 不使用别名 1: 使用examples/dict/alias.json中的别名
+	parserErr  error
+)
+
+// testParser 创建使用GSE完整词典与examples/dict地区数据的解析器, 同一测试进程内共享
+func testParser(t testing.TB, aliases bool) *Parser {
+	t.Helper()
+	parserOnce.Do(func() {
+		engine, err := participle.NewMemory()
+		if err != nil {
+			parserErr = err
+			return
+		}
+		var regions [3][]Region
+		for i, name := range []string{"province", "city", "county"} {
+			if regions[i], err = LoadRegions("../../examples/dict/" + name + ".json"); err != nil {
+				parserErr = err
+				return
+			}
+		}
+		aliasList, err := LoadAliases("../../examples/dict/alias.json")
+		if err != nil {
+			parserErr = err
+			return
+		}
+		parsers[0] = NewParser(engine, regions[0], regions[1], regions[2])
+		parsers[1] = NewParser(engine, regions[0], regions[1], regions[2], WithAliases(aliasList))
+	})
+	if parserErr != nil {
+		t.Fatal(parserErr)
+	}
+	if aliases {
+		return parsers[1]
+	}
+	return parsers[0]
+}
+
+// goldenCase 语料中的一条记录
+type goldenCase struct {
+	Input    string `json:"input"`
+	Aliases  bool   `json:"aliases,omitempty"`
+	KnownBad string `json:"known_bad,omitempty"` // 已知失败的原因
+	Want     Info   `json:"want"`
+}
+
+func TestGoldenCorpus(t *testing.T) {
+	data, err := os.ReadFile(goldenCorpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []goldenCase
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var c goldenCase
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			t.Fatalf("%s:%d: %v", goldenCorpus, len(cases)+1, err)
+		}
+		cases = append(cases, c)
+	}
+
+	if *update {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		for i := range cases {
+			got := testParser(t, cases[i].Aliases).Parse(cases[i].Input)
+			switch {
+			case cases[i].KnownBad == "":
+				cases[i].Want = got
+			case reflect.DeepEqual(got, cases[i].Want):
+				cases[i].KnownBad = ""
+			}
+			if err := enc.Encode(cases[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(goldenCorpus, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("updated %d cases in %s", len(cases), goldenCorpus)
+		return
+	}
+
+	// 按字段统计回归, 便于判断解析器修改的影响范围; 已知失败单独计数, 修复后须清除known_bad
+	regressions := make(map[string]int)
+	failed, knownBad := 0, 0
+	for i, c := range cases {
+		got := testParser(t, c.Aliases).Parse(c.Input)
+		if c.KnownBad != "" {
+			if reflect.DeepEqual(got, c.Want) {
+				t.Errorf("%s:%d %q: known bad case now passes, remove known_bad %q", goldenCorpus, i+1, c.Input, c.KnownBad)
+			} else {
+				knownBad++
+			}
+			continue
+		}
+		if reflect.DeepEqual(got, c.Want) {
+			continue
+		}
+		failed++
+		fields := diffFields(got, c.Want)
+		for _, f := range fields {
+			regressions[f]++
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(c.Want)
+		t.Errorf("%s:%d %q: fields %v differ\n got: %s\nwant: %s", goldenCorpus, i+1, c.Input, fields, gotJSON, wantJSON)
+	}
+	if failed > 0 {
+		t.Logf("%d/%d cases differ, by field: %v", failed, len(cases), regressions)
+	}
+	if knownBad > 0 {
+		t.Logf("%d/%d cases are known bad", knownBad, len(cases))
+	}
+}
+
+// diffFields 返回两个解析结果中不同的字段名, 详细地址结构的字段以components.为前缀
+func diffFields(got, want Info) []string {
+	var fields []string
+	compare := func(prefix string, a, b reflect.Value) {
+		for i := 0; i < a.NumField(); i++ {
+			if a.Type().Field(i).Type.Kind() == reflect.String && a.Field(i).String() != b.Field(i).String() {
+				fields = append(fields, prefix+a.Type().Field(i).Name)
+			}
+		}
+	}
+	compare("", reflect.ValueOf(got), reflect.ValueOf(want))
+	compare("components.", reflect.ValueOf(got.Components), reflect.ValueOf(want.Components))
+	sort.Strings(fields)
+	return fields
+}
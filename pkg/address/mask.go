@@ -0,0 +1,71 @@
+package address
+
+import "unicode/utf8"
+
+// MaskLevel 脱敏级别
+type MaskLevel int
+
+const (
+	MaskNone    MaskLevel = iota // 不脱敏
+	MaskPartial                  // 部分脱敏: 张*、138****8000、南山区**园, 详细地址结构各字段只保留最后一个字如***道、**号
+	MaskStrict                   // 严格脱敏: 仅保留姓氏、号码后四位与省市区, 清空详细地址结构
+)
+
+// Anonymize 对地址信息进行脱敏, 用于日志与展示
+// 备注可能包含任意内容, 两种级别均全部替换
+func Anonymize(info Info, level MaskLevel) Info {
+	switch level {
+	case MaskPartial:
+		info.Name = maskRunes(info.Name, 1, 0)
+		info.Contact = maskContact(info.Contact)
+		info.Detailed = maskRunes(info.Detailed, 0, 1)
+		info.Note = maskRunes(info.Note, 0, 0)
+		info.Components = maskDetail(info.Components)
+	case MaskStrict:
+		info.Name = maskRunes(info.Name, 1, 0)
+		info.Contact = maskRunes(info.Contact, 0, 4)
+		info.Detailed = maskRunes(info.Detailed, 0, 0)
+		info.Note = maskRunes(info.Note, 0, 0)
+		info.Components = Detail{}
+	}
+	return info
+}
+
+// maskDetail 详细地址结构的各字段只保留最后一个字, 保留"路"、"号"、"室"等类型后缀
+func maskDetail(d Detail) Detail {
+	for _, field := range []*string{&d.Area, &d.Road, &d.Number, &d.Place, &d.Building, &d.Unit, &d.Floor, &d.Room} {
+		*field = maskRunes(*field, 0, 1)
+	}
+	return d
+}
+
+// maskContact 联系号码保留前三位与后四位
+func maskContact(contact string) string {
+	if utf8.RuneCountInString(contact) < 8 {
+		return maskRunes(contact, 0, 2)
+	}
+	return maskRunes(contact, 3, 4)
+}
+
+// maskRunes 保留前head个与后tail个字符, 其余替换为*
+// 字符串不足以保留时至少替换一个字符
+func maskRunes(s string, head, tail int) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	if head+tail >= len(runes) {
+		if len(runes) == 1 {
+			return "*"
+		}
+		if head > 0 {
+			head, tail = len(runes)-1, 0
+		} else {
+			head, tail = 0, len(runes)-1
+		}
+	}
+	for i := head; i < len(runes)-tail; i++ {
+		runes[i] = '*'
+	}
+	return string(runes)
+}
@@ -0,0 +1,68 @@
+package address
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeComponents(t *testing.T) {
+	info := Info{
+		Name:     "张三",
+		Note:     "勿打电话13800138000",
+		Contact:  "13800138000",
+		Province: "广东省",
+		City:     "深圳市",
+		County:   "南山区",
+		Detailed: "科技园南区深南大道10000号腾讯大厦A座3单元501室",
+		Components: Detail{
+			Area: "科技园南区", Road: "深南大道", Number: "10000号", Place: "腾讯大厦",
+			Building: "A座", Unit: "3单元", Floor: "5楼", Room: "501室",
+		},
+	}
+
+	partial := Anonymize(info, MaskPartial)
+	want := Detail{
+		Area: "****区", Road: "***道", Number: "*****号", Place: "***厦",
+		Building: "*座", Unit: "**元", Floor: "*楼", Room: "***室",
+	}
+	if partial.Components != want {
+		t.Errorf("partial components = %+v, want %+v", partial.Components, want)
+	}
+	if strings.Trim(partial.Note, "*") != "" {
+		t.Errorf("partial note = %q, want fully masked", partial.Note)
+	}
+
+	strict := Anonymize(info, MaskStrict)
+	if strict.Components != (Detail{}) {
+		t.Errorf("strict components = %+v, want empty", strict.Components)
+	}
+	if strict.Province != "广东省" || strict.County != "南山区" {
+		t.Errorf("strict region = %s%s, want kept", strict.Province, strict.County)
+	}
+
+	if none := Anonymize(info, MaskNone); none != info {
+		t.Errorf("MaskNone changed info: %+v", none)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	info := Info{Name: "欧阳娜娜", Contact: "13800138000", Province: "广东省", Detailed: "深南大道10000号"}
+	tests := []struct {
+		level MaskLevel
+		want  Info
+	}{
+		{MaskPartial, Info{Name: "欧***", Contact: "138****8000", Province: "广东省", Detailed: "*********号"}},
+		{MaskStrict, Info{Name: "欧***", Contact: "*******8000", Province: "广东省", Detailed: "**********"}},
+	}
+	for _, tt := range tests {
+		if got := Anonymize(info, tt.level); got != tt.want {
+			t.Errorf("level %d: %+v, want %+v", tt.level, got, tt.want)
+		}
+	}
+
+	// 字段过短时至少替换一个字符
+	short := Anonymize(Info{Name: "李", Contact: "110"}, MaskPartial)
+	if short.Name != "*" || short.Contact != "*10" {
+		t.Errorf("short fields = %q %q, want \"*\" \"*10\"", short.Name, short.Contact)
+	}
+}
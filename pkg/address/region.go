@@ -0,0 +1,97 @@
+package address
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Region 表示地区信息
+type Region struct {
+	Name string `json:"name"`
+	GB   string `json:"gb"`
+}
+
+// Alias 地区别名
+type Alias struct {
+	Alias string // 别名
+	Name  string // 规范名称
+}
+
+// LoadRegions 从JSON文件中加载地区信息, 文件内容为Region数组
+func LoadRegions(filePath string) ([]Region, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var regions []Region
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+// LoadAliases 从JSON文件中加载地区别名表, 文件格式为规范名称到别名列表的映射
+// 返回按别名长度降序排列的别名, 保证较长的别名优先匹配
+func LoadAliases(filePath string) ([]Alias, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var table map[string][]string
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+
+	var aliases []Alias
+	for name, list := range table {
+		for _, alias := range list {
+			aliases = append(aliases, Alias{Alias: alias, Name: name})
+		}
+	}
+	sortAliases(aliases)
+	return aliases, nil
+}
+
+// sortAliases 按别名长度降序排序, 长度相同时按别名排序
+func sortAliases(aliases []Alias) {
+	sort.Slice(aliases, func(i, j int) bool {
+		if len(aliases[i].Alias) != len(aliases[j].Alias) {
+			return len(aliases[i].Alias) > len(aliases[j].Alias)
+		}
+		return aliases[i].Alias < aliases[j].Alias
+	})
+}
+
+// NormalizeAliases 将输入中的地区别名替换为规范名称, aliases需按别名长度降序排列
+// 输入中已包含规范名称时不做替换, 避免"深圳"匹配到"深圳市"中
+func NormalizeAliases(input string, aliases []Alias) string {
+	for _, a := range aliases {
+		if strings.Contains(input, a.Name) || !strings.Contains(input, a.Alias) {
+			continue
+		}
+		input = strings.Replace(input, a.Alias, a.Name, 1)
+	}
+	return input
+}
+
+// containsRegion 判断字符串是否包含任一地区名称
+func containsRegion(s string, regions []Region) bool {
+	for _, r := range regions {
+		if strings.Contains(s, r.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRegion 返回字符串中第一个匹配的地区名称, 并从字符串中去除该名称
+func matchRegion(s string, regions []Region) (name, rest string) {
+	for _, r := range regions {
+		if strings.Contains(s, r.Name) {
+			return r.Name, strings.ReplaceAll(s, r.Name, "")
+		}
+	}
+	return "", s
+}
@@ -0,0 +1,97 @@
+package address
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFile 在临时目录中写入文件并返回路径
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRegions(t *testing.T) {
+	path := writeFile(t, "city.json", `[{"name":"深圳市","gb":"156440300"},{"name":"广州市","gb":"156440100"}]`)
+	regions, err := LoadRegions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Region{{Name: "深圳市", GB: "156440300"}, {Name: "广州市", GB: "156440100"}}
+	if !slices.Equal(regions, want) {
+		t.Errorf("LoadRegions = %+v, want %+v", regions, want)
+	}
+
+	if _, err := LoadRegions(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file loaded without error")
+	}
+	if _, err := LoadRegions(writeFile(t, "bad.json", `{"name":"深圳市"}`)); err == nil {
+		t.Error("malformed file loaded without error")
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	path := writeFile(t, "alias.json", `{"深圳市":["深圳","鹏城"],"广东省":["粤"],"上海市":["上海","魔都"]}`)
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按别名长度降序, 长度相同时按别名排序
+	want := []Alias{
+		{Alias: "上海", Name: "上海市"},
+		{Alias: "深圳", Name: "深圳市"},
+		{Alias: "魔都", Name: "上海市"},
+		{Alias: "鹏城", Name: "深圳市"},
+		{Alias: "粤", Name: "广东省"},
+	}
+	if !slices.Equal(aliases, want) {
+		t.Errorf("LoadAliases = %+v, want %+v", aliases, want)
+	}
+}
+
+func TestNormalizeAliases(t *testing.T) {
+	aliases := []Alias{
+		{Alias: "深圳", Name: "深圳市"},
+		{Alias: "鹏城", Name: "深圳市"},
+		{Alias: "粤", Name: "广东省"},
+	}
+	tests := []struct {
+		input, want string
+	}{
+		{"粤鹏城南山区", "广东省深圳市南山区"},
+		{"深圳南山区", "深圳市南山区"},
+		{"深圳市南山区", "深圳市南山区"}, // 已包含规范名称时不替换
+		{"北京市朝阳区", "北京市朝阳区"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAliases(tt.input, aliases); got != tt.want {
+			t.Errorf("NormalizeAliases(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRegion(t *testing.T) {
+	regions := []Region{{Name: "广东省"}, {Name: "浙江省"}}
+	tests := []struct {
+		input, name, rest string
+	}{
+		{"广东省深圳市", "广东省", "深圳市"},
+		{"杭州市浙江省", "浙江省", "杭州市"},
+		{"深圳市南山区", "", "深圳市南山区"},
+	}
+	for _, tt := range tests {
+		name, rest := matchRegion(tt.input, regions)
+		if name != tt.name || rest != tt.rest {
+			t.Errorf("matchRegion(%q) = %q, %q, want %q, %q", tt.input, name, rest, tt.name, tt.rest)
+		}
+		if got := containsRegion(tt.input, regions); got != (tt.name != "") {
+			t.Errorf("containsRegion(%q) = %v", tt.input, got)
+		}
+	}
+}
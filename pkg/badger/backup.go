@@ -0,0 +1,219 @@
+package badger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// backupCatalogPrefix 备份目录的键前缀, 键为前缀+备份文件名
+// 位于participle.MetaKeyPrefix之下, 加载词典时跳过
+const backupCatalogPrefix = "\x00nla:backup:"
+
+const (
+	defaultKeepDaily  = 7 // 默认保留的每日备份数
+	defaultKeepWeekly = 4 // 默认保留的每周备份数
+)
+
+// BackupRecord 备份目录中的一条记录
+type BackupRecord struct {
+	Name    string    `json:"name"`    // 备份文件名
+	Time    time.Time `json:"time"`    // 备份时间
+	Size    int64     `json:"size"`    // 文件大小(字节)
+	Version uint64    `json:"version"` // 备份包含的最大数据版本
+}
+
+// Retention 备份保留规则
+// 按本地时间分组, 每天、每周(ISO周)各保留最新的一个备份; 同一备份可同时满足两条规则, 最新的备份始终保留
+type Retention struct {
+	Daily  int // 保留最近几天的每日备份
+	Weekly int // 保留最近几周的每周备份
+}
+
+// keep 返回按规则保留的备份名称, records须按时间降序
+func (r Retention) keep(records []BackupRecord) map[string]bool {
+	keep := make(map[string]bool)
+	if len(records) > 0 {
+		keep[records[0].Name] = true
+	}
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for _, record := range records {
+		t := record.Time.Local()
+		if day := t.Format(time.DateOnly); !days[day] && len(days) < r.Daily {
+			days[day] = true
+			keep[record.Name] = true
+		}
+		year, week := t.ISOWeek()
+		if key := fmt.Sprintf("%d-%02d", year, week); !weeks[key] && len(weeks) < r.Weekly {
+			weeks[key] = true
+			keep[record.Name] = true
+		}
+	}
+	return keep
+}
+
+// BackupOption 备份管理配置项
+type BackupOption func(*BackupManager)
+
+// WithRetention 设置备份保留规则, 默认保留7个每日备份与4个每周备份
+func WithRetention(r Retention) BackupOption {
+	return func(m *BackupManager) { m.retention = r }
+}
+
+// BackupMessage 定时备份错误处理
+type BackupMessage func(err error)
+
+// BackupManager 备份管理: 将数据库备份到目录, 在数据库中记录备份目录, 并按保留规则清理过期备份
+type BackupManager struct {
+	engine    *Engine
+	dir       string
+	retention Retention
+
+	done chan struct{} // 退出信号
+	stop chan struct{} // 退出成功信号
+}
+
+// NewBackupManager 创建备份管理, 备份文件写入dir, 目录不存在时逐级创建
+func NewBackupManager(e *Engine, dir string, opts ...BackupOption) *BackupManager {
+	m := &BackupManager{
+		engine:    e,
+		dir:       filepath.FromSlash(dir),
+		retention: Retention{Daily: defaultKeepDaily, Weekly: defaultKeepWeekly},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Snapshot 创建一个完整备份并记录到备份目录
+func (m *BackupManager) Snapshot() (BackupRecord, error) {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return BackupRecord{}, err
+	}
+	now := time.Now()
+	record := BackupRecord{Name: fmt.Sprintf("backup_%s.bak", now.UTC().Format("20060102T150405.000000000Z")), Time: now}
+
+	path := filepath.Join(m.dir, record.Name)
+	f, err := os.Create(path)
+	if err != nil {
+		return BackupRecord{}, err
+	}
+	record.Version, err = m.engine.db.Backup(f, 0)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return BackupRecord{}, fmt.Errorf("backup db fail: %v", err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		record.Size = info.Size()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return BackupRecord{}, err
+	}
+	if err := m.engine.Set([]byte(backupCatalogPrefix+record.Name), data); err != nil {
+		os.Remove(path)
+		return BackupRecord{}, fmt.Errorf("save backup catalog fail: %v", err)
+	}
+	return record, nil
+}
+
+// Catalog 返回备份目录中的全部记录, 按时间降序
+func (m *BackupManager) Catalog() ([]BackupRecord, error) {
+	var records []BackupRecord
+	err := m.engine.Scan([]byte(backupCatalogPrefix), func(_, value []byte) error {
+		var record BackupRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read backup catalog fail: %v", err)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+	return records, nil
+}
+
+// Prune 按保留规则删除过期的备份文件及其记录, 返回被删除的记录
+// dryRun为true时只返回将被删除的记录, 不做修改; 备份文件已不存在时只删除记录
+func (m *BackupManager) Prune(dryRun bool) ([]BackupRecord, error) {
+	records, err := m.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	keep := m.retention.keep(records)
+
+	var pruned []BackupRecord
+	for _, record := range records {
+		if keep[record.Name] {
+			continue
+		}
+		if !dryRun {
+			err := os.Remove(filepath.Join(m.dir, record.Name))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return pruned, err
+			}
+			if err := m.engine.Del([]byte(backupCatalogPrefix + record.Name)); err != nil {
+				return pruned, fmt.Errorf("delete backup catalog fail: %v", err)
+			}
+		}
+		pruned = append(pruned, record)
+	}
+	return pruned, nil
+}
+
+// Start 启动定时备份, 每个周期创建备份后按保留规则清理, 错误交由bm处理, bm可为nil
+func (m *BackupManager) Start(interval time.Duration, bm BackupMessage) {
+	m.done = make(chan struct{})
+	m.stop = make(chan struct{})
+	go m.listener(interval, bm)
+}
+
+// listener 监听备份周期
+func (m *BackupManager) listener(interval time.Duration, bm BackupMessage) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(m.stop)
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.run(); err != nil && bm != nil {
+				bm(err)
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// run 创建备份并清理过期备份
+func (m *BackupManager) run() error {
+	if _, err := m.Snapshot(); err != nil {
+		return err
+	}
+	_, err := m.Prune(false)
+	return err
+}
+
+// Stop 停止定时备份, 等待进行中的备份完成
+func (m *BackupManager) Stop() {
+	if m.done == nil {
+		return
+	}
+	close(m.done)
+	<-m.stop
+	m.done = nil
+}
@@ -0,0 +1,48 @@
+package badger
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// SetAnyValueTTL 设置任意参数并指定过期时间
+// 通过gob序列化数据后存储, 与SetAny对应; SetAnyTTL的参数类型在v1中保持为[]byte
+func (e *Engine) SetAnyValueTTL(key, value any, ttl time.Duration) error {
+	var keyBuf bytes.Buffer
+	var valBuf bytes.Buffer
+	if err := gob.NewEncoder(&keyBuf).Encode(key); err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(&valBuf).Encode(value); err != nil {
+		return err
+	}
+	return e.SetTTL(keyBuf.Bytes(), valBuf.Bytes(), ttl)
+}
+
+// 以下为v1之前的名称, 保留以兼容已有代码, 将在下一个主版本中移除
+
+// BadgerTX 事务函数
+//
+// Deprecated: 使用TxFunc
+type BadgerTX = TxFunc
+
+// BadgerBatch 批量写入函数
+//
+// Deprecated: 使用BatchFunc
+type BadgerBatch = BatchFunc
+
+// TxSet 事务设置参数操作
+//
+// Deprecated: 使用Update
+func (e *Engine) TxSet(tx TxFunc) error { return e.Update(tx) }
+
+// TxGet 事务获取参数操作
+//
+// Deprecated: 使用View
+func (e *Engine) TxGet(tx TxFunc) error { return e.View(tx) }
+
+// GetKey 获取所有key
+//
+// Deprecated: 使用Keys
+func (e *Engine) GetKey(prefix []byte) ([][]byte, error) { return e.Keys(prefix) }
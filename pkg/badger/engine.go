@@ -1,3 +1,6 @@
+// Package badger 对badger数据库的封装, 提供键值读写、扫描、备份与打开时的锁诊断
+//
+// 本包属于v1稳定接口: 导出的名称与行为在v1内保持兼容, 旧名称见compat.go
 package badger
 
 import (
@@ -9,11 +12,14 @@ import (
 
 // Engine badger引擎
 type Engine struct {
-	db *badger.DB // badgerDB
+	db  *badger.DB // badgerDB
+	dir string     // 数据库目录, 内存模式为空
 
-	gcTicker     *time.Ticker       // GC定时器
+	gcTicker     *time.Ticker       // GC定时器, 只由GC协程访问
 	gcInterval   time.Duration      // GC间隔时间
 	gcUpdateChan chan time.Duration // GC更新间隔时间信号
+	gcStop       chan struct{}      // GC协程退出信号
+	gcStopped    chan struct{}      // GC协程退出成功信号
 
 	done             chan struct{} // 退出信号
 	doneSuccessChain chan struct{} // 退出成功信号
@@ -21,30 +27,61 @@ type Engine struct {
 }
 
 // New 创建一个badger引擎
-func New(opt badger.Options) (*Engine, error) {
-	return new(opt)
+func New(opt badger.Options, opts ...OpenOption) (*Engine, error) {
+	return newOpenOptions(opts).retry(func() (*Engine, error) { return new(opt) })
 }
 
 // Default 创建一个默认的badger引擎
-func Default(addr string) (*Engine, error) {
-	return new(badger.DefaultOptions(addr))
+// addr可使用"/"或当前平台的分隔符, 不存在的目录会逐级创建; 目录被占用时返回ErrLocked, 残留锁可通过RemoveStaleLock移除
+func Default(addr string, opts ...OpenOption) (*Engine, error) {
+	return New(badger.DefaultOptions(addr), opts...)
+}
+
+// ReadOptimized 创建一个面向读多写少场景的badger引擎, 增大块缓存并启用索引缓存
+// 大于valueThreshold字节的值写入值日志, LSM树只保存值指针, 只扫描键时更紧凑;
+// 不超过的值与键一同存放在LSM树中, 读取时无需访问值日志. valueThreshold为0时使用badger默认值(1MB)
+func ReadOptimized(addr string, valueThreshold int64, opts ...OpenOption) (*Engine, error) {
+	opt := badger.DefaultOptions(addr).
+		WithBlockCacheSize(512 << 20).
+		WithIndexCacheSize(128 << 20)
+	if valueThreshold > 0 {
+		opt = opt.WithValueThreshold(valueThreshold)
+	}
+	return New(opt, opts...)
 }
 
 // new 创建一个badger引擎
+// 数据库目录不存在时逐级创建; 打开失败时返回*OpenError, 可通过errors.Is判断ErrLocked或ErrPermission
+// 打开成功后在目录中记录当前进程的进程号与主机名, 其他进程打开失败时据此报告持有者
 func new(opt badger.Options) (*Engine, error) {
-	db, err := badger.Open(opt)
+	opt, err := prepareDirs(opt)
 	if err != nil {
 		return nil, err
 	}
+	db, err := badger.Open(opt)
+	if err != nil {
+		if opt.InMemory {
+			return nil, err
+		}
+		return nil, newOpenError(opt.Dir, err)
+	}
+	if !opt.InMemory {
+		// 持有进程信息仅用于错误提示, 写入失败不影响使用
+		writeHolder(opt.Dir)
+	}
 	be := &Engine{
-		db: db,
+		db:  db,
+		dir: opt.Dir,
 
 		gcInterval:   time.Minute * 5,
 		gcUpdateChan: make(chan time.Duration),
+		gcStop:       make(chan struct{}),
+		gcStopped:    make(chan struct{}),
 
 		done:             make(chan struct{}),
 		doneSuccessChain: make(chan struct{}),
 	}
+	be.gcTicker = time.NewTicker(be.gcInterval)
 	be.listener()
 	return be, nil
 }
@@ -62,19 +99,23 @@ func (e *Engine) listener() {
 func (e *Engine) listenerClose() {
 	select {
 	case <-e.done:
+		// 先停止GC协程, 避免关闭后仍访问数据库
+		close(e.gcStop)
+		<-e.gcStopped
 		if err := e.db.Close(); err != nil {
 			e.err = err
 		}
-		e.db = nil
-		e.gcTicker.Stop()
+		if e.dir != "" {
+			removeHolder(e.dir)
+		}
 		e.doneSuccessChain <- struct{}{}
 	}
 }
 
 // listenerGC 监听GC信号
 func (e *Engine) listenerGC() {
-	e.gcTicker = time.NewTicker(e.gcInterval)
-	defer e.gcTicker.Stop()
+	defer close(e.gcStopped)
+	defer func() { e.gcTicker.Stop() }()
 
 	for {
 		select {
@@ -82,6 +123,8 @@ func (e *Engine) listenerGC() {
 			e.db.RunValueLogGC(0.5)
 		case newGcInterval := <-e.gcUpdateChan:
 			e.updateGcInterval(newGcInterval)
+		case <-e.gcStop:
+			return
 		}
 	}
 }
@@ -110,5 +153,8 @@ func (e *Engine) SetGCInterval(interval time.Duration) {
 	if 0 >= interval {
 		return
 	}
-	e.gcUpdateChan <- interval
+	select {
+	case e.gcUpdateChan <- interval:
+	case <-e.gcStopped:
+	}
 }
@@ -0,0 +1,64 @@
+package badger
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// holderFileName 数据库目录中记录持有进程信息的旁路文件
+// badger只在unix下的LOCK文件中写入进程号, 且不记录主机, 打开成功后额外写入该文件, 关闭时删除
+const holderFileName = "nla.holder"
+
+// Holder 打开数据库的进程信息
+type Holder struct {
+	PID   int       `json:"pid"`   // 进程号
+	Host  string    `json:"host"`  // 主机名
+	Since time.Time `json:"since"` // 打开时间
+}
+
+// ReadHolder 读取数据库目录中记录的持有进程信息, 未记录时返回ok为false
+func ReadHolder(dir string) (holder Holder, ok bool, err error) {
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), holderFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Holder{}, false, nil
+	}
+	if err != nil {
+		return Holder{}, false, err
+	}
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return Holder{}, false, nil
+	}
+	return holder, true, nil
+}
+
+// local 判断持有进程是否在本机, 未记录主机时视为本机
+func (h Holder) local() bool {
+	if h.Host == "" {
+		return true
+	}
+	host, err := os.Hostname()
+	return err == nil && host == h.Host
+}
+
+// writeHolder 记录当前进程为数据库的持有进程
+func writeHolder(dir string) error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(Holder{PID: os.Getpid(), Host: host, Since: time.Now()})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, holderFileName), data, 0644)
+}
+
+// removeHolder 删除持有进程信息
+func removeHolder(dir string) error {
+	err := os.Remove(filepath.Join(dir, holderFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package badger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+var (
+	// ErrLocked 数据库目录被其他进程占用
+	ErrLocked = errors.New("badger: database directory is locked by another process")
+	// ErrPermission 没有数据库目录的访问权限
+	ErrPermission = errors.New("badger: permission denied on database directory")
+	// ErrLockActive 锁文件中的进程仍在运行, 不能作为残留锁移除
+	ErrLockActive = errors.New("badger: lock holder is still running")
+)
+
+// lockFileName badger在数据库目录中创建的锁文件, unix下内容为持有锁的进程号
+const lockFileName = "LOCK"
+
+// OpenError 打开数据库失败, 可通过errors.Is判断ErrLocked或ErrPermission
+type OpenError struct {
+	Dir   string // 数据库目录的绝对路径
+	PID   int    // 持有锁的进程号, 0表示未知
+	Host  string // 持有锁的进程所在主机, 空表示未知
+	Stale bool   // 持有锁的进程已不存在, 确认没有其他进程使用数据库后可调用RemoveStaleLock恢复
+	Kind  error  // 错误类型: ErrLocked或ErrPermission, nil表示其他错误
+	Err   error  // badger或文件系统返回的原始错误
+}
+
+// Error 错误信息, 包含处理建议
+func (e *OpenError) Error() string {
+	switch {
+	case e.Kind == ErrLocked && e.Stale:
+		return fmt.Sprintf("open db %s fail: stale lock left by exited process %d, call RemoveStaleLock once no other process uses the directory", e.Dir, e.PID)
+	case e.Kind == ErrLocked && e.PID > 0 && e.Host != "":
+		return fmt.Sprintf("open db %s fail: locked by process %d on host %s, stop it or use another directory", e.Dir, e.PID, e.Host)
+	case e.Kind == ErrLocked && e.PID > 0:
+		return fmt.Sprintf("open db %s fail: locked by process %d, stop it or use another directory", e.Dir, e.PID)
+	case e.Kind == ErrLocked:
+		return fmt.Sprintf("open db %s fail: locked by another process, stop it or use another directory", e.Dir)
+	case e.Kind == ErrPermission:
+		return fmt.Sprintf("open db %s fail: permission denied, check the owner and mode of the directory and its parents: %v", e.Dir, e.Err)
+	default:
+		return fmt.Sprintf("open db %s fail: %v", e.Dir, e.Err)
+	}
+}
+
+// Unwrap 返回错误类型与原始错误
+func (e *OpenError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// prepareDirs 规范化数据库目录并创建不存在的父目录, 内存模式不做处理
+// 路径可使用"/"或当前平台的分隔符
+func prepareDirs(opt badger.Options) (badger.Options, error) {
+	if opt.InMemory {
+		return opt, nil
+	}
+	valueDir := opt.ValueDir
+	dirs := []*string{&opt.Dir, &opt.ValueDir}
+	if valueDir == "" || valueDir == opt.Dir {
+		dirs = dirs[:1]
+	}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(filepath.FromSlash(*dir))
+		if err != nil {
+			return opt, &OpenError{Dir: *dir, Err: err}
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return opt, newOpenError(abs, err)
+		}
+		*dir = abs
+	}
+	if len(dirs) == 1 {
+		opt.ValueDir = opt.Dir
+	}
+	return opt, nil
+}
+
+// newOpenError 按原始错误判断错误类型
+// badger以字符串包装底层错误, 无法通过errors.Is判断, 锁冲突按其错误信息识别
+func newOpenError(dir string, err error) *OpenError {
+	e := &OpenError{Dir: dir, Err: err}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "Another process is using this Badger database"):
+		e.Kind = ErrLocked
+		if holder, ok, herr := ReadHolder(dir); herr == nil && ok {
+			// 其他主机上的进程无法判断是否存活
+			e.PID, e.Host = holder.PID, holder.Host
+			e.Stale = holder.local() && !processAlive(holder.PID)
+		} else if pid, perr := LockHolder(dir); perr == nil && pid > 0 {
+			e.PID, e.Stale = pid, !processAlive(pid)
+		}
+	case errors.Is(err, fs.ErrPermission) || strings.Contains(msg, "permission denied") || strings.Contains(msg, "Access is denied"):
+		e.Kind = ErrPermission
+	}
+	return e
+}
+
+// LockHolder 读取数据库目录锁文件中记录的进程号, 锁文件不存在或未记录进程号时返回0
+// 只有unix下的badger会在锁文件中写入进程号
+func LockHolder(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), lockFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, nil
+	}
+	return pid, nil
+}
+
+// RemoveStaleLock 移除进程异常退出后残留的锁文件与持有进程信息
+// 持有进程在本机且仍在运行时返回ErrLockActive; 无法判断持有进程时(如未记录进程号或在其他主机)直接移除,
+// 调用方须确认没有其他进程正在使用该数据库, 网络文件系统上的锁可能在进程退出后仍然保留
+func RemoveStaleLock(dir string) error {
+	dir = filepath.FromSlash(dir)
+	holder, ok, err := ReadHolder(dir)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if holder.PID, err = LockHolder(dir); err != nil {
+			return err
+		}
+	}
+	if holder.PID > 0 && holder.local() && processAlive(holder.PID) {
+		return &OpenError{Dir: dir, PID: holder.PID, Host: holder.Host, Kind: ErrLocked, Err: ErrLockActive}
+	}
+	err = os.Remove(filepath.Join(dir, lockFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return removeHolder(dir)
+}
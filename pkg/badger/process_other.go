@@ -0,0 +1,15 @@
+//go:build !unix
+
+package badger
+
+import "os"
+
+// processAlive 判断进程是否仍在运行
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
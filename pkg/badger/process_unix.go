@@ -0,0 +1,14 @@
+//go:build unix
+
+package badger
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive 判断进程是否仍在运行, 无权向其发送信号时视为运行中
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
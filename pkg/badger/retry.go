@@ -0,0 +1,101 @@
+package badger
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond // 默认的首次重试间隔
+	defaultRetryMaxBackoff = 2 * time.Second        // 默认的最大重试间隔
+)
+
+// OpenOption 打开数据库的配置项
+type OpenOption func(*openOptions)
+
+// openOptions 打开数据库的配置
+type openOptions struct {
+	ctx        context.Context
+	wait       time.Duration                     // 目录被占用时的最长等待时间, 0表示不等待
+	backoff    time.Duration                     // 首次重试间隔
+	maxBackoff time.Duration                     // 最大重试间隔
+	notify     func(err *OpenError, attempt int) // 每次重试前的回调
+}
+
+// WithLockWait 目录被其他进程占用时等待并重试, 最长等待wait后返回最后一次的ErrLocked
+func WithLockWait(wait time.Duration) OpenOption {
+	return func(o *openOptions) { o.wait = wait }
+}
+
+// WithRetryBackoff 设置重试间隔, 首次为initial, 之后每次翻倍直到maxBackoff, 默认为100ms与2s
+func WithRetryBackoff(initial, maxBackoff time.Duration) OpenOption {
+	return func(o *openOptions) {
+		if initial > 0 {
+			o.backoff = initial
+		}
+		if maxBackoff > 0 {
+			o.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetryNotify 设置每次重试前的回调, err中包含持有进程的进程号与主机名, 可用于输出等待提示
+func WithRetryNotify(fn func(err *OpenError, attempt int)) OpenOption {
+	return func(o *openOptions) { o.notify = fn }
+}
+
+// WithOpenContext 设置等待重试时的上下文, 取消后立即返回
+func WithOpenContext(ctx context.Context) OpenOption {
+	return func(o *openOptions) { o.ctx = ctx }
+}
+
+// newOpenOptions 创建打开数据库的配置
+func newOpenOptions(opts []OpenOption) openOptions {
+	o := openOptions{
+		ctx:        context.Background(),
+		backoff:    defaultRetryBackoff,
+		maxBackoff: defaultRetryMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// retry 目录被占用时按退避间隔重试open, 其他错误立即返回
+func (o openOptions) retry(open func() (*Engine, error)) (*Engine, error) {
+	e, err := open()
+	if err == nil || o.wait <= 0 {
+		return e, err
+	}
+
+	deadline := time.Now().Add(o.wait)
+	backoff := o.backoff
+	for attempt := 1; ; attempt++ {
+		var oe *OpenError
+		if !errors.As(err, &oe) || oe.Kind != ErrLocked {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, err
+		}
+		if o.notify != nil {
+			o.notify(oe, attempt)
+		}
+
+		timer := time.NewTimer(min(backoff, remaining))
+		select {
+		case <-o.ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, o.maxBackoff)
+
+		if e, err = open(); err == nil {
+			return e, nil
+		}
+	}
+}
@@ -9,22 +9,22 @@ import (
 	"github.com/dgraph-io/badger/v4"
 )
 
-// BadgerTX 事务函数
-type BadgerTX func(tx *badger.Txn) error
+// TxFunc 事务函数
+type TxFunc func(tx *badger.Txn) error
 
-// TxSet 事务设置参数操作
-func (e *Engine) TxSet(tx BadgerTX) error {
-	return e.db.Update(tx)
+// Update 在读写事务中执行fn, fn返回错误时放弃全部修改
+func (e *Engine) Update(fn TxFunc) error {
+	return e.db.Update(fn)
 }
 
-// TxGet 事务获取参数操作
-func (e *Engine) TxGet(tx BadgerTX) error {
-	return e.db.View(tx)
+// View 在只读事务中执行fn
+func (e *Engine) View(fn TxFunc) error {
+	return e.db.View(fn)
 }
 
 // Set 设置参数
 func (e *Engine) Set(key, value []byte) error {
-	return e.TxSet(func(tx *badger.Txn) error {
+	return e.Update(func(tx *badger.Txn) error {
 		return tx.Set(key, value)
 	})
 }
@@ -46,7 +46,7 @@ func (e *Engine) SetAny(key, value any) error {
 // Get 获取参数
 func (e *Engine) Get(key []byte) ([]byte, error) {
 	var value []byte
-	err := e.TxGet(func(tx *badger.Txn) error {
+	err := e.View(func(tx *badger.Txn) error {
 		item, err := tx.Get(key)
 		if err != nil {
 			return err
@@ -73,7 +73,7 @@ func (e *Engine) GetAny(key any, valuePoint any) error {
 
 // Del 删除参数
 func (e *Engine) Del(key []byte) error {
-	return e.TxSet(func(tx *badger.Txn) error {
+	return e.Update(func(tx *badger.Txn) error {
 		return tx.Delete(key)
 	})
 }
@@ -90,29 +90,22 @@ func (e *Engine) DelAny(key any) error {
 
 // SetTTL 设置参数的过期时间
 func (e *Engine) SetTTL(key, value []byte, ttl time.Duration) error {
-	return e.TxSet(func(tx *badger.Txn) error {
+	return e.Update(func(tx *badger.Txn) error {
 		return tx.SetEntry(badger.NewEntry(key, value).WithTTL(ttl))
 	})
 }
 
 // SetAnyTTL 设置参数的过期时间
+// 键与值通过gob序列化后存储, 任意类型的参数使用SetAnyValueTTL
 func (e *Engine) SetAnyTTL(key, value []byte, ttl time.Duration) error {
-	var keyBuf bytes.Buffer
-	var valBuf bytes.Buffer
-	if err := gob.NewEncoder(&keyBuf).Encode(key); err != nil {
-		return err
-	}
-	if err := gob.NewEncoder(&valBuf).Encode(value); err != nil {
-		return err
-	}
-	return e.SetTTL(keyBuf.Bytes(), valBuf.Bytes(), ttl)
+	return e.SetAnyValueTTL(key, value, ttl)
 }
 
-// Batch 批量操作
-type BadgerBatch func(*badger.WriteBatch) error
+// BatchFunc 批量写入函数
+type BatchFunc func(*badger.WriteBatch) error
 
 // Batch 批量操作
-func (e *Engine) Batch(bb BadgerBatch) error {
+func (e *Engine) Batch(bb BatchFunc) error {
 	wb := e.db.NewWriteBatch()
 	defer wb.Cancel()
 	return bb(wb)
@@ -131,45 +124,112 @@ func (e *Engine) Backup(filename string) error {
 	return nil
 }
 
-// GetKey 获取所有key
+// Keys 获取所有key
 // @param prefix 前缀
-func (e *Engine) GetKey(prefix []byte) ([][]byte, error) {
+// 每个键都会被复制, 大量键时建议使用ForEachKey或CountKeys
+func (e *Engine) Keys(prefix []byte) ([][]byte, error) {
 	var keys [][]byte
+	err := e.ForEachKey(prefix, func(key []byte) error {
+		keys = append(keys, bytes.Clone(key))
+		return nil
+	})
+	return keys, err
+}
 
-	err := e.db.View(func(txn *badger.Txn) error {
+// KeyFunc 遍历键的回调函数
+// key只在回调期间有效, 需要保留时须自行复制; 返回错误时停止遍历并返回该错误
+type KeyFunc func(key []byte) error
+
+// ForEachKey 按顺序遍历键, 不读取值也不复制键
+// @param prefix 前缀, 为nil时遍历所有键
+func (e *Engine) ForEachKey(prefix []byte, fn KeyFunc) error {
+	return e.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchValues = false // 只获取键，不获取值
+		opts.Prefix = prefix
 
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		if prefix == nil {
-			// 查询所有键
-			for it.Rewind(); it.Valid(); it.Next() {
-				item := it.Item()
-				key := item.KeyCopy(nil)
-				keys = append(keys, key)
-			}
-		} else {
-			// 查询指定前缀的键
-			prefixBytes := prefix
-			for it.Seek(prefixBytes); it.ValidForPrefix(prefixBytes); it.Next() {
-				item := it.Item()
-				key := item.KeyCopy(nil)
-				keys = append(keys, key)
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := fn(it.Item().Key()); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+}
 
+// CountKeys 统计键的数量
+// @param prefix 前缀, 为nil时统计所有键
+func (e *Engine) CountKeys(prefix []byte) (int, error) {
+	count := 0
+	err := e.ForEachKey(prefix, func([]byte) error {
+		count++
 		return nil
 	})
+	return count, err
+}
 
-	return keys, err
+// ScanFunc 扫描键值的回调函数
+// key与value只在回调期间有效, 需要保留时须自行复制; 不读取值时value为nil; 返回错误时停止扫描并返回该错误
+type ScanFunc func(key, value []byte) error
+
+// ScanOption 扫描配置项
+type ScanOption func(*badger.IteratorOptions)
+
+// WithPrefetchSize 设置预取值的数量, 默认为100
+// 顺序读取大量小值时调大可减少值日志的随机读取, 值较大时调小可降低内存占用
+func WithPrefetchSize(n int) ScanOption {
+	return func(o *badger.IteratorOptions) {
+		if n > 0 {
+			o.PrefetchSize = n
+		}
+	}
+}
+
+// WithKeysOnly 只扫描键, 不读取值
+func WithKeysOnly() ScanOption {
+	return func(o *badger.IteratorOptions) { o.PrefetchValues = false }
+}
+
+// Scan 按顺序扫描键值
+// @param prefix 前缀, 为nil时扫描所有键
+func (e *Engine) Scan(prefix []byte, fn ScanFunc, opts ...ScanOption) error {
+	iterOpts := badger.DefaultIteratorOptions
+	iterOpts.Prefix = prefix
+	for _, opt := range opts {
+		opt(&iterOpts)
+	}
+
+	return e.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(iterOpts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if !iterOpts.PrefetchValues {
+				if err := fn(item.Key(), nil); err != nil {
+					return err
+				}
+				continue
+			}
+			err := item.Value(func(val []byte) error {
+				return fn(item.Key(), val)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Exists 判断key是否存在
+// 只读取键的元数据, 不读取值日志
 func (e *Engine) Exists(key []byte) (bool, error) {
 	var exists bool
-	err := e.TxGet(func(tx *badger.Txn) error {
+	err := e.View(func(tx *badger.Txn) error {
 		_, err := tx.Get(key)
 		if err == nil {
 			exists = true
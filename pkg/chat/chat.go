@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Utterance 聊天记录中的一条发言
+type Utterance struct {
+	Speaker string    `json:"speaker"` // 发言人
+	Time    time.Time `json:"time"`    // 发言时间
+	Text    string    `json:"text"`    // 发言内容, 多行消息以换行连接
+}
+
+// timePattern 聊天导出中的时间格式
+const timePattern = `\d{4}[-/.年]\d{1,2}[-/.月]\d{1,2}日?\s+\d{1,2}:\d{2}(?::\d{2})?`
+
+var (
+	// reInline 单行格式: "[2024-01-02 10:03:04] 张三: 内容"
+	reInline = regexp.MustCompile(`^\[?(` + timePattern + `)\]?\s*([^:：]+?)\s*[:：]\s*(.*)$`)
+	// reSpeakerFirst 微信导出格式: "张三 2024-01-02 10:03:04", 内容在后续行
+	reSpeakerFirst = regexp.MustCompile(`^(.+?)\s+\(?(` + timePattern + `)\)?\s*$`)
+	// reTimeFirst 钉钉导出格式: "2024-01-02 10:03:04 张三", 内容在后续行
+	reTimeFirst = regexp.MustCompile(`^(` + timePattern + `)\s+(.+?)\s*$`)
+	// timeReplacer 将中文日期分隔符统一为"-"
+	timeReplacer = strings.NewReplacer("年", "-", "月", "-", "日", "", "/", "-", ".", "-")
+)
+
+// timeLayouts 时间格式
+var timeLayouts = []string{"2006-1-2 15:04:05", "2006-1-2 15:04"}
+
+// parseTime 解析聊天导出中的时间, 使用本地时区
+func parseTime(s string) time.Time {
+	s = strings.Join(strings.Fields(timeReplacer.Replace(s)), " ")
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Parse 解析微信、钉钉等导出的聊天记录
+// 支持"发言人 时间"或"时间 发言人"作为消息头、内容在后续行的格式, 以及"[时间] 发言人: 内容"的单行格式
+// 消息头之前的行被忽略
+func Parse(r io.Reader) ([]Utterance, error) {
+	var utterances []Utterance
+	var current *Utterance
+	var lines []string
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+			if current.Text != "" {
+				utterances = append(utterances, *current)
+			}
+		}
+		current, lines = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case reInline.MatchString(trimmed):
+			m := reInline.FindStringSubmatch(trimmed)
+			flush()
+			current = &Utterance{Speaker: m[2], Time: parseTime(m[1])}
+			lines = []string{m[3]}
+		case reTimeFirst.MatchString(trimmed):
+			m := reTimeFirst.FindStringSubmatch(trimmed)
+			flush()
+			current = &Utterance{Speaker: m[2], Time: parseTime(m[1])}
+		case reSpeakerFirst.MatchString(trimmed):
+			m := reSpeakerFirst.FindStringSubmatch(trimmed)
+			flush()
+			current = &Utterance{Speaker: m[1], Time: parseTime(m[2])}
+		case current != nil:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return utterances, scanner.Err()
+}
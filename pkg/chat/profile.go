@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+const (
+	// profileKeyPrefix 发言人词频的键前缀, 键为前缀+发言人+"\x00"+词
+	profileKeyPrefix = participle.MetaKeyPrefix + "profile:"
+	// corpusKeyPrefix 全部发言人合计词频的键前缀, 键为前缀+词
+	corpusKeyPrefix = participle.MetaKeyPrefix + "profile-all:"
+	// priorStrength 对数几率先验的总强度
+	priorStrength = 500.0
+)
+
+// Term 发言人的特征词
+type Term struct {
+	Word  string  `json:"word"`  // 词
+	Count uint64  `json:"count"` // 该发言人使用次数
+	Score float64 `json:"score"` // 对数几率z值, 越大越能区分该发言人
+}
+
+// SpeakerProfile 发言人用词画像
+type SpeakerProfile struct {
+	Speaker string `json:"speaker"` // 发言人
+	Words   uint64 `json:"words"`   // 累计词数
+	Terms   []Term `json:"terms"`   // 特征词, 按Score降序
+}
+
+// Profiles 发言人用词画像, 词频保存在数据库中, 可与词典共用数据库
+type Profiles struct {
+	db *badger.Engine
+}
+
+// NewProfiles 创建发言人用词画像
+func NewProfiles(db *badger.Engine) *Profiles {
+	return &Profiles{db: db}
+}
+
+// speakerKey 发言人词频的键
+func speakerKey(speaker, word string) []byte {
+	return []byte(profileKeyPrefix + speaker + "\x00" + word)
+}
+
+// Update 对发言分词并累加各发言人的词频
+func (p *Profiles) Update(ctx context.Context, engine *participle.Engine, utterances []Utterance) error {
+	counts := make(map[string]map[string]uint64)
+	for _, u := range utterances {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		words, ok := counts[u.Speaker]
+		if !ok {
+			words = make(map[string]uint64)
+			counts[u.Speaker] = words
+		}
+		for _, token := range engine.SegmentTokens(u.Text) {
+			if isKeyword(token) {
+				words[token.Text]++
+			}
+		}
+	}
+
+	for speaker, words := range counts {
+		err := p.db.Update(func(tx *bd.Txn) error {
+			for word, n := range words {
+				if err := increment(tx, speakerKey(speaker, word), n); err != nil {
+					return err
+				}
+				if err := increment(tx, []byte(corpusKeyPrefix+word), n); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// increment 在事务中累加计数
+func increment(tx *bd.Txn, key []byte, n uint64) error {
+	var count uint64
+	item, err := tx.Get(key)
+	switch err {
+	case nil:
+		err = item.Value(func(val []byte) error {
+			count = binary.BigEndian.Uint64(val)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	case bd.ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], count+n)
+	return tx.Set(key, buf[:])
+}
+
+// scanCounts 遍历前缀下的计数, 返回词与计数
+func (p *Profiles) scanCounts(prefix string) (map[string]uint64, uint64, error) {
+	counts := make(map[string]uint64)
+	var total uint64
+	err := p.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			word := string(item.Key()[len(prefix):])
+			err := item.Value(func(val []byte) error {
+				n := binary.BigEndian.Uint64(val)
+				counts[word] = n
+				total += n
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return counts, total, err
+}
+
+// Profile 获取发言人的用词画像, 保留得分最高的topN个特征词, 0表示全部保留
+// 特征词得分为该发言人与其余发言人用词的对数几率差的z值, 以全部发言的词频作为先验(Monroe等, 2008)
+func (p *Profiles) Profile(speaker string, topN int) (*SpeakerProfile, error) {
+	own, ownTotal, err := p.scanCounts(profileKeyPrefix + speaker + "\x00")
+	if err != nil {
+		return nil, err
+	}
+	corpus, corpusTotal, err := p.scanCounts(corpusKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &SpeakerProfile{Speaker: speaker, Words: ownTotal}
+	if ownTotal == 0 {
+		return profile, nil
+	}
+	restTotal := float64(corpusTotal - ownTotal)
+
+	for word, y := range own {
+		alpha := priorStrength * float64(corpus[word]) / float64(corpusTotal)
+		yi := float64(y)
+		yj := float64(corpus[word] - y)
+
+		own := math.Log((yi + alpha) / (float64(ownTotal) + priorStrength - yi - alpha))
+		rest := math.Log((yj + alpha) / (restTotal + priorStrength - yj - alpha))
+		variance := 1/(yi+alpha) + 1/(yj+alpha)
+
+		profile.Terms = append(profile.Terms, Term{Word: word, Count: y, Score: (own - rest) / math.Sqrt(variance)})
+	}
+
+	sort.Slice(profile.Terms, func(i, j int) bool {
+		if profile.Terms[i].Score != profile.Terms[j].Score {
+			return profile.Terms[i].Score > profile.Terms[j].Score
+		}
+		return profile.Terms[i].Word < profile.Terms[j].Word
+	})
+	if topN > 0 && len(profile.Terms) > topN {
+		profile.Terms = profile.Terms[:topN]
+	}
+	return profile, nil
+}
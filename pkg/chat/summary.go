@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/miajio/nla/pkg/participle"
+	"github.com/miajio/nla/pkg/pipeline"
+)
+
+// sentimentStage 情感打分阶段名称
+const sentimentStage = "sentiment"
+
+var (
+	// positiveWords 正面情感词
+	positiveWords = wordSet("好", "喜欢", "满意", "谢谢", "感谢", "不错", "开心", "棒", "赞", "支持", "划算", "便宜", "好用", "推荐", "哈哈", "给力", "靠谱", "优秀")
+	// negativeWords 负面情感词
+	negativeWords = wordSet("差", "垃圾", "失望", "投诉", "退款", "退货", "生气", "骗", "贵", "慢", "难用", "坑", "烂", "不满", "糟糕", "问题", "故障", "差评")
+	// negations 否定词, 翻转其后情感词的极性
+	negations = wordSet("不", "没", "没有", "别", "不是", "不太")
+)
+
+// wordSet 构造词集合
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// degreePrefixes 程度副词, 如"太慢"按"慢"计
+var degreePrefixes = []string{"非常", "特别", "太", "很", "真", "超", "好"}
+
+// negationScope 否定词影响其后的词数
+const negationScope = 2
+
+// wordPolarity 返回词的情感极性, 依次尝试原词、去掉否定前缀(如"不贵")与程度副词前缀(如"太慢")
+func wordPolarity(word string) float64 {
+	switch {
+	case positiveWords[word]:
+		return 1
+	case negativeWords[word]:
+		return -1
+	}
+	for _, prefix := range []string{"不", "没"} {
+		if rest, ok := strings.CutPrefix(word, prefix); ok && rest != "" {
+			return -wordPolarity(rest)
+		}
+	}
+	for _, prefix := range degreePrefixes {
+		if rest, ok := strings.CutPrefix(word, prefix); ok && rest != "" {
+			return wordPolarity(rest)
+		}
+	}
+	return 0
+}
+
+// Sentiment 基于情感词表计算分词结果的情感得分, 范围[-1, 1], 无情感词时为0
+// 否定词之后两个词内的情感词极性翻转, 如"不是很满意"
+func Sentiment(tokens []participle.Token) float64 {
+	score, hits := 0.0, 0
+	negate := 0 // 剩余的否定范围
+	for _, token := range tokens {
+		if token.Type == participle.TokenPunct {
+			negate = 0
+			continue
+		}
+		if negations[token.Text] {
+			negate = negationScope
+			continue
+		}
+		if p := wordPolarity(token.Text); p != 0 {
+			score += polarity(p, negate > 0)
+			hits++
+			negate = 0
+			continue
+		}
+		negate = max(negate-1, 0)
+	}
+	if hits == 0 {
+		return 0
+	}
+	return score / float64(hits)
+}
+
+// polarity 按是否否定返回极性
+func polarity(p float64, negate bool) float64 {
+	if negate {
+		return -p
+	}
+	return p
+}
+
+// SentimentStage 流水线情感打分阶段, 结果为float64, 写入Extracted["sentiment"]
+func SentimentStage() pipeline.Stage {
+	return pipeline.Extract(sentimentStage, func(ctx context.Context, doc *pipeline.Document) (any, error) {
+		return Sentiment(doc.Tokens), nil
+	})
+}
+
+// SpeakerSummary 发言人汇总
+type SpeakerSummary struct {
+	Speaker    string                     `json:"speaker"`    // 发言人
+	Utterances int                        `json:"utterances"` // 发言条数
+	Keywords   []participle.WordFrequency `json:"keywords"`   // 高频词, 按次数降序
+	Sentiment  float64                    `json:"sentiment"`  // 平均情感得分
+}
+
+// isKeyword 判断词是否计入关键词: 两个字及以上的汉字或拉丁字母词
+func isKeyword(token participle.Token) bool {
+	return (token.Type == participle.TokenHan || token.Type == participle.TokenLatin) && utf8.RuneCountInString(token.Text) > 1
+}
+
+// Summarize 将发言送入分词与情感打分流水线, 按发言人汇总高频词与平均情感得分
+// topN为每个发言人保留的关键词数量, 0表示全部保留; 结果按发言条数降序
+func Summarize(ctx context.Context, engine *participle.Engine, utterances []Utterance, topN int) ([]SpeakerSummary, error) {
+	texts := make([]string, len(utterances))
+	for i, u := range utterances {
+		texts[i] = u.Text
+	}
+
+	type speakerStats struct {
+		utterances int
+		sentiment  float64
+		counts     map[string]float64
+	}
+	stats := make(map[string]*speakerStats)
+
+	p := pipeline.New(64,
+		pipeline.Stage{Name: "segment", Run: pipeline.Segment(engine).Run, Workers: 4},
+		SentimentStage(),
+	)
+	err := p.Run(ctx, pipeline.FromStrings(texts), func(doc *pipeline.Document) {
+		speaker := utterances[doc.ID].Speaker
+		s, ok := stats[speaker]
+		if !ok {
+			s = &speakerStats{counts: make(map[string]float64)}
+			stats[speaker] = s
+		}
+		s.utterances++
+		s.sentiment += doc.Extracted[sentimentStage].(float64)
+		for _, token := range doc.Tokens {
+			if isKeyword(token) {
+				s.counts[token.Text]++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]SpeakerSummary, 0, len(stats))
+	for speaker, s := range stats {
+		keywords := make([]participle.WordFrequency, 0, len(s.counts))
+		for word, count := range s.counts {
+			keywords = append(keywords, participle.WordFrequency{Name: word, Value: count})
+		}
+		sortWordFrequencies(keywords)
+		if topN > 0 && len(keywords) > topN {
+			keywords = keywords[:topN]
+		}
+		summaries = append(summaries, SpeakerSummary{
+			Speaker:    speaker,
+			Utterances: s.utterances,
+			Keywords:   keywords,
+			Sentiment:  s.sentiment / float64(s.utterances),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Utterances != summaries[j].Utterances {
+			return summaries[i].Utterances > summaries[j].Utterances
+		}
+		return summaries[i].Speaker < summaries[j].Speaker
+	})
+	return summaries, nil
+}
+
+// sortWordFrequencies 按次数降序排序, 次数相同时按词排序
+func sortWordFrequencies(freqs []participle.WordFrequency) {
+	sort.Slice(freqs, func(i, j int) bool {
+		if freqs[i].Value != freqs[j].Value {
+			return freqs[i].Value > freqs[j].Value
+		}
+		return freqs[i].Name < freqs[j].Name
+	})
+}
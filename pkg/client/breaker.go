@@ -0,0 +1,93 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState 熔断器状态
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // 关闭: 正常请求
+	breakerOpen                         // 打开: 拒绝请求直到冷却结束
+	breakerHalfOpen                     // 半开: 放行一个试探请求
+)
+
+// breaker 熔断器, 连续失败达到阈值后打开, 冷却结束后放行一个试探请求, 成功则关闭
+type breaker struct {
+	threshold int           // 打开熔断器的连续失败次数, 0表示不熔断
+	cooldown  time.Duration // 打开后的冷却时间
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int       // 连续失败次数
+	openedAt time.Time // 打开时间
+	probing  bool      // 半开状态下是否已有试探请求
+}
+
+// allow 判断是否放行请求
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state, b.probing = breakerHalfOpen, true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success 记录一次成功, 关闭熔断器
+func (b *breaker) success() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.probing = breakerClosed, 0, false
+}
+
+// failure 记录一次失败, 连续失败达到阈值或试探失败时打开熔断器
+func (b *breaker) failure() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt, b.probing = breakerOpen, time.Now(), false
+	}
+}
+
+// release 放弃本次请求的结果(如调用方取消), 半开状态下允许下一个试探请求
+func (b *breaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// open 熔断器是否处于打开状态
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen && time.Since(b.openedAt) < b.cooldown
+}
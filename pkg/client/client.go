@@ -0,0 +1,321 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+	"github.com/miajio/nla/pkg/server"
+)
+
+// ErrCircuitOpen 熔断器已打开, 请求未发送
+var ErrCircuitOpen = errors.New("client: circuit breaker is open")
+
+// StatusError 服务返回的错误响应
+type StatusError struct {
+	StatusCode int    // HTTP状态码
+	Message    string // 错误信息
+}
+
+// Error 实现error接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("client: server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Client 分词HTTP服务客户端, 可被多个goroutine并发使用
+// 请求失败时按指数退避重试, 连续失败时熔断, 服务不可用时分词可回退到本地引擎
+type Client struct {
+	baseURL string
+	http    *http.Client
+	apiKey  string
+	header  string
+
+	retries    int           // 失败后的重试次数
+	backoff    time.Duration // 首次重试前的等待时间, 之后每次翻倍
+	maxBackoff time.Duration // 重试等待时间上限
+
+	breaker  *breaker
+	fallback *participle.Engine // 服务不可用时用于分词的本地引擎
+}
+
+// Option 客户端配置项
+type Option func(*Client)
+
+// WithHTTPClient 设置HTTP客户端, 默认超时10秒
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) { cl.http = c }
+}
+
+// WithAPIKey 设置API Key, 用于服务端的认证、用量统计与配额, 默认通过X-API-Key请求头发送
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithAPIKeyHeader 设置携带API Key的请求头, 需与服务端的WithAPIKeys一致
+func WithAPIKeyHeader(header string) Option {
+	return func(c *Client) { c.header = header }
+}
+
+// WithRetry 设置失败后的重试次数与首次重试前的等待时间, 等待时间每次翻倍, 最长maxBackoff
+// 默认重试2次, 首次等待100毫秒, 最长2秒
+func WithRetry(retries int, backoff, maxBackoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff, c.maxBackoff = retries, backoff, maxBackoff }
+}
+
+// WithCircuitBreaker 连续失败threshold次后熔断cooldown时长, 期间请求直接返回ErrCircuitOpen
+// 冷却结束后放行一个试探请求, 成功则恢复; threshold为0表示不熔断, 默认连续失败5次熔断30秒
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) { c.breaker = &breaker{threshold: threshold, cooldown: cooldown} }
+}
+
+// WithFallback 服务不可用(网络错误、5xx或熔断)时使用本地引擎分词
+// 本地引擎通常使用精简基础词典创建, 见participle.WithBaseDict
+func WithFallback(engine *participle.Engine) Option {
+	return func(c *Client) { c.fallback = engine }
+}
+
+// New 创建客户端, baseURL为服务地址, 如http://127.0.0.1:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		http:       &http.Client{Timeout: 10 * time.Second},
+		header:     "X-API-Key",
+		retries:    2,
+		backoff:    100 * time.Millisecond,
+		maxBackoff: 2 * time.Second,
+		breaker:    &breaker{threshold: 5, cooldown: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Available 服务是否可用, 熔断器打开时返回false
+func (c *Client) Available() bool {
+	return !c.breaker.open()
+}
+
+// unavailable 判断错误是否表示服务不可用: 网络错误、5xx或熔断
+func unavailable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= http.StatusInternalServerError
+	}
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// retryable 判断失败的请求是否可以重试
+// 非幂等请求只在服务明确未处理(503)时重试, 网络错误时请求可能已被处理
+func retryable(err error, idempotent bool) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		if se.StatusCode == http.StatusServiceUnavailable {
+			return true
+		}
+		return idempotent && se.StatusCode >= http.StatusInternalServerError
+	}
+	return idempotent && !errors.Is(err, ErrCircuitOpen)
+}
+
+// do 发送请求, 按需重试, 响应成功时将内容解码到out(out为*[]byte时返回原始内容)
+func (c *Client) do(ctx context.Context, method, path string, body, out any, idempotent bool) error {
+	var payload []byte
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = data
+	}
+
+	wait := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.once(ctx, method, path, payload, out)
+		if err == nil || attempt >= c.retries || !retryable(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		wait = min(wait*2, c.maxBackoff)
+	}
+}
+
+// once 发送一次请求并记录熔断器状态
+func (c *Client) once(ctx context.Context, method, path string, payload []byte, out any) error {
+	if !c.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(c.header, c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.breaker.release()
+		} else {
+			c.breaker.failure()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.breaker.failure()
+		return fmt.Errorf("read response fail: %v", err)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.breaker.failure()
+	} else {
+		c.breaker.success()
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return &StatusError{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+
+	switch v := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*v = data
+		return nil
+	default:
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response fail: %v", err)
+		}
+		return nil
+	}
+}
+
+// Segment 对文本分词, 服务不可用且设置了本地引擎时使用本地引擎分词
+func (c *Client) Segment(ctx context.Context, text string) ([]string, error) {
+	var resp struct {
+		Tokens []string `json:"tokens"`
+	}
+	err := c.do(ctx, http.MethodPost, "/segment", map[string]string{"text": text}, &resp, true)
+	if err != nil && c.fallback != nil && unavailable(err) {
+		return c.fallback.Segment(text), nil
+	}
+	return resp.Tokens, err
+}
+
+// Learn 提交学习任务, 从文本中学习新词
+func (c *Client) Learn(ctx context.Context, texts ...string) (server.Job, error) {
+	return c.CreateJob(ctx, server.JobRequest{Type: server.JobLearn, Texts: texts})
+}
+
+// CreateJob 提交异步任务
+func (c *Client) CreateJob(ctx context.Context, req server.JobRequest) (server.Job, error) {
+	var job server.Job
+	err := c.do(ctx, http.MethodPost, "/jobs", req, &job, false)
+	return job, err
+}
+
+// Job 查询任务状态
+func (c *Client) Job(ctx context.Context, id string) (server.Job, error) {
+	var job server.Job
+	err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &job, true)
+	return job, err
+}
+
+// CancelJob 取消任务
+func (c *Client) CancelJob(ctx context.Context, id string) (server.Job, error) {
+	var job server.Job
+	err := c.do(ctx, http.MethodDelete, "/jobs/"+url.PathEscape(id), nil, &job, true)
+	return job, err
+}
+
+// JobResult 下载任务结果
+func (c *Client) JobResult(ctx context.Context, id string) ([]byte, error) {
+	var data []byte
+	err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id)+"/result", nil, &data, true)
+	return data, err
+}
+
+// WaitJob 轮询任务直到结束, interval为轮询间隔
+func (c *Client) WaitJob(ctx context.Context, id string, interval time.Duration) (server.Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.Job(ctx, id)
+		if err != nil {
+			return job, err
+		}
+		switch job.Status {
+		case server.JobSucceeded, server.JobFailed, server.JobCanceled:
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Usage 查询当前统计周期内所有租户的用量
+func (c *Client) Usage(ctx context.Context) ([]server.Usage, error) {
+	var list []server.Usage
+	err := c.do(ctx, http.MethodGet, "/usage", nil, &list, true)
+	return list, err
+}
+
+// TenantUsage 查询租户的用量, period为统计周期(如2006-01), 空表示当前月
+func (c *Client) TenantUsage(ctx context.Context, tenant, period string) (server.Usage, error) {
+	path := "/usage/" + url.PathEscape(tenant)
+	if period != "" {
+		path += "?period=" + url.QueryEscape(period)
+	}
+	var usage server.Usage
+	err := c.do(ctx, http.MethodGet, path, nil, &usage, true)
+	return usage, err
+}
+
+// CanaryStats 查询灰度评估统计
+func (c *Client) CanaryStats(ctx context.Context) (server.CanaryStats, error) {
+	var stats server.CanaryStats
+	err := c.do(ctx, http.MethodGet, "/canary", nil, &stats, true)
+	return stats, err
+}
+
+// PromoteCanary 采纳灰度引擎的词典, 返回采纳前的灰度评估统计
+func (c *Client) PromoteCanary(ctx context.Context) (server.CanaryStats, error) {
+	var stats server.CanaryStats
+	err := c.do(ctx, http.MethodPost, "/canary/promote", nil, &stats, false)
+	return stats, err
+}
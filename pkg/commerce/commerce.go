@@ -0,0 +1,213 @@
+package commerce
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Span 抽取结果在原文中的位置
+type Span struct {
+	Text  string `json:"text"`  // 原文片段
+	Start int    `json:"start"` // 起始字节偏移
+	End   int    `json:"end"`   // 结束字节偏移
+}
+
+// Price 价格
+type Price struct {
+	Span
+	Value float64 `json:"value"` // 金额(元)
+}
+
+// Discount 优惠
+type Discount struct {
+	Span
+	Kind      string  `json:"kind"`                // 类型: rate(折扣)、threshold(满减)、gift(买赠)、half(半价)
+	Rate      float64 `json:"rate,omitempty"`      // 折扣率, 如8折为0.8
+	Threshold float64 `json:"threshold,omitempty"` // 满减门槛或买赠购买数量
+	Amount    float64 `json:"amount,omitempty"`    // 满减金额或买赠赠送数量
+}
+
+// Quantity 数量
+type Quantity struct {
+	Span
+	Value float64 `json:"value"` // 数量
+	Unit  string  `json:"unit"`  // 量词
+}
+
+// Delivery 发货承诺
+type Delivery struct {
+	Span
+	Days float64 `json:"days"` // 承诺发货天数
+}
+
+// Result 直播文本商品信息抽取结果
+type Result struct {
+	Prices     []Price    `json:"prices"`
+	Discounts  []Discount `json:"discounts"`
+	Quantities []Quantity `json:"quantities"`
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// priceUnits 价格单位及其黑话, 均按元计
+var priceUnits = []string{"元", "块钱", "块", "米", "达不溜", "刀", "r", "R"}
+
+var (
+	// reDelivery 发货承诺: "3个太阳内飞走"、"48小时内发货"、"当天发"
+	reDelivery = regexp.MustCompile(`(` + numberPattern + `)\s*(?:个)?(太阳|天|日|小时|h)\s*(?:之?内)?\s*(?:飞走|飞|发货|发出|发)|(当天|当日|今天)\s*(?:飞走|飞|发货|发出|发)`)
+	// rePrice 价格: "9.9米"、"¥19.9"、"九块九"、"两百块"
+	rePrice = regexp.MustCompile(`[¥￥]\s*(\d+(?:\.\d+)?)|(` + numberPattern + `)\s*(` + strings.Join(priceUnits, "|") + `)([零一二两三四五六七八九]?)`)
+	// reRate 折扣: "8折"、"八五折"
+	reRate = regexp.MustCompile(`(\d+(?:\.\d+)?|[一二三四五六七八九]{1,2})\s*折`)
+	// reThreshold 满减: "满199减30"
+	reThreshold = regexp.MustCompile(`满\s*(` + numberPattern + `)\s*(?:元|块)?\s*减\s*(` + numberPattern + `)`)
+	// reGift 买赠: "买一送一"、"买2赠1"
+	reGift = regexp.MustCompile(`买\s*(` + numberPattern + `)\s*(?:件|个|份)?\s*(?:送|赠)\s*(` + numberPattern + `)`)
+	// reHalf 半价: "第二件半价"
+	reHalf = regexp.MustCompile(`第(` + numberPattern + `)件半价`)
+	// reQuantity 数量: "3件"、"两盒"
+	reQuantity = regexp.MustCompile(`(` + numberPattern + `)\s*(件|个|瓶|盒|包|袋|箱|份|支|双|套|斤|单)`)
+)
+
+// Extract 从直播文本中抽取价格、优惠、数量与发货承诺
+// 支持常见黑话: "米"、"达不溜"表示元, "个太阳"表示天, "飞走"表示发货
+func Extract(text string) Result {
+	var result Result
+	var used []Span // 已被抽取的片段, 避免同一段文字被重复解析
+
+	overlaps := func(start, end int) bool {
+		for _, s := range used {
+			if start < s.End && s.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+	take := func(loc []int) (Span, bool) {
+		if overlaps(loc[0], loc[1]) {
+			return Span{}, false
+		}
+		span := Span{Text: text[loc[0]:loc[1]], Start: loc[0], End: loc[1]}
+		used = append(used, span)
+		return span, true
+	}
+	group := func(loc []int, i int) string {
+		if loc[2*i] < 0 {
+			return ""
+		}
+		return text[loc[2*i]:loc[2*i+1]]
+	}
+
+	// 发货承诺优先, 避免"3个太阳"被解析为数量
+	for _, loc := range reDelivery.FindAllStringSubmatchIndex(text, -1) {
+		days := 0.0
+		if group(loc, 3) == "" {
+			n, ok := ParseNumber(group(loc, 1))
+			if !ok {
+				continue
+			}
+			days = n
+			if unit := group(loc, 2); unit == "小时" || unit == "h" {
+				days = n / 24
+			}
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Deliveries = append(result.Deliveries, Delivery{Span: span, Days: days})
+		}
+	}
+
+	// 优惠先于价格, 避免"满199减30"中的数字被解析为价格
+	for _, loc := range reThreshold.FindAllStringSubmatchIndex(text, -1) {
+		threshold, ok1 := ParseNumber(group(loc, 1))
+		amount, ok2 := ParseNumber(group(loc, 2))
+		if !ok1 || !ok2 {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Discounts = append(result.Discounts, Discount{Span: span, Kind: "threshold", Threshold: threshold, Amount: amount})
+		}
+	}
+	for _, loc := range reGift.FindAllStringSubmatchIndex(text, -1) {
+		buy, ok1 := ParseNumber(group(loc, 1))
+		gift, ok2 := ParseNumber(group(loc, 2))
+		if !ok1 || !ok2 {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Discounts = append(result.Discounts, Discount{Span: span, Kind: "gift", Threshold: buy, Amount: gift})
+		}
+	}
+	for _, loc := range reHalf.FindAllStringSubmatchIndex(text, -1) {
+		nth, ok := ParseNumber(group(loc, 1))
+		if !ok {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Discounts = append(result.Discounts, Discount{Span: span, Kind: "half", Threshold: nth, Rate: 0.5})
+		}
+	}
+	for _, loc := range reRate.FindAllStringSubmatchIndex(text, -1) {
+		rate, ok := parseRate(group(loc, 1))
+		if !ok {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Discounts = append(result.Discounts, Discount{Span: span, Kind: "rate", Rate: rate})
+		}
+	}
+
+	for _, loc := range rePrice.FindAllStringSubmatchIndex(text, -1) {
+		value, ok := 0.0, false
+		if raw := group(loc, 1); raw != "" {
+			value, ok = ParseNumber(raw)
+		} else {
+			value, ok = ParseNumber(group(loc, 2))
+			// "九块九"中单位后的角
+			if jiao := group(loc, 4); ok && jiao != "" {
+				d, _ := ParseNumber(jiao)
+				value += d / 10
+			}
+		}
+		if !ok {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Prices = append(result.Prices, Price{Span: span, Value: value})
+		}
+	}
+
+	for _, loc := range reQuantity.FindAllStringSubmatchIndex(text, -1) {
+		value, ok := ParseNumber(group(loc, 1))
+		if !ok {
+			continue
+		}
+		if span, ok := take(loc[:2]); ok {
+			result.Quantities = append(result.Quantities, Quantity{Span: span, Value: value, Unit: group(loc, 2)})
+		}
+	}
+
+	sort.Slice(result.Discounts, func(i, j int) bool { return result.Discounts[i].Start < result.Discounts[j].Start })
+	return result
+}
+
+// parseRate 解析折扣数字: "8"→0.8, "8.5"→0.85, "八五"→0.85, "85"→0.85
+func parseRate(s string) (float64, bool) {
+	digits := []rune(s)
+	if len(digits) == 2 && digits[0] >= '一' {
+		a, ok1 := chineseDigits[digits[0]]
+		b, ok2 := chineseDigits[digits[1]]
+		return (a*10 + b) / 100, ok1 && ok2
+	}
+
+	v, ok := ParseNumber(s)
+	switch {
+	case !ok || v <= 0:
+		return 0, false
+	case v < 10:
+		return v / 10, true
+	case v < 100:
+		return v / 100, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,78 @@
+package commerce
+
+import (
+	"strconv"
+	"strings"
+)
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]float64{
+	'零': 0, '〇': 0, '一': 1, '壹': 1, '二': 2, '两': 2, '贰': 2, '三': 3, '叁': 3, '四': 4, '肆': 4,
+	'五': 5, '伍': 5, '六': 6, '陆': 6, '七': 7, '柒': 7, '八': 8, '捌': 8, '九': 9, '玖': 9,
+}
+
+// chineseUnits 中文数字单位
+var chineseUnits = map[rune]float64{'十': 10, '拾': 10, '百': 100, '佰': 100, '千': 1000, '仟': 1000, '万': 10000}
+
+// numberPattern 阿拉伯数字或中文数字的正则片段
+const numberPattern = `(?:\d+(?:\.\d+)?|[零〇一壹二两贰三叁四肆五伍六陆七柒八捌九玖十拾百佰千仟万点]+)`
+
+// ParseNumber 解析阿拉伯数字或中文数字, 如"12.5"、"十二"、"三点五"、"一万二千"
+func ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, true
+	}
+
+	integer, fraction, hasPoint := strings.Cut(s, "点")
+	value, ok := parseChineseInteger(integer)
+	if !ok {
+		return 0, false
+	}
+	if hasPoint {
+		scale := 0.1
+		for _, r := range fraction {
+			d, ok := chineseDigits[r]
+			if !ok {
+				return 0, false
+			}
+			value += d * scale
+			scale /= 10
+		}
+	}
+	return value, true
+}
+
+// parseChineseInteger 解析中文整数, 支持"十二"、"二十"、"一百零五"、"一万二千"
+func parseChineseInteger(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	var total, section, digit float64
+	hasDigit := false
+	for _, r := range s {
+		if d, ok := chineseDigits[r]; ok {
+			digit, hasDigit = d, true
+			continue
+		}
+		unit, ok := chineseUnits[r]
+		if !ok {
+			return 0, false
+		}
+		if !hasDigit {
+			digit = 1 // "十二"中省略的"一"
+		}
+		if unit == 10000 {
+			total += (section + digit) * unit
+			section = 0
+		} else {
+			section += digit * unit
+		}
+		digit, hasDigit = 0, false
+	}
+	return total + section + digit, true
+}
@@ -0,0 +1,561 @@
+// Package expr 轻量表达式引擎, 用于在配置中编写词与实体的后处理规则, 无需重新编译
+//
+// 值有数字(float64)、字符串与布尔三种类型, 支持的语法:
+//
+//	字面量   1, 2.5, "文本", `原样文本`, true, false
+//	变量     由调用方在环境中提供, 如text、pos
+//	运算     || && ! == != < <= > >= + - * / %, 字符串可用+拼接与比较
+//	正则     text =~ "^\\d+$", 右侧为字面量时编译期编译
+//	函数     len contains hasPrefix hasSuffix lower upper trim replace number string
+//
+// 逻辑运算短路求值, 类型不匹配或变量未定义时求值返回错误
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUndefined 变量未定义
+var ErrUndefined = errors.New("expr: undefined variable")
+
+// Program 编译后的表达式, 可并发求值
+type Program struct {
+	src  string
+	eval evalFunc
+}
+
+// evalFunc 求值函数
+type evalFunc func(env map[string]any) (any, error)
+
+// Compile 编译表达式
+func Compile(src string) (*Program, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	eval, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	return &Program{src: src, eval: eval}, nil
+}
+
+// String 表达式源码
+func (p *Program) String() string { return p.src }
+
+// Eval 在环境env中求值, 环境中的整数会转换为数字
+func (p *Program) Eval(env map[string]any) (any, error) {
+	return p.eval(env)
+}
+
+// EvalBool 求值并要求结果为布尔值
+func (p *Program) EvalBool(env map[string]any) (bool, error) {
+	v, err := p.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("result %v is not bool", v)
+	}
+	return b, nil
+}
+
+// EvalString 求值并将结果格式化为字符串
+func (p *Program) EvalString(env map[string]any) (string, error) {
+	v, err := p.eval(env)
+	if err != nil {
+		return "", err
+	}
+	return toString(v), nil
+}
+
+// tokKind 词法单元类型
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+// token 词法单元
+type token struct {
+	kind tokKind
+	text string // 运算符、标识符或原始文本
+	str  string // 字符串字面量的值
+	num  float64
+	pos  int // 在源码中的字节偏移
+}
+
+// operators 运算符, 长的在前
+var operators = []string{"||", "&&", "==", "!=", "=~", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ","}
+
+// lex 词法分析
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			num, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[i:j], i)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:j], num: num, pos: i})
+			i = j
+		case r == '"' || r == '`':
+			j := i + 1
+			for j < len(src) && src[j] != byte(r) {
+				if r == '"' && src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			str, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %v", i, err)
+			}
+			tokens = append(tokens, token{kind: tokString, text: src[i : j+1], str: str, pos: i})
+			i = j + 1
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+// parser 语法分析, 直接生成求值函数
+type parser struct {
+	tokens []token
+	i      int
+}
+
+// peek 当前词法单元
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+// next 读取当前词法单元
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// expect 读取指定的运算符
+func (p *parser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		return fmt.Errorf("expected %q at %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+// precedence 二元运算符的优先级, 数值越大结合越紧
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "=~": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// parseBinary 解析优先级高于minPrec的二元表达式
+func (p *parser) parseBinary(minPrec int) (evalFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := precedence[tok.text]
+		if tok.kind != tokOp || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+
+		if tok.text == "=~" {
+			if left, err = p.parseMatch(left); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		right, err := p.parseBinary(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binary(tok.text, left, right)
+	}
+}
+
+// parseMatch 解析正则匹配, 右侧为字符串字面量时预先编译
+func (p *parser) parseMatch(left evalFunc) (evalFunc, error) {
+	if tok := p.peek(); tok.kind == tokString {
+		// 字面量之后不能紧跟结合更紧的运算符, 如"a" + b
+		if next := p.tokens[p.i+1]; next.kind != tokOp || precedence[next.text] <= precedence["=~"] {
+			p.next()
+			re, err := regexp.Compile(tok.str)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp at %d: %v", tok.pos, err)
+			}
+			return func(env map[string]any) (any, error) {
+				l, err := left(env)
+				if err != nil {
+					return nil, err
+				}
+				return re.MatchString(toString(l)), nil
+			}, nil
+		}
+	}
+	right, err := p.parseBinary(precedence["=~"])
+	if err != nil {
+		return nil, err
+	}
+	return func(env map[string]any) (any, error) {
+		l, err := left(env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(env)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(toString(r))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(l)), nil
+	}, nil
+}
+
+// parseUnary 解析一元表达式
+func (p *parser) parseUnary() (evalFunc, error) {
+	tok := p.peek()
+	if tok.kind != tokOp || tok.text != "!" && tok.text != "-" {
+		return p.parsePrimary()
+	}
+	p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if tok.text == "!" {
+		return func(env map[string]any) (any, error) {
+			v, err := operand(env)
+			if err != nil {
+				return nil, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator ! on non-bool %v", v)
+			}
+			return !b, nil
+		}, nil
+	}
+	return func(env map[string]any) (any, error) {
+		v, err := operand(env)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator - on non-number %v", v)
+		}
+		return -n, nil
+	}, nil
+}
+
+// parsePrimary 解析字面量、变量、函数调用与括号
+func (p *parser) parsePrimary() (evalFunc, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return constant(tok.num), nil
+	case tokString:
+		return constant(tok.str), nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		}
+		if next := p.peek(); next.kind == tokOp && next.text == "(" {
+			return p.parseCall(tok)
+		}
+		name := tok.text
+		return func(env map[string]any) (any, error) {
+			v, ok := env[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUndefined, name)
+			}
+			return normalize(v), nil
+		}, nil
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+// parseCall 解析函数调用
+func (p *parser) parseCall(name token) (evalFunc, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", name.text, name.pos)
+	}
+	p.next() // (
+
+	var args []evalFunc
+	if tok := p.peek(); tok.kind != tokOp || tok.text != ")" {
+		for {
+			arg, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if tok := p.peek(); tok.kind == tokOp && tok.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) != fn.args {
+		return nil, fmt.Errorf("function %s expects %d arguments, got %d", name.text, fn.args, len(args))
+	}
+
+	return func(env map[string]any) (any, error) {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			v, err := arg(env)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return fn.call(values)
+	}, nil
+}
+
+// constant 常量
+func constant(v any) evalFunc {
+	return func(map[string]any) (any, error) { return v, nil }
+}
+
+// binary 二元运算, 逻辑运算短路求值
+func binary(op string, left, right evalFunc) evalFunc {
+	if op == "||" || op == "&&" {
+		return func(env map[string]any) (any, error) {
+			l, err := left(env)
+			if err != nil {
+				return nil, err
+			}
+			lb, ok := l.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator %s on non-bool %v", op, l)
+			}
+			if lb == (op == "||") {
+				return lb, nil
+			}
+			r, err := right(env)
+			if err != nil {
+				return nil, err
+			}
+			rb, ok := r.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator %s on non-bool %v", op, r)
+			}
+			return rb, nil
+		}
+	}
+
+	return func(env map[string]any) (any, error) {
+		l, err := left(env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(env)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		}
+
+		switch lv := l.(type) {
+		case float64:
+			rv, ok := r.(float64)
+			if !ok {
+				break
+			}
+			switch op {
+			case "<":
+				return lv < rv, nil
+			case "<=":
+				return lv <= rv, nil
+			case ">":
+				return lv > rv, nil
+			case ">=":
+				return lv >= rv, nil
+			case "+":
+				return lv + rv, nil
+			case "-":
+				return lv - rv, nil
+			case "*":
+				return lv * rv, nil
+			case "/":
+				return lv / rv, nil
+			case "%":
+				return math.Mod(lv, rv), nil
+			}
+		case string:
+			rv, ok := r.(string)
+			if !ok {
+				break
+			}
+			switch op {
+			case "<":
+				return lv < rv, nil
+			case "<=":
+				return lv <= rv, nil
+			case ">":
+				return lv > rv, nil
+			case ">=":
+				return lv >= rv, nil
+			case "+":
+				return lv + rv, nil
+			}
+		}
+		return nil, fmt.Errorf("operator %s on %v and %v", op, l, r)
+	}
+}
+
+// normalize 将环境中的值转换为表达式的值类型
+func normalize(v any) any {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case fmt.Stringer:
+		return n.String()
+	}
+	return v
+}
+
+// toString 将值格式化为字符串
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// function 内置函数
+type function struct {
+	args int
+	call func(args []any) (any, error)
+}
+
+// functions 内置函数表
+var functions = map[string]function{
+	"len": {1, func(args []any) (any, error) {
+		return float64(utf8.RuneCountInString(toString(args[0]))), nil
+	}},
+	"contains": {2, func(args []any) (any, error) {
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	}},
+	"hasPrefix": {2, func(args []any) (any, error) {
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	}},
+	"hasSuffix": {2, func(args []any) (any, error) {
+		return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+	}},
+	"lower": {1, func(args []any) (any, error) {
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"upper": {1, func(args []any) (any, error) {
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"trim": {1, func(args []any) (any, error) {
+		return strings.TrimSpace(toString(args[0])), nil
+	}},
+	"replace": {3, func(args []any) (any, error) {
+		return strings.ReplaceAll(toString(args[0]), toString(args[1]), toString(args[2])), nil
+	}},
+	"number": {1, func(args []any) (any, error) {
+		if n, ok := args[0].(float64); ok {
+			return n, nil
+		}
+		return strconv.ParseFloat(strings.TrimSpace(toString(args[0])), 64)
+	}},
+	"string": {1, func(args []any) (any, error) {
+		return toString(args[0]), nil
+	}},
+}
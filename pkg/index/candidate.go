@@ -0,0 +1,202 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// ErrNoCandidate 候选词不存在
+var ErrNoCandidate = errors.New("index: candidate not found")
+
+// maxCandidateRunes 作为候选词的无结果查询的最大字数
+const maxCandidateRunes = 8
+
+// CandidateSource 候选词来源
+type CandidateSource string
+
+const (
+	SourceOOV        CandidateSource = "oov"         // 索引中不存在的查询词
+	SourceZeroResult CandidateSource = "zero-result" // 无结果的短查询
+)
+
+// Candidate 从查询日志中发现的候选词, 经人工确认后加入词典
+type Candidate struct {
+	Word      string          `json:"word"`       // 候选词
+	Source    CandidateSource `json:"source"`     // 来源
+	Count     int64           `json:"count"`      // 查询日志中的出现次数
+	Rejected  bool            `json:"rejected"`   // 已被拒绝, 不再提出
+	UpdatedAt time.Time       `json:"updated_at"` // 更新时间
+}
+
+// WithCandidatePromotion 在周期合并时从查询日志中发现候选词, 出现次数不少于minCount的词进入候选区, 需同时开启查询日志
+func WithCandidatePromotion(minCount int64) Option {
+	return func(i *Index) { i.promoteMin = minCount }
+}
+
+// candidateKeyPrefix 候选词的键前缀
+func (i *Index) candidateKeyPrefix() string {
+	return indexKeyPrefix + i.name + "#candidate:"
+}
+
+// candidateWord 判断词是否可作为候选词: 两个字及以上, 不含空白与标点, 不是数字, 且不在自定义词典中
+func (i *Index) candidateWord(word string) bool {
+	n := utf8.RuneCountInString(word)
+	if n < 2 || n > maxCandidateRunes || strings.ContainsFunc(word, participle.IsPunct) || !correctable(word) {
+		return false
+	}
+	return i.engine == nil || !i.engine.Contains(word)
+}
+
+// PromoteQueryTerms 将查询日志中出现次数不少于minCount的未登录查询词与无结果短查询放入候选区
+// 已在候选区的词更新出现次数, 已被拒绝的词与已在词典中的词跳过; 返回本次新增或更新的候选词
+func (i *Index) PromoteQueryTerms(minCount int64) ([]Candidate, error) {
+	found := make(map[string]Candidate)
+
+	oov, err := i.OOVTerms(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, term := range oov {
+		if int64(term.Value) >= minCount && i.candidateWord(term.Name) {
+			found[term.Name] = Candidate{Word: term.Name, Source: SourceOOV, Count: int64(term.Value)}
+		}
+	}
+
+	zero, err := i.ZeroResultQueries(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range zero {
+		if stat.ZeroResults < minCount || !i.candidateWord(stat.Query) {
+			continue
+		}
+		// 整条无结果查询比拆开的查询词更可能是一个新词
+		found[stat.Query] = Candidate{Word: stat.Query, Source: SourceZeroResult, Count: stat.ZeroResults}
+	}
+
+	var promoted []Candidate
+	now := time.Now()
+	err = i.db.Update(func(tx *bd.Txn) error {
+		for word, candidate := range found {
+			key := []byte(i.candidateKeyPrefix() + word)
+			existing, err := getCandidate(tx, key)
+			switch {
+			case err == nil:
+				if existing.Rejected || existing.Count == candidate.Count {
+					continue
+				}
+			case !errors.Is(err, ErrNoCandidate):
+				return err
+			}
+
+			candidate.UpdatedAt = now
+			data, err := json.Marshal(candidate)
+			if err != nil {
+				return err
+			}
+			if err := tx.Set(key, data); err != nil {
+				return err
+			}
+			promoted = append(promoted, candidate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save candidates fail: %v", err)
+	}
+	sortCandidates(promoted)
+	return promoted, nil
+}
+
+// getCandidate 在事务中读取候选词
+func getCandidate(tx *bd.Txn, key []byte) (Candidate, error) {
+	var candidate Candidate
+	item, err := tx.Get(key)
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return candidate, ErrNoCandidate
+	}
+	if err != nil {
+		return candidate, err
+	}
+	err = item.Value(func(val []byte) error { return json.Unmarshal(val, &candidate) })
+	return candidate, err
+}
+
+// Candidates 获取候选区中未被拒绝的候选词, 按出现次数降序
+func (i *Index) Candidates() ([]Candidate, error) {
+	var candidates []Candidate
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(i.candidateKeyPrefix())
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var candidate Candidate
+			if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &candidate) }); err != nil {
+				return err
+			}
+			if !candidate.Rejected {
+				candidates = append(candidates, candidate)
+			}
+		}
+		return nil
+	})
+	sortCandidates(candidates)
+	return candidates, err
+}
+
+// AcceptCandidate 将候选词加入词典并移出候选区
+// 加入词典后需重建索引, 已索引文档中的该词才会作为一个索引词
+func (i *Index) AcceptCandidate(word string, frequency float64, pos string) error {
+	if i.engine == nil {
+		return errors.New("index: no segmentation engine")
+	}
+	key := []byte(i.candidateKeyPrefix() + word)
+	err := i.db.View(func(tx *bd.Txn) error {
+		_, err := getCandidate(tx, key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := i.engine.AddWord(word, frequency, pos); err != nil {
+		return err
+	}
+	return i.db.Del(key)
+}
+
+// RejectCandidate 拒绝候选词, 之后不再提出
+func (i *Index) RejectCandidate(word string) error {
+	key := []byte(i.candidateKeyPrefix() + word)
+	return i.db.Update(func(tx *bd.Txn) error {
+		candidate, err := getCandidate(tx, key)
+		if err != nil {
+			return err
+		}
+		candidate.Rejected, candidate.UpdatedAt = true, time.Now()
+		data, err := json.Marshal(candidate)
+		if err != nil {
+			return err
+		}
+		return tx.Set(key, data)
+	})
+}
+
+// sortCandidates 按出现次数降序排序, 次数相同时按词排序
+func sortCandidates(candidates []Candidate) {
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].Count != candidates[b].Count {
+			return candidates[a].Count > candidates[b].Count
+		}
+		return candidates[a].Word < candidates[b].Word
+	})
+}
@@ -0,0 +1,107 @@
+package index
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidCursor 游标格式错误
+	ErrInvalidCursor = errors.New("index: invalid cursor")
+	// ErrCursorExpired 创建游标后索引已重建, 需从第一页重新查询
+	ErrCursorExpired = errors.New("index: cursor expired by reindex")
+)
+
+// Page 分页查询结果
+type Page struct {
+	Hits        []Hit        `json:"hits"`                   // 本页结果
+	Cursor      string       `json:"cursor"`                 // 下一页游标, 为空表示没有更多结果
+	DidYouMean  string       `json:"did_you_mean,omitempty"` // 纠错后实际执行的查询, 未纠错时为空
+	Corrections []Correction `json:"corrections,omitempty"`  // 查询词纠错
+}
+
+// SearchPage 分页查询, cursor为上一页返回的游标, 为空时查询第一页
+// 游标记录上一页最后一条结果的得分与文档ID, 下一页只返回排在其后的结果(search-after), 不需要重新跳过前面的结果;
+// 翻页期间写入的文档会改变得分, 结果可能重复或遗漏, 但不会出错; 重建索引后游标失效
+// 翻页时需使用与第一页相同的opts
+// 开启纠错时, 索引中不存在的查询词被替换为最接近的索引词后查询, 改写结果见Page.DidYouMean
+func (i *Index) SearchPage(query string, limit int, cursor string, opts ...SearchOption) (*Page, error) {
+	if limit <= 0 {
+		limit = defaultTopK
+	}
+
+	g := i.acquire()
+	defer g.mu.RUnlock()
+
+	var so searchOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+	page := &Page{}
+	original := query
+	if (so.correct == nil && i.spelling) || (so.correct != nil && *so.correct) {
+		rewritten, corrections, err := i.correct(g, query)
+		if err != nil {
+			return nil, err
+		}
+		if len(corrections) > 0 {
+			query, page.DidYouMean, page.Corrections = rewritten, rewritten, corrections
+		}
+	}
+
+	var after *Hit
+	if cursor != "" {
+		gen, hit, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if gen != g.id {
+			return nil, ErrCursorExpired
+		}
+		after = &hit
+	}
+
+	// 多取一条用于判断是否还有下一页
+	hits, total, err := i.searchAfter(g, query, limit+1, after, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == "" {
+		i.logQuery(g, original, total)
+	}
+	page.Hits = hits
+	if len(hits) > limit {
+		page.Hits = hits[:limit]
+		page.Cursor = encodeCursor(g.id, hits[limit-1])
+	}
+	return page, nil
+}
+
+// encodeCursor 编码游标: 代数、得分与文档ID
+func encodeCursor(gen int, hit Hit) string {
+	raw := strconv.Itoa(gen) + ":" + strconv.FormatFloat(hit.Score, 'g', -1, 64) + ":" + hit.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解码游标
+func decodeCursor(cursor string) (int, Hit, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, Hit{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 {
+		return 0, Hit{}, ErrInvalidCursor
+	}
+	gen, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, Hit{}, ErrInvalidCursor
+	}
+	score, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, Hit{}, ErrInvalidCursor
+	}
+	return gen, Hit{ID: parts[2], Score: score}, nil
+}
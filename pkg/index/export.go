@@ -0,0 +1,157 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	bd "github.com/dgraph-io/badger/v4"
+)
+
+// ExportFormat 索引导出格式
+type ExportFormat string
+
+const (
+	ExportPostings  ExportFormat = "postings"  // 倒排表JSONL, 每行一个索引词及其倒排项
+	ExportDocuments ExportFormat = "documents" // 文档JSONL, 每行一篇文档
+	ExportBulk      ExportFormat = "bulk"      // Elasticsearch/OpenSearch _bulk格式, 可直接导入
+)
+
+// PostingEntry 导出的倒排项
+type PostingEntry struct {
+	ID        string `json:"id"`                  // 文档ID
+	TF        int    `json:"tf"`                  // 词频
+	Positions []int  `json:"positions,omitempty"` // 词位置
+}
+
+// TermPostings 导出的索引词倒排表
+type TermPostings struct {
+	Term     string         `json:"term"`     // 索引词
+	DF       int            `json:"df"`       // 文档频率
+	Postings []PostingEntry `json:"postings"` // 倒排项, 按文档ID升序
+}
+
+// bulkAction Elasticsearch _bulk的操作行
+type bulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"index"`
+}
+
+// Export 按格式导出当前一代的索引, 导出内容来自同一个数据库快照
+// ExportPostings用于迁移到其他倒排索引实现; ExportDocuments与ExportBulk导出原文、字段与时间,
+// 由目标搜索引擎重新分词建立索引, 批量导入时索引名称为当前索引名称
+func (i *Index) Export(w io.Writer, format ExportFormat) error {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	var err error
+	switch format {
+	case ExportPostings:
+		err = i.exportPostings(g, enc)
+	case ExportDocuments, ExportBulk:
+		err = i.exportDocuments(g, enc, format == ExportBulk)
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("export index fail: %v", err)
+	}
+	return bw.Flush()
+}
+
+// exportPostings 导出倒排表, 键按索引词与文档ID排序, 相邻的倒排项合并为一行
+func (i *Index) exportPostings(g *generation, enc *json.Encoder) error {
+	prefix := g.prefix + "post:"
+	return i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		var current *TermPostings
+		flush := func() error {
+			if current == nil {
+				return nil
+			}
+			current.DF = len(current.Postings)
+			return enc.Encode(current)
+		}
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			term, id, ok := strings.Cut(string(item.Key()[len(prefix):]), "\x00")
+			if !ok {
+				continue
+			}
+			if current == nil || current.Term != term {
+				if err := flush(); err != nil {
+					return err
+				}
+				current = &TermPostings{Term: term}
+			}
+			err := item.Value(func(val []byte) error {
+				tf, _ := decodePosting(val)
+				current.Postings = append(current.Postings, PostingEntry{ID: id, TF: tf, Positions: decodePositions(val)})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return flush()
+	})
+}
+
+// exportDocuments 导出文档, bulk为true时每篇文档前写入_bulk操作行
+func (i *Index) exportDocuments(g *generation, enc *json.Encoder, bulk bool) error {
+	prefix := g.prefix + "doc:"
+	return i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			id := string(item.Key()[len(prefix):])
+			var doc document
+			if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &doc) }); err != nil {
+				return err
+			}
+
+			if bulk {
+				var action bulkAction
+				action.Index.Index, action.Index.ID = i.name, id
+				if err := enc.Encode(action); err != nil {
+					return err
+				}
+				source := struct {
+					Text   string            `json:"text"`
+					Fields map[string]string `json:"fields,omitempty"`
+					Time   *time.Time        `json:"time,omitempty"`
+				}{Text: doc.Text, Fields: doc.Fields}
+				if !doc.Time.IsZero() {
+					source.Time = &doc.Time
+				}
+				if err := enc.Encode(source); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := enc.Encode(Document{ID: id, Text: doc.Text, Fields: doc.Fields, Time: doc.Time}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,449 @@
+// Package index 基于分词引擎的倒排索引, 索引数据保存在badger中, 可与词典共用数据库
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// indexKeyPrefix 索引数据的键前缀, 键为前缀+索引名称+[#代数]+":"+数据类型+...
+const indexKeyPrefix = participle.MetaKeyPrefix + "index:"
+
+// ErrNotFound 文档不存在
+var ErrNotFound = errors.New("index: document not found")
+
+// Analyzer 分析器, 将文本转换为索引词
+type Analyzer func(text string) []string
+
+// DefaultAnalyzer 默认分析器, 分词后保留汉字、拉丁字母与数字词
+func DefaultAnalyzer(engine *participle.Engine) Analyzer {
+	return func(text string) []string {
+		var terms []string
+		for _, token := range engine.SegmentTokens(text) {
+			switch token.Type {
+			case participle.TokenHan, participle.TokenLatin, participle.TokenNumber:
+				terms = append(terms, token.Text)
+			}
+		}
+		return terms
+	}
+}
+
+// Option 索引配置项
+type Option func(*Index)
+
+// WithAnalyzer 设置分析器, 默认使用DefaultAnalyzer
+// 重建索引更换分析器后, 重新打开索引时需传入相同的分析器
+func WithAnalyzer(analyzer Analyzer) Option {
+	return func(i *Index) { i.analyzer = analyzer }
+}
+
+// WithConsolidation 设置文档频率计数的合并周期, 默认1分钟, 小于等于0时仅在Consolidate与Close时合并
+func WithConsolidation(interval time.Duration) Option {
+	return func(i *Index) { i.interval = interval }
+}
+
+// Document 待索引的文档
+type Document struct {
+	ID     string            `json:"id"`               // 文档ID
+	Text   string            `json:"text"`             // 原文
+	Fields map[string]string `json:"fields,omitempty"` // 字段, 不参与分词, 供自定义打分使用
+	Time   time.Time         `json:"time,omitzero"`    // 文档时间, 供按时间加权使用
+}
+
+// Meta 文档元数据, 自定义打分时读取
+type Meta struct {
+	ID     string            `json:"-"`
+	Fields map[string]string `json:"fields,omitempty"`
+	Time   time.Time         `json:"time,omitzero"`
+}
+
+// document 已索引的文档
+type document struct {
+	Text   string            `json:"text"`             // 原文
+	Terms  map[string]int    `json:"terms"`            // 索引词及词频
+	Length int               `json:"length"`           // 索引词总数
+	Fields map[string]string `json:"fields,omitempty"` // 字段
+	Time   time.Time         `json:"time,omitzero"`    // 文档时间
+}
+
+// generation 索引的一代数据, 重建索引时在新一代中写入, 完成后切换
+type generation struct {
+	id       int      // 代数
+	prefix   string   // 键前缀
+	analyzer Analyzer // 分析器
+	pending  counters // 尚未合并的计数变化, 由Index.mu保护
+
+	mu      sync.RWMutex // 查询持有读锁, 删除数据前持有写锁
+	dropped bool         // 数据已删除
+}
+
+// Index 倒排索引
+// 文档与倒排表在索引时直接写入数据库; 文档频率与文档总数的变化先累积在内存中, 周期性合并写入数据库,
+// 避免高频词的计数键在每次索引时都被改写
+type Index struct {
+	db       *badger.Engine
+	engine   *participle.Engine
+	name     string
+	analyzer Analyzer
+	interval time.Duration
+	spelling bool // 默认是否纠错
+
+	promoteMin int64 // 周期合并时发现候选词的最少出现次数, 0表示不发现
+
+	gen atomic.Pointer[generation] // 当前查询使用的一代
+
+	mu     sync.Mutex  // 写锁
+	shadow *generation // 重建中的一代, 写入同时应用到该代
+
+	scorerMu sync.RWMutex
+	scorers  map[string]ScoreFunc // 已注册的打分函数
+
+	qmu      sync.Mutex
+	queryLog *queryLog // 查询日志, 未开启时为nil
+
+	done chan struct{} // 退出信号
+	stop chan struct{} // 退出成功信号
+}
+
+// New 创建或打开名为name的索引
+func New(db *badger.Engine, engine *participle.Engine, name string, opts ...Option) (*Index, error) {
+	i := &Index{
+		db:       db,
+		engine:   engine,
+		name:     name,
+		interval: time.Minute,
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	if i.analyzer == nil {
+		i.analyzer = DefaultAnalyzer(engine)
+	}
+
+	id := 0
+	data, err := db.Get(i.activeKey())
+	switch {
+	case err == nil:
+		if id, err = strconv.Atoi(string(data)); err != nil {
+			return nil, fmt.Errorf("decode index generation fail: %v", err)
+		}
+	case !errors.Is(err, bd.ErrKeyNotFound):
+		return nil, fmt.Errorf("load index generation fail: %v", err)
+	}
+	i.gen.Store(i.newGeneration(id, i.analyzer))
+
+	go i.listener()
+	return i, nil
+}
+
+// Name 索引名称
+func (i *Index) Name() string { return i.name }
+
+// Generation 当前索引代数, 每次重建索引后加一
+func (i *Index) Generation() int { return i.gen.Load().id }
+
+// activeKey 当前代数的键
+func (i *Index) activeKey() []byte {
+	return []byte(indexKeyPrefix + i.name + "#active")
+}
+
+// newGeneration 创建一代索引
+func (i *Index) newGeneration(id int, analyzer Analyzer) *generation {
+	prefix := indexKeyPrefix + i.name + ":"
+	if id > 0 {
+		prefix = fmt.Sprintf("%s%s#%d:", indexKeyPrefix, i.name, id)
+	}
+	return &generation{id: id, prefix: prefix, analyzer: analyzer, pending: newCounters()}
+}
+
+// docKey 文档的键
+func (g *generation) docKey(id string) []byte {
+	return []byte(g.prefix + "doc:" + id)
+}
+
+// metaKey 文档元数据的键, 只在文档有字段或时间时写入
+func (g *generation) metaKey(id string) []byte {
+	return []byte(g.prefix + "meta:" + id)
+}
+
+// postingPrefix 索引词倒排表的键前缀
+func (g *generation) postingPrefix(term string) string {
+	return g.prefix + "post:" + term + "\x00"
+}
+
+// postingKey 倒排项的键
+func (g *generation) postingKey(term, id string) []byte {
+	return []byte(g.postingPrefix(term) + id)
+}
+
+// Index 索引文档, 已存在的文档被替换
+func (i *Index) Index(id, text string) error {
+	return i.IndexDocument(Document{ID: id, Text: text})
+}
+
+// IndexDocument 索引带字段与时间的文档, 已存在的文档被替换
+func (i *Index) IndexDocument(doc Document) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.indexInto(i.gen.Load(), doc); err != nil {
+		return err
+	}
+	if i.shadow != nil {
+		return i.indexInto(i.shadow, doc)
+	}
+	return nil
+}
+
+// indexInto 将文档写入指定的一代, 调用方持有写锁
+func (i *Index) indexInto(g *generation, src Document) error {
+	id := src.ID
+	doc := document{Text: src.Text, Terms: make(map[string]int), Fields: src.Fields, Time: src.Time}
+	positions := make(map[string][]int)
+	for p, term := range g.analyzer(src.Text) {
+		doc.Terms[term]++
+		doc.Length++
+		positions[term] = append(positions[term], p)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var meta []byte
+	if len(doc.Fields) > 0 || !doc.Time.IsZero() {
+		if meta, err = json.Marshal(Meta{Fields: doc.Fields, Time: doc.Time}); err != nil {
+			return err
+		}
+	}
+
+	delta := newCounters()
+	err = i.db.Update(func(tx *bd.Txn) error {
+		old, err := g.getDocument(tx, id)
+		switch {
+		case err == nil:
+			if err := g.removePostings(tx, id, old, &delta); err != nil {
+				return err
+			}
+		case !errors.Is(err, ErrNotFound):
+			return err
+		}
+
+		for term := range doc.Terms {
+			if err := tx.Set(g.postingKey(term, id), encodePosting(positions[term], doc.Length)); err != nil {
+				return err
+			}
+			delta.df[term]++
+		}
+		delta.docs++
+		delta.length += int64(doc.Length)
+		if meta != nil {
+			if err := tx.Set(g.metaKey(id), meta); err != nil {
+				return err
+			}
+		}
+		return tx.Set(g.docKey(id), data)
+	})
+	if err != nil {
+		return fmt.Errorf("index document %s fail: %v", id, err)
+	}
+	g.pending.merge(delta)
+	return nil
+}
+
+// Delete 删除文档, 文档不存在时返回ErrNotFound
+func (i *Index) Delete(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.deleteFrom(i.gen.Load(), id); err != nil {
+		return err
+	}
+	if i.shadow != nil {
+		if err := i.deleteFrom(i.shadow, id); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFrom 从指定的一代删除文档, 调用方持有写锁
+func (i *Index) deleteFrom(g *generation, id string) error {
+	delta := newCounters()
+	err := i.db.Update(func(tx *bd.Txn) error {
+		old, err := g.getDocument(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := g.removePostings(tx, id, old, &delta); err != nil {
+			return err
+		}
+		return tx.Delete(g.docKey(id))
+	})
+	if errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("delete document %s fail: %v", id, err)
+	}
+	g.pending.merge(delta)
+	return nil
+}
+
+// Get 获取文档原文
+func (i *Index) Get(id string) (string, error) {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+
+	var doc document
+	err := i.db.View(func(tx *bd.Txn) error {
+		var err error
+		doc, err = g.getDocument(tx, id)
+		return err
+	})
+	return doc.Text, err
+}
+
+// acquire 获取当前一代并持有其读锁, 保证查询期间数据不被删除
+func (i *Index) acquire() *generation {
+	for {
+		g := i.gen.Load()
+		g.mu.RLock()
+		if !g.dropped {
+			return g
+		}
+		g.mu.RUnlock()
+	}
+}
+
+// getDocument 在事务中读取文档
+func (g *generation) getDocument(tx *bd.Txn, id string) (document, error) {
+	var doc document
+	item, err := tx.Get(g.docKey(id))
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return doc, ErrNotFound
+	}
+	if err != nil {
+		return doc, err
+	}
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &doc)
+	})
+	return doc, err
+}
+
+// removePostings 在事务中删除文档的倒排项, 并记录计数变化
+func (g *generation) removePostings(tx *bd.Txn, id string, doc document, delta *counters) error {
+	for term := range doc.Terms {
+		if err := tx.Delete(g.postingKey(term, id)); err != nil {
+			return err
+		}
+		delta.df[term]--
+	}
+	if len(doc.Fields) > 0 || !doc.Time.IsZero() {
+		if err := tx.Delete(g.metaKey(id)); err != nil {
+			return err
+		}
+	}
+	delta.docs--
+	delta.length -= int64(doc.Length)
+	return nil
+}
+
+// encodePosting 编码倒排项: 词频、文档长度与差值编码的词位置
+func encodePosting(positions []int, length int) []byte {
+	buf := binary.AppendUvarint(nil, uint64(len(positions)))
+	buf = binary.AppendUvarint(buf, uint64(length))
+	last := 0
+	for _, p := range positions {
+		buf = binary.AppendUvarint(buf, uint64(p-last))
+		last = p
+	}
+	return buf
+}
+
+// decodePosting 解码倒排项, 缺少文档长度时length为-1
+func decodePosting(val []byte) (tf, length int) {
+	v, n := binary.Uvarint(val)
+	if n <= 0 {
+		return 0, -1
+	}
+	l, m := binary.Uvarint(val[n:])
+	if m <= 0 {
+		return int(v), -1
+	}
+	return int(v), int(l)
+}
+
+// decodePositions 解码倒排项中的词位置, 早期写入的倒排项没有位置, 返回nil
+func decodePositions(val []byte) []int {
+	tf, n := binary.Uvarint(val)
+	if n <= 0 {
+		return nil
+	}
+	val = val[n:]
+	if _, n = binary.Uvarint(val); n <= 0 {
+		return nil
+	}
+	val = val[n:]
+
+	positions := make([]int, 0, tf)
+	last := 0
+	for len(val) > 0 && uint64(len(positions)) < tf {
+		d, n := binary.Uvarint(val)
+		if n <= 0 {
+			return nil
+		}
+		last += int(d)
+		positions = append(positions, last)
+		val = val[n:]
+	}
+	return positions
+}
+
+// listener 周期性合并计数
+func (i *Index) listener() {
+	defer close(i.stop)
+	if i.interval <= 0 {
+		<-i.done
+		return
+	}
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			i.Consolidate()
+			i.flushQueryLog()
+			if i.promoteMin > 0 && i.queryLog != nil {
+				i.PromoteQueryTerms(i.promoteMin)
+			}
+		case <-i.done:
+			return
+		}
+	}
+}
+
+// Close 停止周期合并并合并剩余的计数变化与查询日志, 不关闭数据库
+func (i *Index) Close() error {
+	close(i.done)
+	<-i.stop
+	if err := i.flushQueryLog(); err != nil {
+		return err
+	}
+	return i.Consolidate()
+}
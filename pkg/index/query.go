@@ -0,0 +1,187 @@
+package index
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// defaultNear 未指定距离的NEAR运算符允许间隔的词数
+const defaultNear = 5
+
+// reNear NEAR运算符: "NEAR"或"NEAR/3"
+var reNear = regexp.MustCompile(`^NEAR(?:/(\d+))?$`)
+
+// constraint 查询中必须满足的位置约束
+// right为空时为短语约束: left中的词必须连续出现
+// 否则为邻近约束: left与right之间间隔的词数不超过near
+type constraint struct {
+	left  []string
+	right []string
+	near  int
+}
+
+// parsedQuery 解析后的查询
+type parsedQuery struct {
+	terms       []string     // 全部查询词, 去重, 参与打分
+	constraints []constraint // 位置约束, 文档必须全部满足
+}
+
+// operand 查询中的一个操作数
+type operand struct {
+	terms  []string
+	phrase bool // 是否为引号括起的短语
+}
+
+// parseQuery 解析查询
+// 支持引号括起的短语("南山区科技园")与邻近运算符(深圳 NEAR/3 政策); 其余文本经分析器得到可选的查询词
+// 只包含普通查询词时匹配任一查询词的文档, 包含短语或邻近运算符时文档必须满足全部约束
+func parseQuery(query string, analyzer Analyzer) parsedQuery {
+	var operands []operand
+	var nears []int // nears[k]为operands[k]与operands[k+1]之间的NEAR距离, -1表示没有运算符
+	pendingNear := -1
+
+	push := func(op operand) {
+		if len(op.terms) == 0 {
+			return
+		}
+		if len(operands) > 0 {
+			nears = append(nears, pendingNear)
+		}
+		operands = append(operands, op)
+		pendingNear = -1
+	}
+
+	rest := query
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			text := rest[1:]
+			if end >= 0 {
+				text, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				rest = ""
+			}
+			push(operand{terms: analyzer(text), phrase: true})
+			continue
+		}
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+		if m := reNear.FindStringSubmatch(word); m != nil && len(operands) > 0 {
+			pendingNear = defaultNear
+			if m[1] != "" {
+				pendingNear, _ = strconv.Atoi(m[1])
+			}
+			continue
+		}
+		push(operand{terms: analyzer(word)})
+	}
+
+	var q parsedQuery
+	seen := make(map[string]bool)
+	for k, op := range operands {
+		for _, term := range op.terms {
+			if !seen[term] {
+				seen[term] = true
+				q.terms = append(q.terms, term)
+			}
+		}
+		if op.phrase && len(op.terms) > 1 {
+			q.constraints = append(q.constraints, constraint{left: op.terms})
+		}
+		if k > 0 && nears[k-1] >= 0 {
+			q.constraints = append(q.constraints, constraint{left: operands[k-1].terms, right: op.terms, near: nears[k-1]})
+		}
+	}
+	return q
+}
+
+// required 约束涉及的全部查询词
+func (q parsedQuery) required() map[string]bool {
+	terms := make(map[string]bool)
+	for _, c := range q.constraints {
+		for _, term := range c.left {
+			terms[term] = true
+		}
+		for _, term := range c.right {
+			terms[term] = true
+		}
+	}
+	return terms
+}
+
+// match 判断文档的词位置是否满足全部约束
+func (q parsedQuery) match(positions map[string][]int) bool {
+	for _, c := range q.constraints {
+		left := occurrences(c.left, positions)
+		if len(left) == 0 {
+			return false
+		}
+		if len(c.right) == 0 {
+			continue
+		}
+		right := occurrences(c.right, positions)
+		if !near(left, len(c.left), right, len(c.right), c.near) {
+			return false
+		}
+	}
+	return true
+}
+
+// occurrences 词序列在文档中连续出现的起始位置
+func occurrences(seq []string, positions map[string][]int) []int {
+	var starts []int
+	for _, p := range positions[seq[0]] {
+		ok := true
+		for j := 1; j < len(seq) && ok; j++ {
+			ok = containsPosition(positions[seq[j]], p+j)
+		}
+		if ok {
+			starts = append(starts, p)
+		}
+	}
+	return starts
+}
+
+// containsPosition 在升序位置列表中查找位置
+func containsPosition(list []int, p int) bool {
+	lo, hi := 0, len(list)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case list[mid] == p:
+			return true
+		case list[mid] < p:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false
+}
+
+// near 判断两组出现位置中是否有间隔不超过k个词的一对, 不区分先后
+func near(a []int, lenA int, b []int, lenB int, k int) bool {
+	for _, pa := range a {
+		for _, pb := range b {
+			gap := pb - (pa + lenA)
+			if pb < pa {
+				gap = pa - (pb + lenB)
+			}
+			if gap <= k {
+				return true
+			}
+		}
+	}
+	return false
+}
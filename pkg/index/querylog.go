@@ -0,0 +1,270 @@
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// QueryStat 查询统计
+type QueryStat struct {
+	Query       string    `json:"query"`        // 查询
+	Count       int64     `json:"count"`        // 查询次数
+	ZeroResults int64     `json:"zero_results"` // 无结果的次数
+	LastHits    int       `json:"last_hits"`    // 最近一次的匹配文档数
+	LastSeen    time.Time `json:"last_seen"`    // 最近一次查询时间
+}
+
+// queryLog 尚未写入数据库的查询统计, 由Index.qmu保护
+type queryLog struct {
+	queries map[string]*QueryStat // 查询 -> 统计变化
+	oov     map[string]int64      // 索引中不存在的查询词 -> 次数变化
+}
+
+// WithQueryLog 开启查询日志, 记录查询次数、匹配文档数与索引中不存在的查询词
+// 日志先累积在内存中, 与文档频率一同周期性写入数据库, 重建索引后保留
+func WithQueryLog() Option {
+	return func(i *Index) {
+		i.queryLog = &queryLog{queries: make(map[string]*QueryStat), oov: make(map[string]int64)}
+	}
+}
+
+// queryKeyPrefix 查询统计的键前缀
+func (i *Index) queryKeyPrefix() string {
+	return indexKeyPrefix + i.name + "#query:"
+}
+
+// oovKeyPrefix 未登录查询词计数的键前缀
+func (i *Index) oovKeyPrefix() string {
+	return indexKeyPrefix + i.name + "#oov:"
+}
+
+// normalizeQuery 规范化查询, 合并连续空白
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// logQuery 记录一次查询, 未开启查询日志时忽略
+func (i *Index) logQuery(g *generation, query string, hits int) {
+	if i.queryLog == nil {
+		return
+	}
+	query = normalizeQuery(query)
+	if query == "" {
+		return
+	}
+
+	var oov []string
+	terms := parseQuery(query, g.analyzer).terms
+	if dfs, err := i.docFreqs(g, terms); err == nil {
+		for _, term := range terms {
+			if dfs[term] == 0 {
+				oov = append(oov, term)
+			}
+		}
+	}
+
+	i.qmu.Lock()
+	defer i.qmu.Unlock()
+	stat, ok := i.queryLog.queries[query]
+	if !ok {
+		stat = &QueryStat{Query: query}
+		i.queryLog.queries[query] = stat
+	}
+	stat.Count++
+	if hits == 0 {
+		stat.ZeroResults++
+	}
+	stat.LastHits, stat.LastSeen = hits, time.Now()
+	for _, term := range oov {
+		i.queryLog.oov[term]++
+	}
+}
+
+// flushQueryLog 将内存中的查询日志写入数据库, 写入失败时保留在内存中
+func (i *Index) flushQueryLog() error {
+	if i.queryLog == nil {
+		return nil
+	}
+
+	i.qmu.Lock()
+	defer i.qmu.Unlock()
+	if len(i.queryLog.queries) == 0 && len(i.queryLog.oov) == 0 {
+		return nil
+	}
+
+	err := i.db.Update(func(tx *bd.Txn) error {
+		for query, delta := range i.queryLog.queries {
+			key := []byte(i.queryKeyPrefix() + query)
+			stat := QueryStat{Query: query}
+			item, err := tx.Get(key)
+			switch {
+			case err == nil:
+				if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &stat) }); err != nil {
+					return err
+				}
+			case !errors.Is(err, bd.ErrKeyNotFound):
+				return err
+			}
+			stat.Count += delta.Count
+			stat.ZeroResults += delta.ZeroResults
+			stat.LastHits, stat.LastSeen = delta.LastHits, delta.LastSeen
+
+			data, err := json.Marshal(stat)
+			if err != nil {
+				return err
+			}
+			if err := tx.Set(key, data); err != nil {
+				return err
+			}
+		}
+		for term, n := range i.queryLog.oov {
+			key := []byte(i.oovKeyPrefix() + term)
+			count, err := readCount(tx, key)
+			if err != nil {
+				return err
+			}
+			if err := writeCount(tx, key, count+n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("flush query log fail: %v", err)
+	}
+	i.queryLog.queries = make(map[string]*QueryStat)
+	i.queryLog.oov = make(map[string]int64)
+	return nil
+}
+
+// queryStats 读取全部查询统计, 先写入内存中的查询日志
+func (i *Index) queryStats() ([]QueryStat, error) {
+	if err := i.flushQueryLog(); err != nil {
+		return nil, err
+	}
+
+	var stats []QueryStat
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(i.queryKeyPrefix())
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var stat QueryStat
+			if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &stat) }); err != nil {
+				return err
+			}
+			stats = append(stats, stat)
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// TopQueries 查询次数最多的查询, n为返回数量, 0表示全部返回
+func (i *Index) TopQueries(n int) ([]QueryStat, error) {
+	stats, err := i.queryStats()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(stats, func(a, b int) bool {
+		if stats[a].Count != stats[b].Count {
+			return stats[a].Count > stats[b].Count
+		}
+		return stats[a].Query < stats[b].Query
+	})
+	return truncate(stats, n), nil
+}
+
+// ZeroResultQueries 出现过无结果的查询, 按无结果次数降序, n为返回数量, 0表示全部返回
+func (i *Index) ZeroResultQueries(n int) ([]QueryStat, error) {
+	stats, err := i.queryStats()
+	if err != nil {
+		return nil, err
+	}
+	zero := stats[:0]
+	for _, stat := range stats {
+		if stat.ZeroResults > 0 {
+			zero = append(zero, stat)
+		}
+	}
+	sort.Slice(zero, func(a, b int) bool {
+		if zero[a].ZeroResults != zero[b].ZeroResults {
+			return zero[a].ZeroResults > zero[b].ZeroResults
+		}
+		return zero[a].Query < zero[b].Query
+	})
+	return truncate(zero, n), nil
+}
+
+// OOVTerms 查询中出现、索引中不存在的词, 按出现次数降序, 可作为加入词典的候选
+// n为返回数量, 0表示全部返回
+func (i *Index) OOVTerms(n int) ([]participle.WordFrequency, error) {
+	if err := i.flushQueryLog(); err != nil {
+		return nil, err
+	}
+
+	var terms []participle.WordFrequency
+	prefix := i.oovKeyPrefix()
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			term := string(item.Key()[len(prefix):])
+			err := item.Value(func(val []byte) error {
+				terms = append(terms, participle.WordFrequency{Name: term, Value: float64(binary.BigEndian.Uint64(val))})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(terms, func(a, b int) bool {
+		if terms[a].Value != terms[b].Value {
+			return terms[a].Value > terms[b].Value
+		}
+		return terms[a].Name < terms[b].Name
+	})
+	return truncate(terms, n), nil
+}
+
+// ClearQueryLog 清除查询日志
+func (i *Index) ClearQueryLog() error {
+	i.qmu.Lock()
+	defer i.qmu.Unlock()
+	if i.queryLog != nil {
+		i.queryLog.queries = make(map[string]*QueryStat)
+		i.queryLog.oov = make(map[string]int64)
+	}
+	for _, prefix := range []string{i.queryKeyPrefix(), i.oovKeyPrefix()} {
+		if err := i.db.DB().DropPrefix([]byte(prefix)); err != nil {
+			return fmt.Errorf("clear query log fail: %v", err)
+		}
+	}
+	return nil
+}
+
+// truncate 保留前n个元素, n小于等于0时全部保留
+func truncate[T any](list []T, n int) []T {
+	if n > 0 && len(list) > n {
+		return list[:n]
+	}
+	return list
+}
@@ -0,0 +1,260 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	bd "github.com/dgraph-io/badger/v4"
+)
+
+var (
+	// ErrReindexing 已有重建索引任务在运行
+	ErrReindexing = errors.New("index: reindex already running")
+	// ErrVerification 新旧索引的查询结果差异过大, 未切换
+	ErrVerification = errors.New("index: reindex verification failed")
+)
+
+const (
+	// defaultSampleSize 默认自动抽样的校验查询数
+	defaultSampleSize = 20
+	// defaultTopK 默认比较的查询结果数
+	defaultTopK = 10
+	// sampleQueryRunes 自动抽样时从文档截取的查询长度
+	sampleQueryRunes = 32
+)
+
+// ReindexOptions 重建索引配置
+type ReindexOptions struct {
+	Samples    []string // 校验用的查询, 为空时从文档中抽样
+	SampleSize int      // 自动抽样的查询数, 默认20
+	TopK       int      // 比较的结果数, 默认10
+	MinOverlap float64  // 新旧结果的最小平均重合度, 低于时放弃切换, 0表示只报告不拦截
+}
+
+// SampleResult 校验查询的结果
+type SampleResult struct {
+	Query   string  `json:"query"`   // 查询
+	Old     []Hit   `json:"old"`     // 旧索引结果
+	New     []Hit   `json:"new"`     // 新索引结果
+	Overlap float64 `json:"overlap"` // 结果重合度
+}
+
+// ReindexReport 重建索引报告
+type ReindexReport struct {
+	Generation int            `json:"generation"` // 新索引代数
+	Documents  int64          `json:"documents"`  // 重建的文档数
+	Samples    []SampleResult `json:"samples"`    // 校验查询结果
+	Overlap    float64        `json:"overlap"`    // 平均重合度
+	Switched   bool           `json:"switched"`   // 是否已切换到新索引
+}
+
+// Reindex 使用新的分析器重建索引
+// 新索引写入独立的一代, 重建期间查询仍使用当前一代, 写入同时应用到新旧两代;
+// 重建完成后以校验查询比较新旧结果, 通过后原子切换, 并删除旧一代的数据
+func (i *Index) Reindex(ctx context.Context, analyzer Analyzer, opts ReindexOptions) (*ReindexReport, error) {
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = defaultSampleSize
+	}
+	if opts.TopK <= 0 {
+		opts.TopK = defaultTopK
+	}
+
+	i.mu.Lock()
+	if i.shadow != nil {
+		i.mu.Unlock()
+		return nil, ErrReindexing
+	}
+	old := i.gen.Load()
+	shadow := i.newGeneration(old.id+1, analyzer)
+	i.shadow = shadow
+	i.mu.Unlock()
+
+	report := &ReindexReport{Generation: shadow.id}
+	// 清理上次中断的重建留下的数据
+	if err := i.db.DB().DropPrefix([]byte(shadow.prefix)); err != nil {
+		i.abort(shadow)
+		return nil, fmt.Errorf("clear reindex namespace fail: %v", err)
+	}
+
+	ids, err := i.documentIDs(old)
+	if err != nil {
+		i.abort(shadow)
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			i.abort(shadow)
+			return report, err
+		}
+		copied, err := i.copyDocument(old, shadow, id)
+		if err != nil {
+			i.abort(shadow)
+			return report, err
+		}
+		if copied {
+			report.Documents++
+		}
+	}
+
+	if err := i.verify(old, shadow, ids, opts, report); err != nil {
+		i.abort(shadow)
+		return report, err
+	}
+	if opts.MinOverlap > 0 && report.Overlap < opts.MinOverlap {
+		i.abort(shadow)
+		return report, ErrVerification
+	}
+
+	i.mu.Lock()
+	err = i.consolidate(shadow)
+	if err == nil {
+		err = i.db.Set(i.activeKey(), []byte(strconv.Itoa(shadow.id)))
+	}
+	if err != nil {
+		i.mu.Unlock()
+		i.abort(shadow)
+		return report, fmt.Errorf("switch index generation fail: %v", err)
+	}
+	i.gen.Store(shadow)
+	i.shadow = nil
+	i.analyzer = analyzer
+	i.mu.Unlock()
+	report.Switched = true
+
+	// 等待旧一代上的查询结束后删除数据
+	old.mu.Lock()
+	old.dropped = true
+	old.mu.Unlock()
+	if err := i.db.DB().DropPrefix([]byte(old.prefix)); err != nil {
+		return report, fmt.Errorf("drop old index generation fail: %v", err)
+	}
+	return report, nil
+}
+
+// abort 放弃重建, 删除新一代的数据
+func (i *Index) abort(shadow *generation) {
+	i.mu.Lock()
+	if i.shadow == shadow {
+		i.shadow = nil
+	}
+	i.mu.Unlock()
+	i.db.DB().DropPrefix([]byte(shadow.prefix))
+}
+
+// documentIDs 获取指定一代的全部文档ID
+func (i *Index) documentIDs(g *generation) ([]string, error) {
+	var ids []string
+	prefix := g.prefix + "doc:"
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			ids = append(ids, string(it.Item().Key()[len(prefix):]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list documents fail: %v", err)
+	}
+	return ids, nil
+}
+
+// copyDocument 将文档从旧一代复制到新一代
+// 持有写锁读取旧一代的最新内容, 避免覆盖重建期间的写入; 文档已被删除时返回false
+func (i *Index) copyDocument(old, shadow *generation, id string) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var doc document
+	err := i.db.View(func(tx *bd.Txn) error {
+		var err error
+		doc, err = old.getDocument(tx, id)
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, i.indexInto(shadow, Document{ID: id, Text: doc.Text, Fields: doc.Fields, Time: doc.Time})
+}
+
+// verify 以校验查询比较新旧两代的结果
+func (i *Index) verify(old, shadow *generation, ids []string, opts ReindexOptions, report *ReindexReport) error {
+	queries := opts.Samples
+	if len(queries) == 0 {
+		var err error
+		if queries, err = i.sampleQueries(old, ids, opts.SampleSize); err != nil {
+			return err
+		}
+	}
+
+	total := 0.0
+	for _, query := range queries {
+		oldHits, err := i.search(old, query, opts.TopK)
+		if err != nil {
+			return err
+		}
+		newHits, err := i.search(shadow, query, opts.TopK)
+		if err != nil {
+			return err
+		}
+		result := SampleResult{Query: query, Old: oldHits, New: newHits, Overlap: overlap(oldHits, newHits)}
+		report.Samples = append(report.Samples, result)
+		total += result.Overlap
+	}
+	report.Overlap = 1
+	if len(queries) > 0 {
+		report.Overlap = total / float64(len(queries))
+	}
+	return nil
+}
+
+// sampleQueries 从文档中均匀抽样, 截取文档开头作为校验查询
+func (i *Index) sampleQueries(g *generation, ids []string, n int) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	step := max(len(ids)/n, 1)
+
+	var queries []string
+	err := i.db.View(func(tx *bd.Txn) error {
+		for k := 0; k < len(ids) && len(queries) < n; k += step {
+			doc, err := g.getDocument(tx, ids[k])
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			text := []rune(doc.Text)
+			queries = append(queries, string(text[:min(len(text), sampleQueryRunes)]))
+		}
+		return nil
+	})
+	return queries, err
+}
+
+// overlap 两组结果的重合度: 共同文档数除以较大的结果数, 均为空时为1
+func overlap(a, b []Hit) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	ids := make(map[string]bool, len(a))
+	for _, hit := range a {
+		ids[hit.ID] = true
+	}
+	same := 0
+	for _, hit := range b {
+		if ids[hit.ID] {
+			same++
+		}
+	}
+	return float64(same) / float64(max(len(a), len(b)))
+}
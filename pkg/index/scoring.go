@@ -0,0 +1,142 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	bd "github.com/dgraph-io/badger/v4"
+)
+
+// ErrUnknownScorer 打分函数未注册
+var ErrUnknownScorer = errors.New("index: unknown scorer")
+
+// ScoreFunc 自定义打分函数, 在BM25得分的基础上返回调整后的得分
+type ScoreFunc func(meta Meta, score float64) float64
+
+// SearchOption 查询配置项
+type SearchOption func(*searchOptions)
+
+// searchOptions 查询配置
+type searchOptions struct {
+	scorers []ScoreFunc
+	correct *bool // 是否纠错, nil时使用索引的默认开关
+	err     error
+}
+
+// WithScoreFunc 使用打分函数调整得分, 多个打分函数按顺序依次应用
+func WithScoreFunc(f ScoreFunc) SearchOption {
+	return func(o *searchOptions) { o.scorers = append(o.scorers, f) }
+}
+
+// WithBoost 使用加权表达式调整得分, 表达式格式见ParseBoost
+func WithBoost(expr string) SearchOption {
+	return func(o *searchOptions) {
+		f, err := ParseBoost(expr)
+		if err != nil {
+			o.err = err
+			return
+		}
+		o.scorers = append(o.scorers, f)
+	}
+}
+
+// RegisterScorer 以名称注册打分函数, 查询时通过WithScorer引用, 同名覆盖
+func (i *Index) RegisterScorer(name string, f ScoreFunc) {
+	i.scorerMu.Lock()
+	defer i.scorerMu.Unlock()
+	if i.scorers == nil {
+		i.scorers = make(map[string]ScoreFunc)
+	}
+	i.scorers[name] = f
+}
+
+// WithScorer 使用已注册的打分函数调整得分, 未注册时查询返回ErrUnknownScorer
+func (i *Index) WithScorer(name string) SearchOption {
+	i.scorerMu.RLock()
+	f, ok := i.scorers[name]
+	i.scorerMu.RUnlock()
+	return func(o *searchOptions) {
+		if !ok {
+			o.err = fmt.Errorf("%w: %s", ErrUnknownScorer, name)
+			return
+		}
+		o.scorers = append(o.scorers, f)
+	}
+}
+
+// FieldBoost 字段等于指定值的文档得分乘以factor
+func FieldBoost(field, value string, factor float64) ScoreFunc {
+	return func(meta Meta, score float64) float64 {
+		if meta.Fields[field] == value {
+			return score * factor
+		}
+		return score
+	}
+}
+
+// RecencyBoost 按文档时间加权, 得分乘以1+factor*0.5^(age/halfLife), 越新的文档加权越多, 没有时间的文档不加权
+func RecencyBoost(halfLife time.Duration, factor float64) ScoreFunc {
+	return func(meta Meta, score float64) float64 {
+		if meta.Time.IsZero() || halfLife <= 0 {
+			return score
+		}
+		age := max(time.Since(meta.Time), 0)
+		return score * (1 + factor*math.Pow(0.5, float64(age)/float64(halfLife)))
+	}
+}
+
+// ParseBoost 解析加权表达式
+// "field=value^2": 字段等于value的文档得分乘以2
+// "recency:72h^1.5": 按文档时间加权, 半衰期72小时, 见RecencyBoost
+// 省略"^factor"时factor为2
+func ParseBoost(expr string) (ScoreFunc, error) {
+	body, factorText, hasFactor := strings.Cut(strings.TrimSpace(expr), "^")
+	factor := 2.0
+	if hasFactor {
+		f, err := strconv.ParseFloat(factorText, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse boost factor %q fail: %v", expr, err)
+		}
+		factor = f
+	}
+
+	if rest, ok := strings.CutPrefix(body, "recency:"); ok {
+		halfLife, err := time.ParseDuration(rest)
+		if err != nil {
+			return nil, fmt.Errorf("parse boost half-life %q fail: %v", expr, err)
+		}
+		return RecencyBoost(halfLife, factor), nil
+	}
+	if field, value, ok := strings.Cut(body, "="); ok && field != "" {
+		return FieldBoost(field, value, factor), nil
+	}
+	return nil, fmt.Errorf("invalid boost expression: %q", expr)
+}
+
+// applyScorers 读取候选文档的元数据并依次应用打分函数
+func (i *Index) applyScorers(g *generation, scores map[string]float64, scorers []ScoreFunc) error {
+	return i.db.View(func(tx *bd.Txn) error {
+		for id, score := range scores {
+			meta := Meta{ID: id}
+			item, err := tx.Get(g.metaKey(id))
+			switch {
+			case err == nil:
+				if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &meta) }); err != nil {
+					return err
+				}
+			case !errors.Is(err, bd.ErrKeyNotFound):
+				return err
+			}
+			for _, f := range scorers {
+				score = f(meta, score)
+			}
+			scores[id] = score
+		}
+		return nil
+	})
+}
@@ -0,0 +1,193 @@
+package index
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	bd "github.com/dgraph-io/badger/v4"
+)
+
+const (
+	// bm25K1 BM25词频饱和参数
+	bm25K1 = 1.2
+	// bm25B BM25文档长度归一化参数
+	bm25B = 0.75
+)
+
+// Hit 查询结果
+type Hit struct {
+	ID    string  `json:"id"`    // 文档ID
+	Score float64 `json:"score"` // BM25得分, 使用打分函数时为调整后的得分
+}
+
+// Search 查询文档, 按BM25得分降序, 得分相同时按文档ID升序
+// 支持引号括起的短语与NEAR/k邻近运算符, 见parseQuery; opts可指定在BM25得分基础上调整得分的打分函数
+// limit为返回的结果数量, 0表示全部返回
+// 查询在当前一代的数据上执行, 重建索引期间不受影响
+func (i *Index) Search(query string, limit int, opts ...SearchOption) ([]Hit, error) {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+	hits, total, err := i.searchAfter(g, query, limit, nil, opts...)
+	if err == nil {
+		i.logQuery(g, query, total)
+	}
+	return hits, err
+}
+
+// search 在指定一代上查询, 调用方持有该代的读锁
+func (i *Index) search(g *generation, query string, limit int) ([]Hit, error) {
+	hits, _, err := i.searchAfter(g, query, limit, nil)
+	return hits, err
+}
+
+// searchAfter 在指定一代上查询排在after之后的结果, after为nil时从头开始, 同时返回匹配的文档总数
+// limit大于0时使用堆只保留前limit个结果, 避免对全部结果排序
+func (i *Index) searchAfter(g *generation, query string, limit int, after *Hit, opts ...SearchOption) ([]Hit, int, error) {
+	var so searchOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+	if so.err != nil {
+		return nil, 0, so.err
+	}
+
+	scores, err := i.score(g, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(so.scorers) > 0 {
+		if err := i.applyScorers(g, scores, so.scorers); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if limit <= 0 {
+		hits := make([]Hit, 0, len(scores))
+		for id, score := range scores {
+			if hit := (Hit{ID: id, Score: score}); after == nil || before(*after, hit) {
+				hits = append(hits, hit)
+			}
+		}
+		sort.Slice(hits, func(a, b int) bool { return before(hits[a], hits[b]) })
+		return hits, len(scores), nil
+	}
+
+	top := make(hitHeap, 0, limit)
+	for id, score := range scores {
+		hit := Hit{ID: id, Score: score}
+		if after != nil && !before(*after, hit) {
+			continue
+		}
+		if len(top) < limit {
+			heap.Push(&top, hit)
+		} else if before(hit, top[0]) {
+			top[0] = hit
+			heap.Fix(&top, 0)
+		}
+	}
+	hits := make([]Hit, len(top))
+	for k := len(top) - 1; k >= 0; k-- {
+		hits[k] = heap.Pop(&top).(Hit)
+	}
+	return hits, len(scores), nil
+}
+
+// before 判断结果a是否排在b之前: 得分降序, 得分相同时按文档ID升序
+func before(a, b Hit) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.ID < b.ID
+}
+
+// hitHeap 结果堆, 堆顶为排在最后的结果
+type hitHeap []Hit
+
+func (h hitHeap) Len() int           { return len(h) }
+func (h hitHeap) Less(a, b int) bool { return before(h[b], h[a]) }
+func (h hitHeap) Swap(a, b int)      { h[a], h[b] = h[b], h[a] }
+func (h *hitHeap) Push(x any)        { *h = append(*h, x.(Hit)) }
+func (h *hitHeap) Pop() any {
+	old := *h
+	hit := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return hit
+}
+
+// score 计算匹配查询的文档的BM25得分, 查询语法见parseQuery
+func (i *Index) score(g *generation, query string) (map[string]float64, error) {
+	q := parseQuery(query, g.analyzer)
+	if len(q.terms) == 0 {
+		return nil, nil
+	}
+	required := q.required()
+
+	stats, err := i.stats(g)
+	if err != nil {
+		return nil, err
+	}
+	dfs, err := i.docFreqs(g, q.terms)
+	if err != nil {
+		return nil, err
+	}
+	avgLength := 1.0
+	if stats.Docs > 0 {
+		avgLength = float64(stats.Length) / float64(stats.Docs)
+	}
+
+	scores := make(map[string]float64)
+	positions := make(map[string]map[string][]int) // 文档ID -> 约束涉及的词 -> 位置
+	err = i.db.View(func(tx *bd.Txn) error {
+		for _, term := range q.terms {
+			weight := bm25IDF(stats.Docs, dfs[term])
+			prefix := g.postingPrefix(term)
+
+			opts := bd.DefaultIteratorOptions
+			opts.Prefix = []byte(prefix)
+			it := tx.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				id := string(item.Key()[len(prefix):])
+				err := item.Value(func(val []byte) error {
+					tf, length := decodePosting(val)
+					if length < 0 {
+						length = int(avgLength)
+					}
+					norm := bm25K1 * (1 - bm25B + bm25B*float64(length)/avgLength)
+					scores[id] += weight * float64(tf) * (bm25K1 + 1) / (float64(tf) + norm)
+					if required[term] {
+						if positions[id] == nil {
+							positions[id] = make(map[string][]int)
+						}
+						positions[id][term] = decodePositions(val)
+					}
+					return nil
+				})
+				if err != nil {
+					it.Close()
+					return err
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.constraints) > 0 {
+		for id := range scores {
+			if !q.match(positions[id]) {
+				delete(scores, id)
+			}
+		}
+	}
+	return scores, nil
+}
+
+// bm25IDF BM25逆文档频率
+func bm25IDF(docs, df int64) float64 {
+	return math.Log(1 + (float64(docs)-float64(df)+0.5)/(float64(df)+0.5))
+}
@@ -0,0 +1,177 @@
+package index
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	bd "github.com/dgraph-io/badger/v4"
+)
+
+// Correction 查询词纠错
+type Correction struct {
+	From string `json:"from"` // 原查询词, 索引中不存在
+	To   string `json:"to"`   // 纠正后的查询词
+}
+
+// WithSpellCorrection 设置查询词纠错的默认开关, 默认关闭, 查询时可用Correct覆盖
+func WithSpellCorrection(enabled bool) Option {
+	return func(i *Index) { i.spelling = enabled }
+}
+
+// Correct 设置本次查询是否纠错, 覆盖索引的默认开关
+// 纠错只在SearchPage中生效, 纠正结果通过Page.Corrections与Page.DidYouMean返回
+func Correct(enabled bool) SearchOption {
+	return func(o *searchOptions) { o.correct = &enabled }
+}
+
+// maxEditDistance 按词长确定纠错允许的最大编辑距离
+func maxEditDistance(term string) int {
+	if utf8.RuneCountInString(term) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// correctable 判断查询词是否尝试纠错, 单字与数字不纠错
+func correctable(term string) bool {
+	if utf8.RuneCountInString(term) < 2 {
+		return false
+	}
+	for _, r := range term {
+		if !unicode.IsDigit(r) && r != '.' {
+			return true
+		}
+	}
+	return false
+}
+
+// correct 将索引中不存在的查询词替换为编辑距离最近的索引词, 距离相同时选择文档频率高的
+// 返回改写后的查询, 没有可纠正的词时返回原查询
+func (i *Index) correct(g *generation, query string) (string, []Correction, error) {
+	terms := parseQuery(query, g.analyzer).terms
+	dfs, err := i.docFreqs(g, terms)
+	if err != nil {
+		return query, nil, err
+	}
+	var oov []string
+	for _, term := range terms {
+		if dfs[term] == 0 && correctable(term) {
+			oov = append(oov, term)
+		}
+	}
+	if len(oov) == 0 {
+		return query, nil, nil
+	}
+
+	vocabulary, err := i.vocabulary(g)
+	if err != nil {
+		return query, nil, err
+	}
+
+	var corrections []Correction
+	rewritten := query
+	for _, term := range oov {
+		source := []rune(term)
+		limit := maxEditDistance(term)
+		best, bestDistance, bestDF := "", limit+1, int64(0)
+		for candidate, df := range vocabulary {
+			target := []rune(candidate)
+			if abs(len(target)-len(source)) > limit {
+				continue
+			}
+			d := editDistance(source, target, limit)
+			if d > limit {
+				continue
+			}
+			if d < bestDistance || (d == bestDistance && (df > bestDF || (df == bestDF && candidate < best))) {
+				best, bestDistance, bestDF = candidate, d, df
+			}
+		}
+		if best == "" {
+			continue
+		}
+		corrections = append(corrections, Correction{From: term, To: best})
+		if strings.Contains(rewritten, term) {
+			rewritten = strings.ReplaceAll(rewritten, term, best)
+		} else {
+			// 分析器会将拉丁字母转为小写
+			rewritten = strings.ReplaceAll(strings.ToLower(rewritten), term, best)
+		}
+	}
+	return rewritten, corrections, nil
+}
+
+// vocabulary 获取指定一代的全部索引词及文档频率, 包含尚未合并的变化
+func (i *Index) vocabulary(g *generation) (map[string]int64, error) {
+	vocabulary := make(map[string]int64)
+	prefix := g.prefix + "df:"
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			term := string(item.Key()[len(prefix):])
+			err := item.Value(func(val []byte) error {
+				vocabulary[term] = int64(binary.BigEndian.Uint64(val))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	for term, n := range g.pending.df {
+		vocabulary[term] += n
+	}
+	i.mu.Unlock()
+	for term, n := range vocabulary {
+		if n <= 0 {
+			delete(vocabulary, term)
+		}
+	}
+	return vocabulary, nil
+}
+
+// editDistance 按字计算编辑距离, 超过limit时提前返回limit+1
+func editDistance(a, b []rune, limit int) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for x := 1; x <= len(a); x++ {
+		curr[0] = x
+		rowMin := curr[0]
+		for y := 1; y <= len(b); y++ {
+			cost := 1
+			if a[x-1] == b[y-1] {
+				cost = 0
+			}
+			curr[y] = min(prev[y]+1, curr[y-1]+1, prev[y-1]+cost)
+			rowMin = min(rowMin, curr[y])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return min(prev[len(b)], limit+1)
+}
+
+// abs 绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,355 @@
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// consolidateBatch 合并计数时每个事务写入的索引词数
+const consolidateBatch = 1000
+
+// Stats 索引统计
+type Stats struct {
+	Docs   int64 `json:"docs"`   // 文档数
+	Length int64 `json:"length"` // 索引词总数
+}
+
+// counters 计数变化
+type counters struct {
+	df     map[string]int64 // 文档频率变化
+	docs   int64            // 文档数变化
+	length int64            // 索引词总数变化
+}
+
+// newCounters 创建计数变化
+func newCounters() counters {
+	return counters{df: make(map[string]int64)}
+}
+
+// merge 合并计数变化
+func (c *counters) merge(o counters) {
+	for term, n := range o.df {
+		if c.df[term] += n; c.df[term] == 0 {
+			delete(c.df, term)
+		}
+	}
+	c.docs += o.docs
+	c.length += o.length
+}
+
+// dfKey 文档频率的键
+func (g *generation) dfKey(term string) []byte {
+	return []byte(g.prefix + "df:" + term)
+}
+
+// statsKey 索引统计的键
+func (g *generation) statsKey() []byte {
+	return []byte(g.prefix + "stats")
+}
+
+// readCount 在事务中读取计数, 不存在时为0
+func readCount(tx *bd.Txn, key []byte) (int64, error) {
+	item, err := tx.Get(key)
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = item.Value(func(val []byte) error {
+		n = int64(binary.BigEndian.Uint64(val))
+		return nil
+	})
+	return n, err
+}
+
+// writeCount 在事务中写入计数, 小于等于0时删除
+func writeCount(tx *bd.Txn, key []byte, n int64) error {
+	if n <= 0 {
+		return tx.Delete(key)
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(n))
+	return tx.Set(key, buf[:])
+}
+
+// readStats 在事务中读取已合并的索引统计
+func (g *generation) readStats(tx *bd.Txn) (Stats, error) {
+	var stats Stats
+	item, err := tx.Get(g.statsKey())
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &stats)
+	})
+	return stats, err
+}
+
+// writeStats 在事务中写入索引统计
+func (g *generation) writeStats(tx *bd.Txn, stats Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return tx.Set(g.statsKey(), data)
+}
+
+// Consolidate 将内存中累积的计数变化合并写入数据库
+// 写入失败时未写入的变化保留在内存中, 下次合并时重试
+func (i *Index) Consolidate() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.shadow != nil {
+		if err := i.consolidate(i.shadow); err != nil {
+			return err
+		}
+	}
+	return i.consolidate(i.gen.Load())
+}
+
+// consolidate 合并指定一代的计数变化, 调用方持有写锁
+func (i *Index) consolidate(g *generation) error {
+	terms := make([]string, 0, len(g.pending.df))
+	for term := range g.pending.df {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	for len(terms) > 0 {
+		batch := terms[:min(consolidateBatch, len(terms))]
+		err := i.db.Update(func(tx *bd.Txn) error {
+			for _, term := range batch {
+				df, err := readCount(tx, g.dfKey(term))
+				if err != nil {
+					return err
+				}
+				if err := writeCount(tx, g.dfKey(term), df+g.pending.df[term]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("consolidate document frequency fail: %v", err)
+		}
+		for _, term := range batch {
+			delete(g.pending.df, term)
+		}
+		terms = terms[len(batch):]
+	}
+
+	if g.pending.docs == 0 && g.pending.length == 0 {
+		return nil
+	}
+	err := i.db.Update(func(tx *bd.Txn) error {
+		stats, err := g.readStats(tx)
+		if err != nil {
+			return err
+		}
+		stats.Docs += g.pending.docs
+		stats.Length += g.pending.length
+		return g.writeStats(tx, stats)
+	})
+	if err != nil {
+		return fmt.Errorf("consolidate index stats fail: %v", err)
+	}
+	g.pending.docs, g.pending.length = 0, 0
+	return nil
+}
+
+// Rebuild 从已索引的文档重新计算文档频率与索引统计
+// 用于进程异常退出导致未合并的计数变化丢失后修复统计
+func (i *Index) Rebuild() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	g := i.gen.Load()
+	df := make(map[string]int64)
+	var stats Stats
+	var stale [][]byte
+	err := i.db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(g.prefix + "doc:")
+		it := tx.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var doc document
+			if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &doc) }); err != nil {
+				return err
+			}
+			for term := range doc.Terms {
+				df[term]++
+			}
+			stats.Docs++
+			stats.Length += int64(doc.Length)
+		}
+
+		keyOpts := bd.DefaultIteratorOptions
+		keyOpts.PrefetchValues = false
+		keyOpts.Prefix = []byte(g.prefix + "df:")
+		keys := tx.NewIterator(keyOpts)
+		defer keys.Close()
+		for keys.Rewind(); keys.Valid(); keys.Next() {
+			stale = append(stale, keys.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan index fail: %v", err)
+	}
+
+	wb := i.db.DB().NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range stale {
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("clear document frequency fail: %v", err)
+	}
+
+	wb = i.db.DB().NewWriteBatch()
+	defer wb.Cancel()
+	for term, n := range df {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(n))
+		if err := wb.Set(g.dfKey(term), buf[:]); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if err := wb.Set(g.statsKey(), data); err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("write document frequency fail: %v", err)
+	}
+	g.pending = newCounters()
+	return nil
+}
+
+// Stats 获取索引统计, 包含尚未合并的变化
+func (i *Index) Stats() (Stats, error) {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+	return i.stats(g)
+}
+
+// stats 获取指定一代的索引统计, 包含尚未合并的变化
+func (i *Index) stats(g *generation) (Stats, error) {
+	var stats Stats
+	err := i.db.View(func(tx *bd.Txn) error {
+		var err error
+		stats, err = g.readStats(tx)
+		return err
+	})
+
+	i.mu.Lock()
+	stats.Docs += g.pending.docs
+	stats.Length += g.pending.length
+	i.mu.Unlock()
+	return stats, err
+}
+
+// DocFreq 获取包含索引词的文档数, 包含尚未合并的变化
+func (i *Index) DocFreq(term string) (int64, error) {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+	dfs, err := i.docFreqs(g, []string{term})
+	return dfs[term], err
+}
+
+// docFreqs 批量获取指定一代的文档频率, 包含尚未合并的变化
+func (i *Index) docFreqs(g *generation, terms []string) (map[string]int64, error) {
+	dfs := make(map[string]int64, len(terms))
+	err := i.db.View(func(tx *bd.Txn) error {
+		for _, term := range terms {
+			n, err := readCount(tx, g.dfKey(term))
+			if err != nil {
+				return err
+			}
+			dfs[term] = n
+		}
+		return nil
+	})
+
+	i.mu.Lock()
+	for _, term := range terms {
+		dfs[term] = max(dfs[term]+g.pending.df[term], 0)
+	}
+	i.mu.Unlock()
+	return dfs, err
+}
+
+// idf 逆文档频率, 平滑处理后未出现过的词也有有限的权重
+func idf(docs, df int64) float64 {
+	return math.Log(float64(docs+1)/float64(df+1)) + 1
+}
+
+// ExtractTFIDF 基于索引的文档频率抽取文本的关键词, 按TF-IDF权重降序
+// topN为保留的关键词数量, 0表示全部保留
+func (i *Index) ExtractTFIDF(text string, topN int) ([]participle.WordFrequency, error) {
+	g := i.acquire()
+	defer g.mu.RUnlock()
+
+	tf := make(map[string]int)
+	total := 0
+	for _, term := range g.analyzer(text) {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		tf[term]++
+		total++
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	terms := make([]string, 0, len(tf))
+	for term := range tf {
+		terms = append(terms, term)
+	}
+	stats, err := i.stats(g)
+	if err != nil {
+		return nil, err
+	}
+	dfs, err := i.docFreqs(g, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := make([]participle.WordFrequency, 0, len(terms))
+	for _, term := range terms {
+		weight := float64(tf[term]) / float64(total) * idf(stats.Docs, dfs[term])
+		keywords = append(keywords, participle.WordFrequency{Name: term, Value: weight})
+	}
+	sort.Slice(keywords, func(a, b int) bool {
+		if keywords[a].Value != keywords[b].Value {
+			return keywords[a].Value > keywords[b].Value
+		}
+		return keywords[a].Name < keywords[b].Name
+	})
+	if topN > 0 && len(keywords) > topN {
+		keywords = keywords[:topN]
+	}
+	return keywords, nil
+}
@@ -0,0 +1,139 @@
+// Package middleware HTTP中间件: 分析请求文本, 并按过滤词表与隐私信息审核内容
+//
+// 本包属于v1稳定接口: 导出的名称与行为在v1内保持兼容
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/miajio/nla/pkg/pipeline"
+)
+
+// maxBodyBytes 读取JSON请求体的字节上限
+const maxBodyBytes = 10 << 20
+
+// ErrFieldNotFound 请求中不存在指定字段
+var ErrFieldNotFound = errors.New("middleware: field not found")
+
+// contextKey 请求上下文中分析结果的键, 以字段名区分
+type contextKey struct {
+	kind  string
+	field string
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError 写出JSON错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
+
+// TextFunc 从请求中取出待分析的文本
+type TextFunc func(r *http.Request) (string, error)
+
+// FormField 取表单字段, 包括URL参数与表单请求体
+func FormField(field string) TextFunc {
+	return func(r *http.Request) (string, error) {
+		if err := r.ParseForm(); err != nil {
+			return "", err
+		}
+		if _, ok := r.Form[field]; !ok {
+			return "", fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+		}
+		return r.Form.Get(field), nil
+	}
+}
+
+// JSONField 取JSON请求体中的字符串字段, 嵌套字段以"."分隔, 如"comment.text"
+// 读取后恢复请求体, 后续处理函数仍可读取完整内容
+func JSONField(field string) TextFunc {
+	path := strings.Split(field, ".")
+	return func(r *http.Request) (string, error) {
+		if r.Body == nil {
+			return "", fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+		}
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("read body fail: %v", err)
+		}
+
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return "", fmt.Errorf("decode body fail: %v", err)
+		}
+		for _, key := range path {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+			}
+			if v, ok = obj[key]; !ok {
+				return "", fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+			}
+		}
+		text, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("field %s is not a string", field)
+		}
+		return text, nil
+	}
+}
+
+// Analyze 对请求中的文本运行流水线, 处理结果存入请求上下文, 由DocumentOf按name取出
+// 字段不存在时不分析, 直接交给后续处理函数; 请求体无法解析时返回400, 流水线出错时返回500
+func Analyze(name string, text TextFunc, p *pipeline.Pipeline) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := text(r)
+			switch {
+			case errors.Is(err, ErrFieldNotFound):
+				next.ServeHTTP(w, r)
+				return
+			case err != nil:
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			var doc *pipeline.Document
+			err = p.Run(r.Context(), pipeline.FromStrings([]string{s}), func(d *pipeline.Document) { doc = d })
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("analyze %s fail: %v", name, err))
+				return
+			}
+			if doc == nil {
+				doc = &pipeline.Document{Text: s}
+			}
+			ctx := context.WithValue(r.Context(), contextKey{"analyze", name}, doc)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AnalyzeFormField 对表单字段运行流水线, 见Analyze
+func AnalyzeFormField(field string, p *pipeline.Pipeline) func(http.Handler) http.Handler {
+	return Analyze(field, FormField(field), p)
+}
+
+// AnalyzeJSONField 对JSON请求体中的字段运行流水线, 见Analyze
+func AnalyzeJSONField(field string, p *pipeline.Pipeline) func(http.Handler) http.Handler {
+	return Analyze(field, JSONField(field), p)
+}
+
+// DocumentOf 取出Analyze存入请求上下文的处理结果, 文档被流水线丢弃或字段不存在时返回false
+func DocumentOf(ctx context.Context, name string) (*pipeline.Document, bool) {
+	doc, ok := ctx.Value(contextKey{"analyze", name}).(*pipeline.Document)
+	return doc, ok
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// ErrRejected 请求文本未通过审核
+var ErrRejected = errors.New("middleware: content rejected")
+
+// Verdict 文本审核结论
+type Verdict struct {
+	Field   string   `json:"field"`          // 字段名
+	Flagged bool     `json:"flagged"`        // 是否命中审核规则
+	Hits    []string `json:"hits,omitempty"` // 命中的屏蔽词, 按出现顺序去重
+	PII     bool     `json:"pii"`            // 是否含电话号码或身份证号
+}
+
+// Moderator 基于屏蔽词的文本审核
+// 屏蔽词须与分词边界对齐: 由一个或连续几个词组成, 避免"舞台独白"之类跨词的误判
+type Moderator struct {
+	engine  *participle.Engine
+	blocked map[string]bool
+	maxLen  int // 屏蔽词的最大字节数
+
+	flagPII bool // 含电话号码或身份证号时是否判为命中
+	reject  bool // 命中时是否直接拒绝请求
+}
+
+// ModerateOption 审核配置项
+type ModerateOption func(*Moderator)
+
+// FlagPII 含电话号码或身份证号时判为命中
+func FlagPII() ModerateOption {
+	return func(m *Moderator) { m.flagPII = true }
+}
+
+// RejectFlagged 命中时由中间件直接返回422, 不再交给后续处理函数
+func RejectFlagged() ModerateOption {
+	return func(m *Moderator) { m.reject = true }
+}
+
+// NewModerator 创建文本审核, words为屏蔽词, 拉丁字母不区分大小写
+func NewModerator(engine *participle.Engine, words []string, opts ...ModerateOption) *Moderator {
+	m := &Moderator{engine: engine, blocked: make(map[string]bool, len(words))}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || m.blocked[word] {
+			continue
+		}
+		m.blocked[word] = true
+		m.maxLen = max(m.maxLen, len(word))
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Check 审核文本
+func (m *Moderator) Check(text string) Verdict {
+	var v Verdict
+	seen := make(map[string]bool)
+	tokens := m.engine.Segment(text)
+	for i := range tokens {
+		word := ""
+		for j := i; j < len(tokens) && len(word)+len(tokens[j]) <= m.maxLen; j++ {
+			word += strings.ToLower(tokens[j])
+			if m.blocked[word] && !seen[word] {
+				seen[word] = true
+				v.Hits = append(v.Hits, word)
+			}
+		}
+	}
+	v.PII = participle.RedactPII(text) != text
+	v.Flagged = len(v.Hits) > 0 || (m.flagPII && v.PII)
+	return v
+}
+
+// Moderate 审核请求中的文本, 结论存入请求上下文, 由VerdictOf按name取出
+// 字段不存在时不审核; 设置RejectFlagged时命中的请求返回422
+func Moderate(name string, text TextFunc, m *Moderator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := text(r)
+			switch {
+			case errors.Is(err, ErrFieldNotFound):
+				next.ServeHTTP(w, r)
+				return
+			case err != nil:
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			v := m.Check(s)
+			v.Field = name
+			if v.Flagged && m.reject {
+				writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%w: %s", ErrRejected, name))
+				return
+			}
+			ctx := context.WithValue(r.Context(), contextKey{"moderate", name}, v)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ModerateFormField 审核表单字段, 见Moderate
+func ModerateFormField(field string, m *Moderator) func(http.Handler) http.Handler {
+	return Moderate(field, FormField(field), m)
+}
+
+// ModerateJSONField 审核JSON请求体中的字段, 见Moderate
+func ModerateJSONField(field string, m *Moderator) func(http.Handler) http.Handler {
+	return Moderate(field, JSONField(field), m)
+}
+
+// VerdictOf 取出Moderate存入请求上下文的审核结论, 字段不存在时返回false
+func VerdictOf(ctx context.Context, name string) (Verdict, bool) {
+	v, ok := ctx.Value(contextKey{"moderate", name}).(Verdict)
+	return v, ok
+}
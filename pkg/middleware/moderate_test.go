@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+func FuzzModerator(f *testing.F) {
+	engine, err := participle.NewMemory(participle.WithBaseDict(participle.BaseDictEmpty))
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.AddWords([]participle.DictEntry{{Content: "违禁词", Frequency: 10}, {Content: "敏感", Frequency: 10}}); err != nil {
+		f.Fatal(err)
+	}
+	m := NewModerator(engine, []string{"违禁词", "敏感内容", "BadWord", " "}, FlagPII())
+
+	for _, seed := range []string{
+		"",
+		"这里有违禁词和敏感内容",
+		"BADWORD badword BadWord",
+		"\xff违禁\xfe词\x00",
+		"！！违禁词！！。。，，",
+		"电话13800138000, 身份证11010519491231002X",
+		strings.Repeat("敏感", 4096),
+		strings.Repeat("违禁词", 2048),
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		v := m.Check(text)
+		seen := make(map[string]bool)
+		for _, hit := range v.Hits {
+			if !m.blocked[hit] {
+				t.Fatalf("hit %q is not a blocked word", hit)
+			}
+			if seen[hit] {
+				t.Fatalf("hit %q reported twice", hit)
+			}
+			seen[hit] = true
+			if !strings.Contains(strings.ToLower(text), hit) {
+				t.Fatalf("hit %q not in text %q", hit, text)
+			}
+		}
+		if v.Flagged != (len(v.Hits) > 0 || v.PII) {
+			t.Fatalf("flagged %v with hits %v and pii %v", v.Flagged, v.Hits, v.PII)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package participle
+
+// arenaSlabSize 每个分配块可容纳的节点数
+const arenaSlabSize = 1024
+
+// nodeArena 前缀树节点的分块分配器
+// 节点按块批量分配, 减少百万级节点时的小对象数量与GC开销; 移除的节点不复用, 由Compact复制到新的分配器后整体回收
+// 分配器属于快照, 只在持有写锁时分配
+type nodeArena struct {
+	slabs [][]TrieNode // 分配块
+	used  int          // 最后一个分配块已分配的节点数
+}
+
+// newNodeArena 创建节点分配器
+func newNodeArena() *nodeArena {
+	return &nodeArena{}
+}
+
+// alloc 分配一个新的前缀树节点
+func (a *nodeArena) alloc() *TrieNode {
+	if len(a.slabs) == 0 || a.used == arenaSlabSize {
+		a.slabs = append(a.slabs, make([]TrieNode, arenaSlabSize))
+		a.used = 0
+	}
+	node := &a.slabs[len(a.slabs)-1][a.used]
+	a.used++
+	return node
+}
+
+// allocated 已分配的节点数, 包含已从前缀树移除的节点
+func (a *nodeArena) allocated() int64 {
+	if len(a.slabs) == 0 {
+		return 0
+	}
+	return int64(len(a.slabs)-1)*arenaSlabSize + int64(a.used)
+}
+
+// ArenaStats 前缀树节点分配统计
+type ArenaStats struct {
+	Slabs     int   `json:"slabs"`     // 分配块数量
+	Capacity  int64 `json:"capacity"`  // 可容纳的节点数
+	Allocated int64 `json:"allocated"` // 已分配的节点数, 包含已移除的节点
+	Live      int64 `json:"live"`      // 前缀树中的节点数
+}
+
+// ArenaStats 获取前缀树节点分配统计, Allocated远大于Live时可调用Compact回收
+func (d *Engine) ArenaStats() ArenaStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snap := d.snap.Load()
+	return ArenaStats{
+		Slabs:     len(snap.arena.slabs),
+		Capacity:  int64(len(snap.arena.slabs)) * arenaSlabSize,
+		Allocated: snap.arena.allocated(),
+		Live:      snap.nodes,
+	}
+}
@@ -0,0 +1,179 @@
+package participle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrPatternNotFound 正则表达式不在黑名单中
+var ErrPatternNotFound = errors.New("participle: blacklist pattern not found")
+
+const (
+	// blacklistWordPrefix 黑名单词的键前缀
+	blacklistWordPrefix = MetaKeyPrefix + "blacklist:word:"
+	// blacklistPatternPrefix 黑名单正则表达式的键前缀
+	blacklistPatternPrefix = MetaKeyPrefix + "blacklist:re:"
+)
+
+// 常用的黑名单正则表达式, 可通过AddBlacklistPattern启用
+const (
+	BlacklistDigits = `^[\d.,%+\-]+$`                                 // 纯数字
+	BlacklistPhone  = `^(?:(?:\+?86)?1[3-9]\d{9}|0\d{2,3}-?\d{7,8})$` // 电话号码
+	BlacklistURL    = `(?i)^(?:https?://|www\.)`                      // 网址
+)
+
+// blacklist 学习新词时排除的词与正则表达式, 保存在数据库中
+type blacklist struct {
+	mu       sync.RWMutex
+	words    map[string]bool
+	patterns map[string]*regexp.Regexp
+}
+
+// loadBlacklist 从数据库加载黑名单
+func loadBlacklist(store DictStore) (*blacklist, error) {
+	b := &blacklist{words: make(map[string]bool), patterns: make(map[string]*regexp.Regexp)}
+	err := store.Iterate([]byte(MetaKeyPrefix+"blacklist:"), func(k, _ []byte) error {
+		key := string(k)
+		switch {
+		case strings.HasPrefix(key, blacklistWordPrefix):
+			b.words[key[len(blacklistWordPrefix):]] = true
+		case strings.HasPrefix(key, blacklistPatternPrefix):
+			pattern := key[len(blacklistPatternPrefix):]
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("compile blacklist pattern %q fail: %v", pattern, err)
+			}
+			b.patterns[pattern] = re
+		}
+		return nil
+	})
+	return b, err
+}
+
+// match 判断词是否在黑名单中
+func (b *blacklist) match(word string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.words[strings.ToLower(word)] {
+		return true
+	}
+	for _, re := range b.patterns {
+		if re.MatchString(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blacklisted 判断词是否在学习黑名单中
+func (d *Engine) Blacklisted(word string) bool {
+	return d.blacklist != nil && d.blacklist.match(word)
+}
+
+// AddBlacklistWords 将词加入学习黑名单, LearnFromText与LearnDiscovered不会学习黑名单中的词
+// 拉丁字母不区分大小写; 已在词典中的词不受影响, 需要时调用DeleteWord
+func (d *Engine) AddBlacklistWords(words ...string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	var keys []string
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			keys = append(keys, word)
+		}
+	}
+	err := d.store.Update(func(tx StoreTxn) error {
+		for _, word := range keys {
+			if err := tx.Set([]byte(blacklistWordPrefix+word), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save blacklist fail: %v", err)
+	}
+
+	d.blacklist.mu.Lock()
+	defer d.blacklist.mu.Unlock()
+	for _, word := range keys {
+		d.blacklist.words[word] = true
+	}
+	return nil
+}
+
+// RemoveBlacklistWord 将词移出学习黑名单, 不在黑名单中时返回ErrWordNotFound
+func (d *Engine) RemoveBlacklistWord(word string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	word = strings.ToLower(strings.TrimSpace(word))
+	d.blacklist.mu.Lock()
+	defer d.blacklist.mu.Unlock()
+	if !d.blacklist.words[word] {
+		return ErrWordNotFound
+	}
+	if err := d.store.Delete([]byte(blacklistWordPrefix + word)); err != nil {
+		return fmt.Errorf("save blacklist fail: %v", err)
+	}
+	delete(d.blacklist.words, word)
+	return nil
+}
+
+// AddBlacklistPattern 将正则表达式加入学习黑名单, 匹配的词不会被学习, 常用表达式见BlacklistDigits等
+func (d *Engine) AddBlacklistPattern(pattern string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	if err := d.store.Set([]byte(blacklistPatternPrefix+pattern), nil); err != nil {
+		return fmt.Errorf("save blacklist fail: %v", err)
+	}
+
+	d.blacklist.mu.Lock()
+	defer d.blacklist.mu.Unlock()
+	d.blacklist.patterns[pattern] = re
+	return nil
+}
+
+// RemoveBlacklistPattern 将正则表达式移出学习黑名单, 不在黑名单中时返回ErrPatternNotFound
+func (d *Engine) RemoveBlacklistPattern(pattern string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	d.blacklist.mu.Lock()
+	defer d.blacklist.mu.Unlock()
+	if _, ok := d.blacklist.patterns[pattern]; !ok {
+		return ErrPatternNotFound
+	}
+	if err := d.store.Delete([]byte(blacklistPatternPrefix + pattern)); err != nil {
+		return fmt.Errorf("save blacklist fail: %v", err)
+	}
+	delete(d.blacklist.patterns, pattern)
+	return nil
+}
+
+// Blacklist 获取学习黑名单中的词与正则表达式, 均按字典序
+func (d *Engine) Blacklist() (words, patterns []string) {
+	if d.blacklist == nil {
+		return nil, nil
+	}
+	d.blacklist.mu.RLock()
+	defer d.blacklist.mu.RUnlock()
+	for word := range d.blacklist.words {
+		words = append(words, word)
+	}
+	for pattern := range d.blacklist.patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(words)
+	sort.Strings(patterns)
+	return words, patterns
+}
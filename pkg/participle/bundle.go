@@ -0,0 +1,234 @@
+package participle
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// bundleFormat 引擎状态包的格式版本
+const bundleFormat = 1
+
+// ErrBundleFormat 引擎状态包的格式版本不受支持
+var ErrBundleFormat = errors.New("participle: unsupported bundle format")
+
+// Bundle 引擎状态包, 包含词典、学习黑名单、逆文档频率表与配置, 用于在环境间整体迁移
+// 停用词表、行政区划数据、过滤词表等由调用方维护的数据以附加文件的形式随包导出
+type Bundle struct {
+	Format    int               `json:"format"`          // 格式版本
+	CreatedAt time.Time         `json:"created_at"`      // 导出时间
+	Config    BundleConfig      `json:"config"`          // 导出时的引擎配置
+	Entries   []DictEntry       `json:"entries"`         // 词条, 按字典序
+	Blacklist BundleBlacklist   `json:"blacklist"`       // 学习黑名单
+	IDF       BundleIDF         `json:"idf"`             // 逆文档频率表
+	Files     map[string][]byte `json:"files,omitempty"` // 附加文件, 键为文件名
+}
+
+// BundleConfig 可随状态包迁移的引擎配置
+type BundleConfig struct {
+	Namespace      string        `json:"namespace,omitempty"`       // 词典命名空间
+	BaseNamespaces []string      `json:"base_namespaces,omitempty"` // 组合的只读命名空间
+	MergeSpan      int           `json:"merge_span"`                // 分词后按用户词典合并的最大相邻词数
+	BaseDict       BaseDict      `json:"base_dict"`                 // GSE基础词典
+	SegmentMode    SegmentMode   `json:"segment_mode"`              // 默认的分词方式
+	HistoryBucket  time.Duration `json:"history_bucket"`            // 词频时间序列的时间段长度
+	Learn          LearnOptions  `json:"learn"`                     // 学习新词的策略
+}
+
+// Options 转换为引擎配置项, 用于以导出时的配置创建目标环境的引擎
+func (c BundleConfig) Options() []Option {
+	return []Option{
+		WithNamespace(c.Namespace, c.BaseNamespaces...),
+		WithUserWordPriority(c.MergeSpan),
+		WithBaseDict(c.BaseDict),
+		WithDefaultSegmentMode(c.SegmentMode),
+		WithHistoryBucket(c.HistoryBucket),
+		WithLearnOptions(c.Learn),
+	}
+}
+
+// BundleBlacklist 学习黑名单
+type BundleBlacklist struct {
+	Words    []string `json:"words,omitempty"`    // 词
+	Patterns []string `json:"patterns,omitempty"` // 正则表达式
+}
+
+// BundleIDF 逆文档频率表
+type BundleIDF struct {
+	Docs  uint64            `json:"docs"`            // 语料文档数
+	Terms map[string]uint64 `json:"terms,omitempty"` // 词的文档频率
+}
+
+// ExportBundle 将引擎状态导出为一个JSON文档, files为随包导出的附加文件, 键为文件名
+// 词条取自当前词典, 含降级模式下尚未落库的修改; 词频时间序列与语料任务进度属于运行数据, 不导出
+func (d *Engine) ExportBundle(w io.Writer, files map[string][]byte) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+
+	b := Bundle{
+		Format:    bundleFormat,
+		CreatedAt: time.Now(),
+		Config: BundleConfig{
+			Namespace:      d.opts.namespace,
+			BaseNamespaces: d.opts.baseNamespaces,
+			MergeSpan:      d.opts.mergeSpan,
+			BaseDict:       d.opts.baseDict,
+			SegmentMode:    d.opts.segmentMode,
+			HistoryBucket:  d.opts.historyBucket,
+			Learn:          d.opts.learn,
+		},
+		Files: files,
+	}
+
+	d.rw.RLock()
+	b.Entries = collectEntries(d.snap.Load().root)
+	d.rw.RUnlock()
+	sort.Slice(b.Entries, func(i, j int) bool { return b.Entries[i].Content < b.Entries[j].Content })
+
+	b.Blacklist.Words, b.Blacklist.Patterns = d.Blacklist()
+
+	var err error
+	if b.IDF, err = d.exportIDF(); err != nil {
+		return fmt.Errorf("export idf fail: %v", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(&b)
+}
+
+// exportIDF 读取逆文档频率表
+func (d *Engine) exportIDF() (BundleIDF, error) {
+	idf := BundleIDF{Terms: make(map[string]uint64)}
+	value, err := d.store.Get([]byte(idfDocsKey))
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return idf, err
+	}
+	if err == nil {
+		idf.Docs = binary.BigEndian.Uint64(value)
+	}
+	err = d.store.Iterate([]byte(idfTermKeyPrefix), func(key, value []byte) error {
+		idf.Terms[string(key[len(idfTermKeyPrefix):])] = binary.BigEndian.Uint64(value)
+		return nil
+	})
+	return idf, err
+}
+
+// ImportBundle 读取引擎状态包并恢复词典、学习黑名单与逆文档频率表, 返回读取的状态包
+// replace为true时先移除包中没有的词条与黑名单项并清空逆文档频率表, 使状态与包一致; 否则合并到现有状态
+// 包中的配置不会改变当前引擎, 需要时以Bundle.Config.Options()创建引擎; 附加文件由调用方处理
+func (d *Engine) ImportBundle(r io.Reader, replace bool) (*Bundle, error) {
+	if d.fork != nil {
+		return nil, ErrForkNoDB
+	}
+
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("decode bundle fail: %v", err)
+	}
+	if b.Format != bundleFormat {
+		return nil, ErrBundleFormat
+	}
+
+	if replace {
+		if err := d.pruneToBundle(&b); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.AddWords(b.Entries); err != nil {
+		return nil, fmt.Errorf("import entries fail: %v", err)
+	}
+	if err := d.AddBlacklistWords(b.Blacklist.Words...); err != nil {
+		return nil, err
+	}
+	for _, pattern := range b.Blacklist.Patterns {
+		if err := d.AddBlacklistPattern(pattern); err != nil {
+			return nil, fmt.Errorf("import blacklist pattern %q fail: %v", pattern, err)
+		}
+	}
+	if err := d.importIDF(b.IDF); err != nil {
+		return nil, fmt.Errorf("import idf fail: %v", err)
+	}
+	return &b, nil
+}
+
+// pruneToBundle 移除状态包中没有的词条与黑名单项, 并清空逆文档频率表
+func (d *Engine) pruneToBundle(b *Bundle) error {
+	keep := make(map[string]bool, len(b.Entries))
+	for _, entry := range b.Entries {
+		keep[entry.Content] = true
+	}
+	d.rw.RLock()
+	current := collectEntries(d.snap.Load().root)
+	d.rw.RUnlock()
+	for _, entry := range current {
+		if keep[entry.Content] {
+			continue
+		}
+		if err := d.DeleteWord(entry.Content); err != nil && !errors.Is(err, ErrWordNotFound) {
+			return fmt.Errorf("delete word %s fail: %v", entry.Content, err)
+		}
+	}
+
+	words, patterns := d.Blacklist()
+	keepWords := make(map[string]bool, len(b.Blacklist.Words))
+	for _, word := range b.Blacklist.Words {
+		keepWords[word] = true
+	}
+	for _, word := range words {
+		if !keepWords[word] {
+			if err := d.RemoveBlacklistWord(word); err != nil && !errors.Is(err, ErrWordNotFound) {
+				return err
+			}
+		}
+	}
+	keepPatterns := make(map[string]bool, len(b.Blacklist.Patterns))
+	for _, pattern := range b.Blacklist.Patterns {
+		keepPatterns[pattern] = true
+	}
+	for _, pattern := range patterns {
+		if !keepPatterns[pattern] {
+			if err := d.RemoveBlacklistPattern(pattern); err != nil && !errors.Is(err, ErrPatternNotFound) {
+				return err
+			}
+		}
+	}
+	return d.ResetIDF()
+}
+
+// bundleIDFBatch 导入逆文档频率表时每个事务写入的词数, 避免单个事务过大
+const bundleIDFBatch = 1000
+
+// importIDF 将逆文档频率表累加到现有计数
+func (d *Engine) importIDF(idf BundleIDF) error {
+	terms := make([]string, 0, len(idf.Terms))
+	for term := range idf.Terms {
+		terms = append(terms, term)
+	}
+	for start := 0; start < len(terms); start += bundleIDFBatch {
+		batch := terms[start:min(start+bundleIDFBatch, len(terms))]
+		err := d.store.Update(func(tx StoreTxn) error {
+			for _, term := range batch {
+				if err := addCount(tx, []byte(idfTermKeyPrefix+term), idf.Terms[term]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if idf.Docs == 0 {
+		return nil
+	}
+	return d.store.Update(func(tx StoreTxn) error {
+		return addCount(tx, []byte(idfDocsKey), idf.Docs)
+	})
+}
@@ -1,7 +1,7 @@
 package participle
 
 import (
-	"regexp"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -16,16 +16,48 @@ func SplitString(s string) []string {
 	return result
 }
 
-// IsSpecialChar 判断字符串是否为特殊符号
-func IsSpecialChar(s string) bool {
-	// 检查是否为空字符串
+// asciiPunct ASCII字符是否为标点、符号或空格的预计算表
+var asciiPunct = func() (table [utf8.RuneSelf]bool) {
+	for r := rune(0); r < utf8.RuneSelf; r++ {
+		table[r] = unicode.In(r, unicode.P, unicode.S, unicode.Z)
+	}
+	return table
+}()
+
+// IsPunct 判断字符是否为标点、符号或空格(Unicode P、S、Z类)
+func IsPunct(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= 0 && asciiPunct[r]
+	}
+	return unicode.In(r, unicode.P, unicode.S, unicode.Z)
+}
+
+// IsAllPunct 判断字符串是否全部由标点、符号或空格组成, 空字符串返回false
+func IsAllPunct(s string) bool {
 	if s == "" {
 		return false
 	}
+	for _, r := range s {
+		if !IsPunct(r) {
+			return false
+		}
+	}
+	return true
+}
 
-	// 使用正则表达式检查是否为标点符号或特殊符号
-	chinesePunctuation := regexp.MustCompile(`[\p{P}\p{S}\p{Z}]+`)
+// HasPunct 判断字符串是否包含标点、符号或空格
+func HasPunct(s string) bool {
+	for _, r := range s {
+		if IsPunct(r) {
+			return true
+		}
+	}
+	return false
+}
 
-	// 如果整个字符串都是特殊符号，则返回true
-	return chinesePunctuation.MatchString(s)
+// IsSpecialChar 判断字符串是否包含特殊符号
+//
+// Deprecated: 使用IsPunct、IsAllPunct或HasPunct, 本函数等同于HasPunct
+func IsSpecialChar(s string) bool {
+	return HasPunct(s)
 }
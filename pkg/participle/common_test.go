@@ -0,0 +1,65 @@
+package participle
+
+import (
+	"regexp"
+	"testing"
+)
+
+// punctTexts 基准测试的输入: 纯中文、中英混排与纯标点
+var punctTexts = map[string]string{
+	"han":   "自然语言处理是人工智能的重要方向之一",
+	"mixed": "北京市朝阳区建国路88号SOHO现代城A座1001室",
+	"punct": "，。！？、；：「」『』（）——……",
+}
+
+// regexpPunct 替换前IsSpecialChar使用的正则表达式, 作为对照
+var regexpPunct = regexp.MustCompile(`[\p{P}\p{S}\p{Z}]+`)
+
+func TestIsPunct(t *testing.T) {
+	for _, r := range []rune{'，', '。', '!', ' ', '　', '+', '¥', '—'} {
+		if !IsPunct(r) {
+			t.Errorf("IsPunct(%q) = false", r)
+		}
+	}
+	for _, r := range []rune{'中', 'a', '1', '\n', -1, 0x110000} {
+		if IsPunct(r) {
+			t.Errorf("IsPunct(%q) = true", r)
+		}
+	}
+	for _, s := range punctTexts {
+		if got, want := HasPunct(s), regexpPunct.MatchString(s); got != want {
+			t.Errorf("HasPunct(%q) = %v, regexp %v", s, got, want)
+		}
+	}
+	if IsAllPunct("") || !IsAllPunct(punctTexts["punct"]) || IsAllPunct(punctTexts["mixed"]) {
+		t.Error("IsAllPunct mismatch")
+	}
+}
+
+func BenchmarkIsPunct(b *testing.B) {
+	for name, s := range punctTexts {
+		runes := []rune(s)
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				for _, r := range runes {
+					IsPunct(r)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHasPunct(b *testing.B) {
+	for name, s := range punctTexts {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				HasPunct(s)
+			}
+		})
+		b.Run(name+"/regexp", func(b *testing.B) {
+			for b.Loop() {
+				regexpPunct.MatchString(s)
+			}
+		})
+	}
+}
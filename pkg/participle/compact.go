@@ -0,0 +1,119 @@
+package participle
+
+import (
+	"time"
+)
+
+// CompactionStats 前缀树压缩统计
+type CompactionStats struct {
+	Runs           int64     `json:"runs"`            // 压缩次数
+	NodesReclaimed int64     `json:"nodes_reclaimed"` // 累计回收的节点数
+	BytesReclaimed int64     `json:"bytes_reclaimed"` // 累计回收的估算内存(字节)
+	Removals       int64     `json:"removals"`        // 上次压缩后的移除次数
+	LastRun        time.Time `json:"last_run"`        // 上次压缩时间
+	LastDuration   string    `json:"last_duration"`   // 上次压缩耗时
+}
+
+// WithCompaction 开启前缀树后台压缩
+// 词条移除次数达到threshold后在后台重建前缀树, 剪除无词条的分支并释放子节点map中已删除元素占用的空间,
+// 两次压缩的间隔不小于minInterval; threshold为0表示不压缩
+func WithCompaction(threshold int64, minInterval time.Duration) Option {
+	return func(o *options) {
+		o.compactThreshold = threshold
+		o.compactInterval = minInterval
+	}
+}
+
+// CompactionStats 获取前缀树压缩统计
+func (d *Engine) CompactionStats() CompactionStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.compaction
+	stats.Removals = d.snap.Load().removals
+	return stats
+}
+
+// maybeCompact 移除次数达到阈值且距上次压缩超过最小间隔时启动后台压缩, 调用方须持有写锁
+func (d *Engine) maybeCompact() {
+	threshold := d.opts.compactThreshold
+	if threshold <= 0 || d.compacting || d.snap.Load().removals < threshold {
+		return
+	}
+	if !d.compaction.LastRun.IsZero() && time.Since(d.compaction.LastRun) < d.opts.compactInterval {
+		return
+	}
+
+	d.compacting = true
+	d.bg.Add(1)
+	go func() {
+		defer d.bg.Done()
+		d.compactTrie()
+	}()
+}
+
+// Compact 立即压缩前缀树, 将仍在使用的节点复制到新的分配器, 旧的分配块在最后一个读者结束后由GC回收
+func (d *Engine) Compact() CompactionStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.compactLocked()
+	stats := d.compaction
+	stats.Removals = d.snap.Load().removals
+	return stats
+}
+
+// compactTrie 后台压缩前缀树
+func (d *Engine) compactTrie() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer func() { d.compacting = false }()
+
+	d.compactLocked()
+}
+
+// compactLocked 重建前缀树并原子切换快照, 正在执行的Segment仍使用旧前缀树完成, 调用方须持有写锁
+func (d *Engine) compactLocked() {
+	start := time.Now()
+	snap := d.snap.Load()
+	next := *snap
+	next.arena = newNodeArena()
+	next.root, next.nodes, next.bytes = compactNode(snap.root, next.arena, next.childLimit)
+	if next.root == nil {
+		next.root, next.nodes, next.bytes = next.arena.alloc(), 1, trieNodeBytes
+	}
+	next.removals = 0
+	next.asciiRoots = countASCIIRoots(next.root)
+	d.snap.Store(&next)
+
+	d.compaction.Runs++
+	d.compaction.NodesReclaimed += snap.nodes - next.nodes
+	d.compaction.BytesReclaimed += snap.bytes - next.bytes
+	d.compaction.LastRun = start
+	d.compaction.LastDuration = time.Since(start).String()
+}
+
+// compactNode 将节点及其子树复制到arena, 跳过不含词条的分支, 返回新节点、节点数与估算内存
+// 子节点按数量重新选择切片或map存储; 子树中没有词条时返回nil
+func compactNode(node *TrieNode, arena *nodeArena, limit int) (*TrieNode, int64, int64) {
+	compacted := &TrieNode{IsEnd: node.IsEnd, Entry: node.Entry}
+	nodes, bytes := int64(1), trieNodeBytes
+	for char, child := range node.Children() {
+		c, n, b := compactNode(child, arena, limit)
+		if c == nil {
+			continue
+		}
+		compacted.setChild(char, c, limit)
+		nodes += n
+		bytes += b + trieEdgeBytes + int64(len(char))
+	}
+	if !node.IsEnd && compacted.ChildCount() == 0 {
+		return nil, 0, 0
+	}
+	if node.IsEnd && node.Entry != nil {
+		bytes += entryBytes(node.Entry)
+	}
+	slot := arena.alloc()
+	*slot = *compacted
+	return slot, nodes, bytes
+}
@@ -0,0 +1,135 @@
+package participle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrConditionalUnsupported 主节点不支持条件写入
+var ErrConditionalUnsupported = errors.New("participle: primary does not support conditional writes")
+
+// ConditionalPrimary 支持条件写入的主节点, *Engine实现了该接口
+// 启用读写分离时, 主节点实现该接口才能转发AddWordIfAbsent与CompareAndSetFrequency
+type ConditionalPrimary interface {
+	AddWordIfAbsent(content string, frequency float64, pos string) (bool, error)
+	CompareAndSetFrequency(content string, old, frequency float64) (bool, error)
+}
+
+// AddWordIfAbsent 词典中不存在该词时添加, 返回是否添加
+// 检查与写入在同一个数据库事务中完成, 多个客户端同时推送同一个词时只有一个生效
+func (d *Engine) AddWordIfAbsent(content string, frequency float64, pos string) (bool, error) {
+	if d.opts.primary != nil {
+		p, ok := d.opts.primary.(ConditionalPrimary)
+		if !ok {
+			return false, ErrConditionalUnsupported
+		}
+		return p.AddWordIfAbsent(content, frequency, pos)
+	}
+	return d.conditionalWrite(content, func(current *DictEntry) (*DictEntry, error) {
+		if current != nil {
+			return nil, nil
+		}
+		return &DictEntry{Content: content, Frequency: frequency, Pos: pos}, nil
+	})
+}
+
+// CompareAndSetFrequency 词频等于old时将词频改为frequency, 返回是否修改, 词不存在时返回ErrWordNotFound
+// 比较与写入在同一个数据库事务中完成, 客户端读取词频后据此调整, 可避免覆盖其他客户端在此期间的调整
+func (d *Engine) CompareAndSetFrequency(content string, old, frequency float64) (bool, error) {
+	if d.opts.primary != nil {
+		p, ok := d.opts.primary.(ConditionalPrimary)
+		if !ok {
+			return false, ErrConditionalUnsupported
+		}
+		return p.CompareAndSetFrequency(content, old, frequency)
+	}
+	return d.conditionalWrite(content, func(current *DictEntry) (*DictEntry, error) {
+		if current == nil {
+			return nil, ErrWordNotFound
+		}
+		if current.Frequency != old {
+			return nil, nil
+		}
+		next := *current
+		next.Frequency = frequency
+		return &next, nil
+	})
+}
+
+// conditionalWrite 读取词条并按条件修改, 成功后应用到当前词典, 返回是否修改
+// decide根据当前词条(不存在时为nil)返回要写入的词条, 返回nil表示条件不满足
+// 读取与写入在同一个数据库事务中完成; 副本引擎以内存中的词典为准
+func (d *Engine) conditionalWrite(content string, decide func(current *DictEntry) (*DictEntry, error)) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snap := d.snap.Load()
+	if d.fork != nil {
+		if d.fork.parent == nil {
+			return false, ErrForkPromoted
+		}
+		var current *DictEntry
+		if prev := snap.lookup(content); prev != nil {
+			entry := *prev
+			current = &entry
+		}
+		next, err := decide(current)
+		if err != nil || next == nil {
+			return false, err
+		}
+		return true, d.addEntryLocked(*next)
+	}
+
+	// 降级模式下待写队列中的修改尚未写入数据库, 无法在事务中比较
+	if d.degradedErr != nil {
+		if err := d.flushPending(); err != nil {
+			return false, fmt.Errorf("database unavailable: %v", err)
+		}
+	}
+
+	var next *DictEntry
+	err := d.store.Update(func(tx StoreTxn) error {
+		current, err := readEntry(tx, d.entryKey(content))
+		if err != nil {
+			return err
+		}
+		if next, err = decide(current); err != nil || next == nil {
+			return err
+		}
+		if err := d.checkLimits(snap, content, next); err != nil {
+			return err
+		}
+		data, err := json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		return tx.Set(d.entryKey(content), data)
+	})
+	if err != nil || next == nil {
+		return false, err
+	}
+
+	// 已写入数据库, 不再回滚
+	if _, err := d.applyEntry(snap, *next); err != nil {
+		return true, fmt.Errorf("add token to segmenter fail: %v", err)
+	}
+	d.seq++
+	return true, nil
+}
+
+// readEntry 在事务中读取键对应的词条, 不存在时返回nil
+func readEntry(tx StoreTxn, key []byte) (*DictEntry, error) {
+	val, err := tx.Get(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry DictEntry
+	if err := json.Unmarshal(val, &entry); err != nil {
+		return nil, fmt.Errorf("decode entry fail: %v", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,244 @@
+package participle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld 写入租约已被其他进程持有
+	ErrLeaseHeld = errors.New("participle: writer lease is held by another process")
+	// ErrLeaseLost 写入租约已释放, 或协调目录中的租约文件已被删除或替换
+	ErrLeaseLost = errors.New("participle: writer lease has been lost")
+)
+
+const (
+	leaseFileName = "writer.lock" // 写入租约文件
+	seqFileName   = "seq"         // 修改序号文件
+	packFileName  = "dict.pack"   // 已发布的词典包
+)
+
+// Lease 多进程共享词典时的写入租约
+// 同一协调目录下只有持有租约的进程可以打开数据库写入, 其他进程通过Follow跟随已发布的词典
+// 租约基于flock, 进程退出时由操作系统自动释放
+type Lease struct {
+	f *os.File
+}
+
+// AcquireLease 尝试获取协调目录下的写入租约, 已被其他进程持有时返回ErrLeaseHeld
+func AcquireLease(dir string) (*Lease, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, leaseFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lease{f: f}, nil
+}
+
+// Release 释放写入租约
+func (l *Lease) Release() error {
+	return l.f.Close()
+}
+
+// check 检查是否仍持有写入租约
+// 租约文件被删除后其他进程可以在新文件上获取租约, 此时原租约的锁已不再排他
+func (l *Lease) check() error {
+	held, err := l.f.Stat()
+	if err != nil {
+		return ErrLeaseLost
+	}
+	current, err := os.Stat(l.f.Name())
+	if err != nil || !os.SameFile(held, current) {
+		return ErrLeaseLost
+	}
+	if err := lockFile(l.f); err != nil {
+		return fmt.Errorf("%w: %v", ErrLeaseLost, err)
+	}
+	return nil
+}
+
+// ChangeSeq 读取协调目录下已发布词典的修改序号, 尚未发布时返回0
+func ChangeSeq(dir string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, seqFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// writeFileAtomic 先写临时文件再重命名, 读者不会读到写了一半的文件
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Publish 将当前词典发布到协调目录并递增修改序号, 由持有租约的写入进程在修改后调用
+// 租约已失效时返回ErrLeaseLost, 不写入任何文件, 避免覆盖新的写入进程发布的词典
+func (d *Engine) Publish(lease *Lease) error {
+	dir := filepath.Dir(lease.f.Name())
+	if err := lease.check(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	pack := &DictionaryPack{Name: "shared", Entries: collectEntries(d.snap.Load().root)}
+	d.mu.Unlock()
+
+	seq, err := ChangeSeq(dir)
+	if err != nil {
+		return fmt.Errorf("read change seq fail: %v", err)
+	}
+	pack.Version = strconv.FormatUint(seq+1, 10)
+
+	if err := writeFileAtomic(filepath.Join(dir, packFileName), func(f *os.File) error {
+		return WritePack(f, pack, nil)
+	}); err != nil {
+		return fmt.Errorf("write dict pack fail: %v", err)
+	}
+	// 写入词典包期间租约可能失效, 更新序号前再次检查
+	if err := lease.check(); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, seqFileName), func(f *os.File) error {
+		_, err := f.WriteString(pack.Version)
+		return err
+	})
+}
+
+// collectEntries 收集前缀树中的所有词条
+func collectEntries(root *TrieNode) []DictEntry {
+	var entries []DictEntry
+	var walk func(node *TrieNode)
+	walk = func(node *TrieNode) {
+		if node.IsEnd && node.Entry != nil {
+			entries = append(entries, *node.Entry)
+		}
+		for _, child := range node.Children() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return entries
+}
+
+// Follower 跟随协调目录中已发布的词典
+type Follower struct {
+	engine   *Engine
+	dir      string
+	interval time.Duration
+	rm       ReportMessage
+	seq      uint64 // 已加载的修改序号
+
+	done chan struct{} // 退出信号
+	stop chan struct{} // 退出成功信号
+}
+
+// Follow 定时轮询协调目录的修改序号, 序号变化时以已发布的词典替换当前词典
+// 用于未持有写入租约的只读进程, 替换后的词典只存在于内存中; 加载错误交由rm处理, rm可为nil
+func (d *Engine) Follow(dir string, interval time.Duration, rm ReportMessage) *Follower {
+	f := &Follower{
+		engine:   d,
+		dir:      dir,
+		interval: interval,
+		rm:       rm,
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	f.poll()
+	go f.listener()
+	return f
+}
+
+// listener 监听轮询周期
+func (f *Follower) listener() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	defer close(f.stop)
+
+	for {
+		select {
+		case <-ticker.C:
+			f.poll()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// poll 检查修改序号并加载新发布的词典
+func (f *Follower) poll() {
+	seq, err := ChangeSeq(f.dir)
+	if err == nil && seq > f.seq {
+		err = f.load()
+		if err == nil {
+			f.seq = seq
+		}
+	}
+	if err != nil && f.rm != nil {
+		f.rm(err)
+	}
+}
+
+// load 加载已发布的词典并切换快照
+func (f *Follower) load() error {
+	file, err := os.Open(filepath.Join(f.dir, packFileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	pack, err := LoadPack(file, nil)
+	if err != nil {
+		return err
+	}
+
+	d := f.engine
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snap := newSnapshot(d.snap.Load().version+1, d.opts)
+	for i := range pack.Entries {
+		snap.insert(pack.Entries[i].Content, &pack.Entries[i])
+	}
+	if err := snap.initSegmenter(d.opts); err != nil {
+		return err
+	}
+	d.snap.Store(snap)
+	d.seq++
+	return nil
+}
+
+// Stop 停止跟随
+func (f *Follower) Stop() {
+	close(f.done)
+	<-f.stop
+}
@@ -0,0 +1,56 @@
+//go:build unix
+
+package participle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPublishRequiresLease(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	lease, err := AcquireLease(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLease(dir); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("second AcquireLease = %v, want ErrLeaseHeld", err)
+	}
+	if err := d.Publish(lease); err != nil {
+		t.Fatal(err)
+	}
+
+	// 租约文件被删除后新的写入进程获得租约, 原写入进程不能再发布
+	if err := os.Remove(filepath.Join(dir, leaseFileName)); err != nil {
+		t.Fatal(err)
+	}
+	next, err := AcquireLease(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Publish(next); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Publish(lease); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Publish with a replaced lease = %v, want ErrLeaseLost", err)
+	}
+	if seq, err := ChangeSeq(dir); err != nil || seq != 2 {
+		t.Errorf("ChangeSeq = %d, %v, want 2", seq, err)
+	}
+
+	if err := next.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Publish(next); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Publish with a released lease = %v, want ErrLeaseLost", err)
+	}
+	lease.Release()
+}
@@ -0,0 +1,144 @@
+package participle
+
+import "errors"
+
+// ErrPendingFull 降级模式下待写队列已满
+var ErrPendingFull = errors.New("participle: pending mutation queue is full")
+
+// Mode 引擎运行模式
+type Mode int
+
+const (
+	ModeNormal   Mode = iota // 正常模式
+	ModeDegraded             // 降级模式: 数据库不可写, 仅提供内存分词, 词典修改暂存于待写队列
+)
+
+// String 运行模式名称
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "normal"
+	case ModeDegraded:
+		return "degraded"
+	default:
+		return "unknown"
+	}
+}
+
+// Status 引擎状态
+type Status struct {
+	Mode    Mode  // 运行模式
+	Pending int   // 待写入数据库的词条数
+	Err     error // 进入降级模式的原因
+}
+
+// Status 获取引擎状态
+func (d *Engine) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := Status{Mode: ModeNormal, Pending: len(d.pending), Err: d.degradedErr}
+	if d.degradedErr != nil {
+		status.Mode = ModeDegraded
+	}
+	return status
+}
+
+// Recover 尝试将待写队列写入数据库并退出降级模式
+// 正常模式下直接返回nil
+func (d *Engine) Recover() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.flushPending(); err != nil {
+		return err
+	}
+	return d.checkpoint()
+}
+
+// persist 保存词条到数据库
+// 降级模式下先尝试恢复, 仍不可写时将词条加入待写队列
+func (d *Engine) persist(entry DictEntry) error {
+	if d.degradedErr != nil {
+		if err := d.flushPending(); err != nil {
+			return d.enqueue(entry)
+		}
+	}
+
+	if err := d.saveEntry(entry); err != nil {
+		d.degradedErr = err
+		return d.enqueue(entry)
+	}
+	return nil
+}
+
+// persistAll 批量保存词条到数据库
+// 降级模式下先尝试恢复, 仍不可写时将全部词条加入待写队列
+func (d *Engine) persistAll(entries []DictEntry) error {
+	if d.degradedErr != nil {
+		if err := d.flushPending(); err != nil {
+			return d.enqueueAll(entries)
+		}
+	}
+
+	if err := d.saveEntries(entries); err != nil {
+		d.degradedErr = err
+		return d.enqueueAll(entries)
+	}
+	return nil
+}
+
+// enqueueAll 将词条全部加入待写队列, 队列容纳不下时不加入任何词条
+func (d *Engine) enqueueAll(entries []DictEntry) error {
+	added := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if _, ok := d.pendingAt[entry.Content]; !ok {
+			added[entry.Content] = true
+		}
+	}
+	if len(d.pending)+len(added) > d.opts.maxPending {
+		return ErrPendingFull
+	}
+	for _, entry := range entries {
+		d.enqueue(entry)
+	}
+	return nil
+}
+
+// enqueue 将词条加入待写队列, 同一词条只保留最后一次修改
+func (d *Engine) enqueue(entry DictEntry) error {
+	if i, ok := d.pendingAt[entry.Content]; ok {
+		d.pending[i] = entry
+		return nil
+	}
+	if len(d.pending) >= d.opts.maxPending {
+		return ErrPendingFull
+	}
+	if d.pendingAt == nil {
+		d.pendingAt = make(map[string]int)
+	}
+	d.pendingAt[entry.Content] = len(d.pending)
+	d.pending = append(d.pending, entry)
+	return nil
+}
+
+// flushPending 依次写入待写队列, 全部成功后退出降级模式
+// 写入失败时保留未写入的词条并重建索引
+func (d *Engine) flushPending() error {
+	for i, entry := range d.pending {
+		if err := d.saveEntry(entry); err != nil {
+			d.degradedErr = err
+			if i > 0 {
+				d.pending = d.pending[i:]
+				clear(d.pendingAt)
+				for j, entry := range d.pending {
+					d.pendingAt[entry.Content] = j
+				}
+			}
+			return err
+		}
+	}
+	d.pending = nil
+	d.pendingAt = nil
+	d.degradedErr = nil
+	return nil
+}
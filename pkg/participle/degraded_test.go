@@ -0,0 +1,95 @@
+package participle
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// pickyStore 写入指定词条时失败的内存存储
+type pickyStore struct {
+	*MemoryStore
+	mu  sync.Mutex
+	bad string
+}
+
+func (s *pickyStore) fail(content string) {
+	s.mu.Lock()
+	s.bad = content
+	s.mu.Unlock()
+}
+
+func (s *pickyStore) Set(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bad != "" && string(key) == s.bad {
+		return errStoreDown
+	}
+	return s.MemoryStore.Set(key, value)
+}
+
+func TestPendingQueueDeduplicates(t *testing.T) {
+	store := &pickyStore{MemoryStore: NewMemoryStore()}
+	d, err := NewWithStore(t.Context(), store, WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	wantPending := func(n int) {
+		t.Helper()
+		status := d.Status()
+		if status.Mode != ModeDegraded || status.Pending != n {
+			t.Fatalf("status %v with %d pending, want degraded with %d", status.Mode, status.Pending, n)
+		}
+	}
+
+	store.fail("乙")
+	for _, word := range []string{"甲", "乙", "丙"} {
+		if err := d.AddWord(word, 1, "n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantPending(2)
+	// 同一词条只保留最后一次修改
+	if err := d.AddWord("乙", 2, "n"); err != nil {
+		t.Fatal(err)
+	}
+	wantPending(2)
+
+	// 部分写入后失败, 剩余词条仍可按词去重
+	store.fail("丙")
+	if err := d.Recover(); err == nil {
+		t.Fatal("Recover succeeded with a failing write")
+	}
+	wantPending(1)
+	if err := d.AddWord("丙", 5, "n"); err != nil {
+		t.Fatal(err)
+	}
+	wantPending(1)
+	if err := d.AddWord("丁", 1, "n"); err != nil {
+		t.Fatal(err)
+	}
+	wantPending(2)
+
+	store.fail("")
+	if err := d.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if status := d.Status(); status.Mode != ModeNormal || status.Pending != 0 {
+		t.Fatalf("status after recover: %+v", status)
+	}
+	for word, freq := range map[string]float64{"甲": 1, "乙": 2, "丙": 5, "丁": 1} {
+		data, err := store.Get([]byte(word))
+		if err != nil {
+			t.Fatalf("%s: %v", word, err)
+		}
+		var entry DictEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Frequency != freq {
+			t.Errorf("%s frequency = %v, want %v", word, entry.Frequency, freq)
+		}
+	}
+}
@@ -5,4 +5,8 @@ type DictEntry struct {
 	Content   string  `json:"content"`   // 词条内容
 	Frequency float64 `json:"frequency"` // 词频
 	Pos       string  `json:"pos"`       // 词性
+
+	Examples []string `json:"examples,omitempty"` // 样例句子
+
+	deleted bool // 删除标记, 仅用于待写队列与副本的修改记录
 }
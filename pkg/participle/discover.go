@@ -0,0 +1,250 @@
+package participle
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"unicode"
+)
+
+const (
+	// defaultDiscoverMaxLen 新词发现的默认最大字数
+	defaultDiscoverMaxLen = 4
+	// defaultDiscoverMinCount 新词发现的默认最少出现次数
+	defaultDiscoverMinCount = 5
+	// defaultDiscoverMinPMI 新词发现的默认最小凝固度
+	defaultDiscoverMinPMI = 3.0
+	// defaultDiscoverMinEntropy 新词发现的默认最小左右邻字信息熵
+	defaultDiscoverMinEntropy = 1.0
+)
+
+// NewWord 统计发现的新词
+type NewWord struct {
+	Word         string  `json:"word"`          // 词
+	Count        int     `json:"count"`         // 出现次数
+	PMI          float64 `json:"pmi"`           // 凝固度: 各种切分方式下点互信息的最小值
+	LeftEntropy  float64 `json:"left_entropy"`  // 左邻字信息熵
+	RightEntropy float64 `json:"right_entropy"` // 右邻字信息熵
+}
+
+// DiscoverOption 新词发现配置项
+type DiscoverOption func(*discoverOptions)
+
+// discoverOptions 新词发现配置
+type discoverOptions struct {
+	maxLen     int
+	minCount   int
+	minPMI     float64
+	minEntropy float64
+}
+
+// DiscoverMaxLen 设置候选词的最大字数, 默认为4
+func DiscoverMaxLen(n int) DiscoverOption {
+	return func(o *discoverOptions) { o.maxLen = max(n, 2) }
+}
+
+// DiscoverMinCount 设置候选词的最少出现次数, 默认为5
+func DiscoverMinCount(n int) DiscoverOption {
+	return func(o *discoverOptions) { o.minCount = n }
+}
+
+// DiscoverMinPMI 设置候选词的最小凝固度(自然对数的点互信息), 默认为3
+func DiscoverMinPMI(v float64) DiscoverOption {
+	return func(o *discoverOptions) { o.minPMI = v }
+}
+
+// DiscoverMinEntropy 设置候选词左右邻字信息熵的最小值(自然对数), 默认为1
+func DiscoverMinEntropy(v float64) DiscoverOption {
+	return func(o *discoverOptions) { o.minEntropy = v }
+}
+
+// neighbors n元组的邻字统计
+type neighbors struct {
+	left, right           map[rune]int // 邻字 -> 次数
+	leftBound, rightBound int          // 位于片段边界的次数, 每次视为不同的邻字
+}
+
+// WordDiscoverer 基于n元组频率、点互信息与左右邻字信息熵的新词发现
+// 语料按汉字片段统计, 非汉字字符作为片段边界; 统计结果保存在内存中
+type WordDiscoverer struct {
+	opts  discoverOptions
+	total int                   // 汉字总数
+	grams map[string]int        // n元组 -> 次数, n为1到maxLen
+	sides map[string]*neighbors // 两字及以上的n元组 -> 邻字统计
+}
+
+// NewWordDiscoverer 创建新词发现器
+func NewWordDiscoverer(opts ...DiscoverOption) *WordDiscoverer {
+	o := discoverOptions{
+		maxLen:     defaultDiscoverMaxLen,
+		minCount:   defaultDiscoverMinCount,
+		minPMI:     defaultDiscoverMinPMI,
+		minEntropy: defaultDiscoverMinEntropy,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &WordDiscoverer{opts: o, grams: make(map[string]int), sides: make(map[string]*neighbors)}
+}
+
+// Add 统计一段文本
+func (w *WordDiscoverer) Add(text string) {
+	var chunk []rune
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			chunk = append(chunk, r)
+			continue
+		}
+		w.addChunk(chunk)
+		chunk = chunk[:0]
+	}
+	w.addChunk(chunk)
+}
+
+// addChunk 统计一个汉字片段中的n元组及其邻字
+func (w *WordDiscoverer) addChunk(chunk []rune) {
+	w.total += len(chunk)
+	for i := range chunk {
+		for n := 1; n <= w.opts.maxLen && i+n <= len(chunk); n++ {
+			gram := string(chunk[i : i+n])
+			w.grams[gram]++
+			if n == 1 {
+				continue
+			}
+
+			side := w.sides[gram]
+			if side == nil {
+				side = &neighbors{left: make(map[rune]int), right: make(map[rune]int)}
+				w.sides[gram] = side
+			}
+			if i > 0 {
+				side.left[chunk[i-1]]++
+			} else {
+				side.leftBound++
+			}
+			if i+n < len(chunk) {
+				side.right[chunk[i+n]]++
+			} else {
+				side.rightBound++
+			}
+		}
+	}
+}
+
+// pmi 凝固度: 各种切分为两部分的方式中点互信息的最小值
+func (w *WordDiscoverer) pmi(gram string, count int) float64 {
+	runes := []rune(gram)
+	best := math.Inf(1)
+	for i := 1; i < len(runes); i++ {
+		a, b := w.grams[string(runes[:i])], w.grams[string(runes[i:])]
+		if a == 0 || b == 0 {
+			continue
+		}
+		best = min(best, math.Log(float64(count)*float64(w.total)/(float64(a)*float64(b))))
+	}
+	return best
+}
+
+// entropy 邻字信息熵, 边界处的每次出现视为不同的邻字
+func entropy(counts map[rune]int, bound int) float64 {
+	total := bound
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	var h float64
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		h -= p * math.Log(p)
+	}
+	if bound > 0 {
+		p := 1 / float64(total)
+		h -= float64(bound) * p * math.Log(p)
+	}
+	return h
+}
+
+// Words 获取满足出现次数、凝固度与左右邻字信息熵阈值的候选词, 按出现次数降序
+func (w *WordDiscoverer) Words() []NewWord {
+	var words []NewWord
+	for gram, side := range w.sides {
+		count := w.grams[gram]
+		if count < w.opts.minCount {
+			continue
+		}
+		pmi := w.pmi(gram, count)
+		if pmi < w.opts.minPMI {
+			continue
+		}
+		left, right := entropy(side.left, side.leftBound), entropy(side.right, side.rightBound)
+		if min(left, right) < w.opts.minEntropy {
+			continue
+		}
+		words = append(words, NewWord{Word: gram, Count: count, PMI: pmi, LeftEntropy: left, RightEntropy: right})
+	}
+	sort.Slice(words, func(a, b int) bool {
+		if words[a].Count != words[b].Count {
+			return words[a].Count > words[b].Count
+		}
+		return words[a].Word < words[b].Word
+	})
+	return words
+}
+
+// DiscoverWords 从语料中统计发现新词, 每行为一篇文档, 已在自定义词典、GSE基础词典或学习黑名单中的词不会返回
+func (d *Engine) DiscoverWords(ctx context.Context, r io.Reader, opts ...DiscoverOption) ([]NewWord, error) {
+	w := NewWordDiscoverer(opts...)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		w.Add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read corpus fail: %v", err)
+	}
+
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+	snap := d.snap.Load()
+	all := w.Words()
+	words := all[:0]
+	for _, word := range all {
+		if snap.contains(word.Word) || d.Blacklisted(word.Word) {
+			continue
+		}
+		if _, _, ok := snap.segmenter.Find(word.Word); ok {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+// LearnDiscovered 从语料中统计发现新词并加入词典, 见DiscoverWords
+// 与LearnFromText不同, 只有满足出现次数、凝固度与左右邻字信息熵阈值的词才会被学习, 返回学习到的新词
+func (d *Engine) LearnDiscovered(ctx context.Context, r io.Reader, opts ...DiscoverOption) ([]NewWord, error) {
+	words, err := d.DiscoverWords(ctx, r, opts...)
+	if err != nil || len(words) == 0 {
+		return words, err
+	}
+
+	entries := make([]DictEntry, len(words))
+	for i, word := range words {
+		entries[i] = DictEntry{Content: word.Word, Frequency: 1000.0, Pos: "nz"}
+	}
+	if err := d.AddWords(entries); err != nil {
+		return nil, fmt.Errorf("添加新词失败: %v", err)
+	}
+	for _, entry := range entries {
+		d.recordLearned(entry)
+	}
+	return words, nil
+}
@@ -1,61 +1,259 @@
+// Package participle 基于GSE与前缀树的中文分词引擎, 词典保存在DictStore中
+//
+// 本包属于v1稳定接口: 导出的名称与行为在v1内保持兼容, 标记为Deprecated的名称在下一个主版本中移除
 package participle
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
 	bd "github.com/dgraph-io/badger/v4"
 
-	"github.com/go-ego/gse"
 	"github.com/miajio/nla/pkg/badger"
 )
 
-// Engine 分词引擎
+var (
+	// ErrWordNotFound 词不在词典中
+	ErrWordNotFound = errors.New("participle: word not found")
+	// ErrInvalidWord 词为空、不是合法的UTF-8文本或包含NUL字符, GSE分词器无法正确保存与移除这样的词
+	ErrInvalidWord = errors.New("participle: invalid word")
+)
+
+// Engine 分词引擎, 可被多个goroutine并发使用
+// 词典修改由写锁mu串行化; 修改当前快照的前缀树与分词器时另加读写锁rw的写锁, 分词等读操作持有rw的读锁
 type Engine struct {
-	dbEngine  *badger.Engine // 数据库
-	segmenter gse.Segmenter  // 分词器
-	root      *TrieNode      // 前缀树根节点
+	store DictStore // 词典存储
+
+	opts options // 配置项
+
+	journal     *journal       // 预写日志
+	pending     []DictEntry    // 降级模式下待写入数据库的词条
+	pendingAt   map[string]int // 待写队列中词条的位置, 按词索引
+	degradedErr error          // 进入降级模式的原因, nil表示正常模式
+
+	fork *fork  // 非nil表示该引擎为Fork创建的副本
+	seq  uint64 // 词典修改序号
+
+	learnedMu sync.Mutex    // 学习记录锁
+	learned   []LearnedWord // 未被取走的学习记录
+	blacklist *blacklist    // 学习黑名单, 副本引擎与原引擎共用
+
+	historyMu sync.Mutex // 词频时间序列更新锁
+
+	mu   sync.Mutex               // 写锁, 串行化词典修改与重载
+	rw   sync.RWMutex             // 读写锁, 保护当前快照中前缀树与分词器的原地修改
+	snap atomic.Pointer[snapshot] // 当前快照
+
+	compaction CompactionStats // 前缀树压缩统计, 由mu保护
+	compacting bool            // 是否正在后台压缩, 由mu保护
+	bg         sync.WaitGroup  // 后台任务
 }
 
 // New 创建分词引擎
-func New(dbEngine *badger.Engine) (*Engine, error) {
-	// 初始化前缀树根节点
-	root := NewTrieNode()
+func New(dbEngine *badger.Engine, opts ...Option) (*Engine, error) {
+	return NewWithContext(context.Background(), dbEngine, opts...)
+}
+
+// NewWithContext 创建分词引擎, 加载词典期间ctx取消时放弃已加载的内容并返回ctx的错误
+// GSE基础词典的加载无法中断, 在加载完成后检查ctx
+func NewWithContext(ctx context.Context, dbEngine *badger.Engine, opts ...Option) (*Engine, error) {
+	return NewWithStore(ctx, NewBadgerStore(dbEngine), opts...)
+}
 
-	// 从数据库加载已有词典到前缀树
-	if err := loadDictionaryFromDB(dbEngine.DB(), root); err != nil {
-		return nil, fmt.Errorf("read db load dict fail: %v", err)
+// NewWithStore 使用自定义词典存储创建分词引擎, 见NewWithContext; 关闭引擎时一并关闭存储
+func NewWithStore(ctx context.Context, store DictStore, opts ...Option) (*Engine, error) {
+	e := &Engine{store: store, opts: defaultOptions()}
+	for _, opt := range opts {
+		opt(&e.opts)
+	}
+	if err := e.opts.checkNamespaces(); err != nil {
+		return nil, err
 	}
 
-	// 初始化GSE分词器
-	seg, err := gse.New()
+	snap, err := buildSnapshot(ctx, store, 1, e.opts)
 	if err != nil {
-		return nil, fmt.Errorf("无法初始化GSE分词器: %v", err)
+		return nil, err
 	}
+	e.snap.Store(snap)
 
-	// 从前缀树加载词典到GSE
-	loadDictionaryFromTrie(root, &seg)
+	if e.blacklist, err = loadBlacklist(store); err != nil {
+		return nil, fmt.Errorf("load blacklist fail: %v", err)
+	}
 
-	return &Engine{
-		segmenter: seg,
-		dbEngine:  dbEngine,
-		root:      root,
-	}, nil
+	if e.opts.journal != "" {
+		if err := e.openJournal(); err != nil {
+			return nil, fmt.Errorf("open journal fail: %v", err)
+		}
+	}
+	return e, nil
+}
+
+// openJournal 打开预写日志并重放上次未正常关闭时遗留的修改
+func (d *Engine) openJournal() error {
+	j, records, err := openJournal(d.opts.journal)
+	if err != nil {
+		return err
+	}
+	d.journal = j
+
+	snap := d.snap.Load()
+	for _, rec := range records {
+		switch rec.Op {
+		case journalOpAdd:
+		case journalOpDelete:
+			rec.Entry.deleted = true
+		default:
+			continue
+		}
+		if err := d.persist(rec.Entry); err != nil {
+			return err
+		}
+		if _, err := d.applyEntry(snap, rec.Entry); err != nil {
+			return err
+		}
+	}
+	return d.checkpoint()
+}
+
+// journalMark 返回预写日志的当前长度, 修改未能保存时传给rollbackJournal
+func (d *Engine) journalMark() int64 {
+	if d.journal == nil {
+		return 0
+	}
+	return d.journal.size
+}
+
+// rollbackJournal 将预写日志截断到mark, 去除既未写入数据库也未转入待写队列的修改, 避免重放时恢复已回滚的修改
+func (d *Engine) rollbackJournal(mark int64) error {
+	if d.journal == nil {
+		return nil
+	}
+	if err := d.journal.truncate(mark); err != nil {
+		return fmt.Errorf("truncate journal fail: %v", err)
+	}
+	return nil
+}
+
+// checkpoint 待写队列为空时所有修改均已落库, 清空预写日志
+func (d *Engine) checkpoint() error {
+	if d.journal == nil || len(d.pending) > 0 {
+		return nil
+	}
+	return d.journal.reset()
+}
+
+// Reload 从数据库重新加载词典
+// 新快照构建完成后原子切换, 切换前开始的Segment仍使用旧快照完成
+func (d *Engine) Reload() error {
+	return d.ReloadContext(context.Background())
+}
+
+// ReloadContext 从数据库重新加载词典, 见Reload; 切换前ctx取消时保留当前词典并返回ctx的错误
+func (d *Engine) ReloadContext(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+
+	snap, err := buildSnapshot(ctx, d.store, d.snap.Load().version+1, d.opts)
+	if err != nil {
+		return err
+	}
+
+	// 待写队列中的词条尚未落库, 需重新应用到新快照
+	for _, entry := range d.pending {
+		if _, err := snap.apply(entry); err != nil {
+			return err
+		}
+	}
+	d.snap.Store(snap)
+	d.seq++
+	return nil
+}
+
+// Version 获取当前词典快照版本
+func (d *Engine) Version() uint64 {
+	return d.snap.Load().version
+}
+
+// MetaKeyPrefix 非词条数据的键前缀, 加载词典时跳过
+// 其他模块与词典共用数据库时, 键须以该前缀开头
+const MetaKeyPrefix = "\x00nla:"
+
+// metaKeyEnd 按字节序位于所有MetaKeyPrefix键之后的位置, 即前缀的最后一个字节加一
+var metaKeyEnd = []byte("\x00nla;")
+
+// loadCheckEvery 加载词典时检查ctx的间隔词条数
+const loadCheckEvery = 1024
+
+// loadDictionary 从词典存储加载词典到前缀树, 参数见loadDictionaryFromDB
+// badger存储按迭代器跳过非词条数据, 其他存储逐个跳过
+func loadDictionary(ctx context.Context, store DictStore, snap *snapshot, prefix string, prefetchSize int) error {
+	if bs, ok := store.(*BadgerStore); ok {
+		return loadDictionaryFromDB(ctx, bs.db.DB(), snap, prefix, prefetchSize)
+	}
+
+	n := 0
+	return store.Iterate([]byte(prefix), func(key, value []byte) error {
+		if n++; n%loadCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if prefix == "" && bytes.HasPrefix(key, []byte(MetaKeyPrefix)) {
+			return nil
+		}
+		var entry DictEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return err
+		}
+		snap.insert(string(key[len(prefix):]), &entry)
+		return nil
+	})
 }
 
 // 从数据库加载词典到前缀树
-func loadDictionaryFromDB(db *bd.DB, root *TrieNode) error {
+// prefix为空时加载默认命名空间, 非词条数据整段跳过; 否则只加载键以prefix开头的词条, 词为去除前缀后的部分
+// prefetchSize为预取值的数量, 0表示使用默认值; 每加载loadCheckEvery个词条检查一次ctx
+func loadDictionaryFromDB(ctx context.Context, db *bd.DB, snap *snapshot, prefix string, prefetchSize int) error {
 	err := db.View(func(txn *bd.Txn) error {
 		opts := bd.DefaultIteratorOptions
 		opts.PrefetchValues = true
+		opts.Prefix = []byte(prefix)
+		if prefetchSize > 0 {
+			opts.PrefetchSize = prefetchSize
+		}
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
+		n := 0
 		for it.Rewind(); it.Valid(); it.Next() {
+			if n++; n%loadCheckEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
 			item := it.Item()
 			key := item.Key()
-			content := string(key)
+			if prefix == "" && bytes.HasPrefix(key, []byte(MetaKeyPrefix)) {
+				// 跳到非词条数据之后的第一个键
+				it.Seek(metaKeyEnd)
+				if !it.Valid() {
+					break
+				}
+				item, key = it.Item(), it.Item().Key()
+			}
+			content := string(key[len(prefix):])
 
 			err := item.Value(func(val []byte) error {
 				var entry DictEntry
@@ -64,18 +262,7 @@ func loadDictionaryFromDB(db *bd.DB, root *TrieNode) error {
 				}
 
 				// 将词条添加到前缀树
-				node := root
-				chars := SplitString(content)
-
-				for _, char := range chars {
-					if _, ok := node.Children[char]; !ok {
-						node.Children[char] = NewTrieNode()
-					}
-					node = node.Children[char]
-				}
-
-				node.IsEnd = true
-				node.Entry = &entry
+				snap.insert(content, &entry)
 				return nil
 			})
 
@@ -90,120 +277,360 @@ func loadDictionaryFromDB(db *bd.DB, root *TrieNode) error {
 	return err
 }
 
-// 从前缀树加载词典到GSE
-func loadDictionaryFromTrie(root *TrieNode, seg *gse.Segmenter) {
-	contents := make([]string, 0)
+// saveEntry 将词条保存到数据库
+// 副本引擎仅记录修改, 由Promote写入数据库
+func (d *Engine) saveEntry(entry DictEntry) error {
+	if d.fork != nil {
+		d.fork.record(entry)
+		return nil
+	}
 
-	// 遍历前缀树，收集所有词条
-	var collectContents func(node *TrieNode, prefix string)
-	collectContents = func(node *TrieNode, prefix string) {
-		if node.IsEnd && node.Entry != nil {
-			contents = append(contents, fmt.Sprintf("%s %f %s", node.Entry.Content, node.Entry.Frequency, node.Entry.Pos))
-		}
+	if entry.deleted {
+		return d.store.Delete(d.entryKey(entry.Content))
+	}
 
-		for char, child := range node.Children {
-			collectContents(child, prefix+char)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return d.store.Set(d.entryKey(entry.Content), data)
+}
+
+// saveEntries 将词条批量保存到数据库, badger存储通过WriteBatch写入, 其他存储在一个事务中写入
+// 副本引擎仅记录修改, 由Promote写入数据库
+func (d *Engine) saveEntries(entries []DictEntry) error {
+	if d.fork != nil {
+		for _, entry := range entries {
+			d.fork.record(entry)
 		}
+		return nil
 	}
 
-	collectContents(root, "")
+	if bs, ok := d.store.(*BadgerStore); ok {
+		return bs.db.Batch(func(wb *bd.WriteBatch) error {
+			if err := d.writeEntries(entries, wb.Set, wb.Delete); err != nil {
+				return err
+			}
+			return wb.Flush()
+		})
+	}
+	return d.store.Update(func(tx StoreTxn) error {
+		return d.writeEntries(entries, tx.Set, tx.Delete)
+	})
+}
 
-	// 如果有词条，加载到GSE分词器
-	if len(contents) > 0 {
-		dictData := strings.Join(contents, "\n")
-		seg.LoadDictStr(dictData)
+// writeEntries 通过set与del依次写入词条
+func (d *Engine) writeEntries(entries []DictEntry, set func(key, value []byte) error, del func(key []byte) error) error {
+	for _, entry := range entries {
+		if entry.deleted {
+			if err := del(d.entryKey(entry.Content)); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := set(d.entryKey(entry.Content), data); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// AddWord 添加一个新词到词典, 词不合法时返回ErrInvalidWord
+// 数据库不可写时引擎进入降级模式, 词条仅更新内存并进入待写队列, 见Status
+func (d *Engine) AddWord(content string, frequency float64, pos string) error {
+	return d.addEntry(DictEntry{
+		Content:   content,
+		Frequency: frequency,
+		Pos:       pos,
+	})
 }
 
-// 将词条插入前缀树并保存到数据库
-func (d *Engine) insertIntoTrieAndDB(content string, entry DictEntry) error {
-	// 添加到前缀树
-	node := d.root
-	chars := SplitString(content)
+// AddWords 批量添加词条到词典, 同一词条以最后一次出现为准
+// 写入前检查全部词条, 任一词条不合法(包括内容为空)时返回ErrInvalidWord并注明其下标, 不添加任何词条
+// 全部词条在一次加锁中写入前缀树与GSE分词器, 并通过一个WriteBatch写入数据库, 适用于导入大量词条
+// 任一词条超出资源限制或写入失败时不保留任何修改
+func (d *Engine) AddWords(entries []DictEntry) error {
+	for i, entry := range entries {
+		if !validWord(entry.Content) {
+			return fmt.Errorf("%w: entry %d: %q", ErrInvalidWord, i, entry.Content)
+		}
+	}
 
-	for _, char := range chars {
-		if _, ok := node.Children[char]; !ok {
-			node.Children[char] = NewTrieNode()
+	index := make(map[string]int, len(entries))
+	batch := make([]DictEntry, 0, len(entries))
+	for _, entry := range entries {
+		if i, ok := index[entry.Content]; ok {
+			batch[i] = entry
+			continue
 		}
-		node = node.Children[char]
+		index[entry.Content] = len(batch)
+		batch = append(batch, entry)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	if d.opts.primary != nil {
+		return d.opts.primary.AddWords(batch)
 	}
 
-	node.IsEnd = true
-	node.Entry = &entry
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	snap := d.snap.Load()
 
-	// 保存到数据库
-	data, err := json.Marshal(entry)
+	if d.fork != nil && d.fork.parent == nil {
+		return ErrForkPromoted
+	}
+
+	// 检查资源限制并更新前缀树与GSE分词器
+	undo, err := d.applyEntries(snap, batch)
 	if err != nil {
 		return err
 	}
 
-	return d.dbEngine.Set([]byte(content), data)
+	// 写入预写日志
+	mark := d.journalMark()
+	if d.journal != nil {
+		records := make([]journalRecord, len(batch))
+		for i, entry := range batch {
+			records[i] = journalRecord{Op: journalOpAdd, Entry: entry}
+		}
+		if err := d.journal.appendAll(records); err != nil {
+			undo()
+			return fmt.Errorf("write journal fail: %v", err)
+		}
+	}
+
+	// 保存到数据库, 失败时转入待写队列, 队列已满则回滚内存修改
+	if err := d.persistAll(batch); err != nil {
+		undo()
+		return errors.Join(err, d.rollbackJournal(mark))
+	}
+	d.seq++
+
+	return d.checkpoint()
 }
 
-// AddWord 添加一个新词到词典
-func (d *Engine) AddWord(content string, frequency float64, pos string) error {
-	entry := DictEntry{
-		Content:   content,
-		Frequency: frequency,
-		Pos:       pos,
+// applyEntries 在读写锁的保护下依次检查资源限制并将词条应用到当前快照, 失败时撤销已应用的词条
+func (d *Engine) applyEntries(snap *snapshot, entries []DictEntry) (undo func(), err error) {
+	d.rw.Lock()
+	defer d.rw.Unlock()
+
+	undos := make([]func(), 0, len(entries))
+	undoAll := func() {
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
 	}
+	for _, entry := range entries {
+		if err := d.checkLimits(snap, entry.Content, &entry); err != nil {
+			undoAll()
+			return nil, err
+		}
+		u, err := snap.apply(entry)
+		if err != nil {
+			undoAll()
+			return nil, fmt.Errorf("add token to segmenter fail: %v", err)
+		}
+		undos = append(undos, u)
+	}
+	return func() {
+		d.rw.Lock()
+		defer d.rw.Unlock()
+		undoAll()
+	}, nil
+}
+
+// UpdateWord 修改词典中已有词的词频与词性, 同时更新数据库中的词条并重新注册到GSE分词器
+// 样例句子保持不变; 词不在词典中时返回ErrWordNotFound
+func (d *Engine) UpdateWord(content string, frequency float64, pos string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	// 添加到前缀树并保存到数据库
-	if err := d.insertIntoTrieAndDB(content, entry); err != nil {
-		return fmt.Errorf("save content to db fail: %v", err)
+	prev := d.snap.Load().lookup(content)
+	if prev == nil {
+		return ErrWordNotFound
 	}
+	entry := *prev
+	entry.Frequency, entry.Pos = frequency, pos
+	return d.addEntryLocked(entry)
+}
 
-	// 更新GSE分词器
-	d.segmenter.AddToken(content, frequency, pos)
+// validWord 判断词能否加入词典
+func validWord(content string) bool {
+	return content != "" && utf8.ValidString(content) && !strings.ContainsRune(content, 0)
+}
 
-	return nil
+// addEntry 添加词条到词典
+func (d *Engine) addEntry(entry DictEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.addEntryLocked(entry)
 }
 
-// LearnFromText 从文本中学习新词汇
-func (d *Engine) LearnFromText(text string) error {
-	// 分词
-	contents := d.segmenter.Cut(text, true)
+// addEntryLocked 添加词条到词典, 调用方须持有写锁
+// 启用读写分离时转发到主节点
+func (d *Engine) addEntryLocked(entry DictEntry) error {
+	if !validWord(entry.Content) {
+		return fmt.Errorf("%w: %q", ErrInvalidWord, entry.Content)
+	}
+	if d.opts.primary != nil {
+		return d.opts.primary.AddWords([]DictEntry{entry})
+	}
+	content := entry.Content
+	snap := d.snap.Load()
 
-	// 分析新词
-	for _, content := range contents {
-		// 跳过特殊符号和单字词
-		if len(content) <= 1 || IsSpecialChar(content) {
-			continue
+	if d.fork != nil && d.fork.parent == nil {
+		return ErrForkPromoted
+	}
+
+	// 检查资源限制
+	if err := d.checkLimits(snap, content, &entry); err != nil {
+		return err
+	}
+
+	// 更新前缀树与GSE分词器, 成功后再写入预写日志, 避免重放应用失败的修改
+	undo, err := d.applyEntry(snap, entry)
+	if err != nil {
+		return fmt.Errorf("add token to segmenter fail: %v", err)
+	}
+	mark := d.journalMark()
+	if d.journal != nil {
+		if err := d.journal.append(journalRecord{Op: journalOpAdd, Entry: entry}); err != nil {
+			undo()
+			return fmt.Errorf("write journal fail: %v", err)
 		}
+	}
 
-		// 检查是否已存在于前缀树中
-		if !d.containsWord(content) {
-			// 默认频率为1000.0，词性为"nz"（其他专名）
-			if err := d.AddWord(content, 1000.0, "nz"); err != nil {
-				return fmt.Errorf("添加新词失败: %v", err)
-			}
-			fmt.Printf("学习到新词: %s\n", content)
+	// 保存到数据库, 失败时转入待写队列, 队列已满则回滚内存修改
+	if err := d.persist(entry); err != nil {
+		undo()
+		return errors.Join(err, d.rollbackJournal(mark))
+	}
+	d.seq++
+
+	return d.checkpoint()
+}
+
+// applyEntry 在读写锁的保护下将词条应用到当前快照, 返回的撤销函数同样加锁执行
+func (d *Engine) applyEntry(snap *snapshot, entry DictEntry) (undo func(), err error) {
+	d.rw.Lock()
+	defer d.rw.Unlock()
+
+	undoApply, err := snap.apply(entry)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		d.rw.Lock()
+		defer d.rw.Unlock()
+		undoApply()
+	}, nil
+}
+
+// DeleteWord 从词典中删除一个词, 同时删除数据库中的词条并从GSE分词器中移除
+// 词不在词典中时返回ErrWordNotFound; 词典中的词覆盖了GSE基础词典中的同名词时, 该词一并从分词器中移除
+func (d *Engine) DeleteWord(content string) error {
+	if d.opts.primary != nil {
+		return d.opts.primary.DeleteWord(content)
+	}
+	entry := DictEntry{Content: content, deleted: true}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	snap := d.snap.Load()
+
+	if d.fork != nil && d.fork.parent == nil {
+		return ErrForkPromoted
+	}
+	if !snap.contains(content) {
+		return ErrWordNotFound
+	}
+
+	// 更新前缀树与GSE分词器, 成功后再写入预写日志
+	undo, err := d.applyEntry(snap, entry)
+	if err != nil {
+		return fmt.Errorf("remove token from segmenter fail: %v", err)
+	}
+	mark := d.journalMark()
+	if d.journal != nil {
+		if err := d.journal.append(journalRecord{Op: journalOpDelete, Entry: DictEntry{Content: content}}); err != nil {
+			undo()
+			return fmt.Errorf("write journal fail: %v", err)
 		}
 	}
 
-	return nil
+	// 从数据库删除, 失败时转入待写队列, 队列已满则回滚内存修改
+	if err := d.persist(entry); err != nil {
+		undo()
+		return errors.Join(err, d.rollbackJournal(mark))
+	}
+	d.seq++
+	d.maybeCompact()
+
+	return d.checkpoint()
+}
+
+// LearnFromText 从文本中学习新词汇并返回学习到的新词, 使用WithLearnOptions设置的学习策略, 默认见DefaultLearnOptions
+// 词典中不存在且满足策略的词都会被学习, 需要按统计阈值筛选新词时使用LearnDiscovered
+func (d *Engine) LearnFromText(text string) ([]DictEntry, error) {
+	return d.LearnFromTextWith(text, d.opts.learn)
 }
 
 // containsWord 检查前缀树中是否包含指定的词
 func (d *Engine) containsWord(content string) bool {
-	node := d.root
-	chars := SplitString(content)
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+	return d.snap.Load().contains(content)
+}
 
-	for _, char := range chars {
-		if _, ok := node.Children[char]; !ok {
-			return false
-		}
-		node = node.Children[char]
-	}
-	return node.IsEnd
+// Contains 判断自定义词典中是否包含指定的词, 不包括GSE基础词典
+func (d *Engine) Contains(content string) bool {
+	return d.containsWord(content)
 }
 
-// Segment 对文本进行分词
-func (d *Engine) Segment(text string) []string {
-	return d.segmenter.Cut(text, true)
+// Walk 按字典序遍历自定义词典中的词条, fn返回false时停止, 不包括GSE基础词典
+// 遍历的是调用时的词条副本, fn中可修改词典, 修改不影响本次遍历
+func (d *Engine) Walk(fn func(DictEntry) bool) {
+	d.rw.RLock()
+	entries := collectEntries(d.snap.Load().root)
+	d.rw.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Content < entries[j].Content })
+	for _, entry := range entries {
+		if !fn(entry) {
+			return
+		}
+	}
 }
 
 // Close 关闭词典
+// 降级模式下会先尝试写入待写队列
+// 副本引擎不持有数据库, 关闭时不做任何操作
 func (d *Engine) Close() error {
-	return d.dbEngine.Close()
+	if d.fork != nil {
+		return nil
+	}
+	d.bg.Wait()
+
+	d.mu.Lock()
+	flushErr := d.flushPending()
+	if d.journal != nil {
+		if flushErr == nil {
+			flushErr = d.checkpoint()
+		}
+		d.journal.close()
+	}
+	d.mu.Unlock()
+
+	if err := d.store.Close(); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return fmt.Errorf("flush pending words fail: %v", flushErr)
+	}
+	return nil
 }
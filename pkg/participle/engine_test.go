@@ -0,0 +1,142 @@
+package participle
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// brokenSegmenter AddToken与RemoveToken始终失败的分词器
+type brokenSegmenter struct{ tokenizer }
+
+var errSegmenterBroken = errors.New("segmenter broken")
+
+func (brokenSegmenter) AddToken(string, float64, string) error { return errSegmenterBroken }
+
+func (brokenSegmenter) RemoveToken(string) error { return errSegmenterBroken }
+
+func TestFailedApplyNotJournaled(t *testing.T) {
+	store := &flakyStore{MemoryStore: NewMemoryStore()}
+	journalPath := filepath.Join(t.TempDir(), "dict.journal")
+	broken := WithSegmenter(func() (Segmenter, error) { return brokenSegmenter{}, nil })
+
+	seed, err := NewWithStore(t.Context(), store, WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seed.Close()
+	if err := seed.AddWord("已有词条", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewWithStore(t.Context(), store, WithJournal(journalPath), broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddWord("失败词条", 10, "n"); err == nil {
+		t.Fatal("AddWord succeeded with a broken segmenter")
+	}
+	if err := d.UpdateWord("已有词条", 20, "v"); err == nil {
+		t.Fatal("UpdateWord succeeded with a broken segmenter")
+	}
+	if err := d.DeleteWord("已有词条"); err == nil {
+		t.Fatal("DeleteWord succeeded with a broken segmenter")
+	}
+	// 进程崩溃, 不调用Close
+	d.journal.close()
+
+	restarted, err := NewWithStore(t.Context(), store, WithBaseDict(BaseDictEmpty), WithJournal(journalPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if restarted.Contains("失败词条") {
+		t.Error("failed add replayed from journal")
+	}
+	entry := restarted.snap.Load().lookup("已有词条")
+	if entry == nil {
+		t.Fatal("failed delete replayed from journal")
+	}
+	if entry.Frequency != 10 || entry.Pos != "n" {
+		t.Errorf("failed update replayed from journal: %+v", entry)
+	}
+}
+
+func TestPendingFullNotJournaled(t *testing.T) {
+	store := &flakyStore{MemoryStore: NewMemoryStore()}
+	journalPath := filepath.Join(t.TempDir(), "dict.journal")
+	opts := []Option{WithBaseDict(BaseDictEmpty), WithJournal(journalPath), WithMaxPending(1)}
+
+	d, err := NewWithStore(t.Context(), store, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddWord("已有词条", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一条修改转入待写队列并保留在日志中, 之后的修改因队列已满回滚
+	store.fail.Store(true)
+	if err := d.AddWord("排队词条", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddWord("溢出词条", 10, "n"); !errors.Is(err, ErrPendingFull) {
+		t.Fatalf("AddWord = %v, want ErrPendingFull", err)
+	}
+	if err := d.AddWords([]DictEntry{{Content: "批量词条", Frequency: 10}}); !errors.Is(err, ErrPendingFull) {
+		t.Fatalf("AddWords = %v, want ErrPendingFull", err)
+	}
+	if err := d.UpdateWord("已有词条", 20, "v"); !errors.Is(err, ErrPendingFull) {
+		t.Fatalf("UpdateWord = %v, want ErrPendingFull", err)
+	}
+	if err := d.DeleteWord("已有词条"); !errors.Is(err, ErrPendingFull) {
+		t.Fatalf("DeleteWord = %v, want ErrPendingFull", err)
+	}
+	// 进程崩溃, 不调用Close
+	d.journal.close()
+	store.fail.Store(false)
+
+	restarted, err := NewWithStore(t.Context(), store, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if !restarted.Contains("排队词条") {
+		t.Error("pending add not replayed from journal")
+	}
+	for _, word := range []string{"溢出词条", "批量词条"} {
+		if restarted.Contains(word) {
+			t.Errorf("rejected add %q replayed from journal", word)
+		}
+	}
+	entry := restarted.snap.Load().lookup("已有词条")
+	if entry == nil {
+		t.Fatal("rejected delete replayed from journal")
+	}
+	if entry.Frequency != 10 || entry.Pos != "n" {
+		t.Errorf("rejected update replayed from journal: %+v", entry)
+	}
+}
+
+func TestAddWordsRejectsInvalidBatch(t *testing.T) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	for _, bad := range []string{"", "含\x00字符", "\xff"} {
+		entries := []DictEntry{{Content: "有效词条", Frequency: 10}, {Content: bad, Frequency: 10}}
+		err := d.AddWords(entries)
+		if !errors.Is(err, ErrInvalidWord) {
+			t.Fatalf("AddWords with %q = %v, want ErrInvalidWord", bad, err)
+		}
+		if !strings.Contains(err.Error(), "entry 1") {
+			t.Errorf("AddWords error %q does not name entry 1", err)
+		}
+		if d.Contains("有效词条") {
+			t.Fatalf("AddWords with %q added the valid entries", bad)
+		}
+	}
+}
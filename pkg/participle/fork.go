@@ -0,0 +1,146 @@
+package participle
+
+import "errors"
+
+var (
+	// ErrForkNoDB 副本引擎不持有数据库
+	ErrForkNoDB = errors.New("participle: fork has no database")
+	// ErrForkPromoted 副本已被采纳, 不能继续修改
+	ErrForkPromoted = errors.New("participle: fork has been promoted")
+	// ErrNotFork 引擎不是当前引擎的副本
+	ErrNotFork = errors.New("participle: engine is not a fork of this engine")
+	// ErrStaleFork 创建副本后原引擎词典已发生修改
+	ErrStaleFork = errors.New("participle: parent dictionary changed since fork")
+)
+
+// fork 副本状态
+type fork struct {
+	parent  *Engine        // 原引擎, 采纳后置为nil
+	baseSeq uint64         // 创建副本时原引擎的修改序号
+	changes []DictEntry    // 副本中的修改, 按首次修改顺序排列
+	index   map[string]int // 词条在changes中的位置
+}
+
+// record 记录副本中的修改, 同一词条只保留最后一次修改
+func (f *fork) record(entry DictEntry) {
+	if i, ok := f.index[entry.Content]; ok {
+		f.changes[i] = entry
+		return
+	}
+	f.index[entry.Content] = len(f.changes)
+	f.changes = append(f.changes, entry)
+}
+
+// Fork 创建词典的隔离副本
+// 副本拥有独立的前缀树与分词器, 其修改只存在于内存中, 不会影响原引擎
+// 可用于激进学习、剪枝等试验, 通过原引擎的Promote采纳
+func (d *Engine) Fork() (*Engine, error) {
+	d.mu.Lock()
+	src := d.snap.Load()
+	snap := cloneSnapshot(src, src.version, d.opts)
+	baseSeq := d.seq
+	d.mu.Unlock()
+
+	if err := snap.initSegmenter(d.opts); err != nil {
+		return nil, err
+	}
+
+	f := &Engine{
+		opts:      d.opts,
+		fork:      &fork{parent: d, baseSeq: baseSeq, index: make(map[string]int)},
+		blacklist: d.blacklist,
+	}
+	f.opts.journal = ""
+	f.opts.primary = nil
+	f.snap.Store(snap)
+	return f, nil
+}
+
+// Promote 采纳副本
+// 副本中的修改写入数据库, 副本的词典成为当前词典
+// 创建副本后原引擎若有修改或重载, 返回ErrStaleFork
+// 采纳后副本只读
+func (d *Engine) Promote(f *Engine) error {
+	if f.fork == nil || f.fork.parent != d {
+		return ErrNotFork
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if d.seq != f.fork.baseSeq {
+		return ErrStaleFork
+	}
+
+	// 读写分离时副本中的修改转发到主节点
+	if d.opts.primary != nil {
+		if err := d.forward(f.fork.changes); err != nil {
+			return err
+		}
+		f.fork.parent = nil
+		return nil
+	}
+
+	// 复制副本的词典, 采纳后两个引擎不共享前缀树与分词器, 原引擎的后续修改不会影响副本上进行中的分词
+	snap := d.snap.Load()
+	promoted := cloneSnapshot(f.snap.Load(), snap.version+1, d.opts)
+	if err := promoted.initSegmenter(d.opts); err != nil {
+		return err
+	}
+
+	// 写入副本中的修改, 失败时将已写入部分应用到当前词典, 保持内存与数据库一致
+	for i, entry := range f.fork.changes {
+		mark := d.journalMark()
+		if d.journal != nil {
+			if err := d.journal.append(journalRecordOf(entry)); err != nil {
+				d.applyPromoted(snap, f.fork.changes[:i])
+				return err
+			}
+		}
+		if err := d.persist(entry); err != nil {
+			d.applyPromoted(snap, f.fork.changes[:i])
+			return errors.Join(err, d.rollbackJournal(mark))
+		}
+	}
+
+	d.snap.Store(promoted)
+	d.seq++
+	f.fork.parent = nil
+
+	return d.checkpoint()
+}
+
+// applyPromoted 将已写入数据库的副本修改应用到当前词典
+func (d *Engine) applyPromoted(snap *snapshot, entries []DictEntry) {
+	for _, entry := range entries {
+		d.applyEntry(snap, entry)
+	}
+	if len(entries) > 0 {
+		d.seq++
+	}
+}
+
+// cloneSnapshot 复制快照的前缀树与统计信息, 分词器须另行调用initSegmenter创建
+func cloneSnapshot(src *snapshot, version uint64, o options) *snapshot {
+	snap := newSnapshot(version, o)
+	snap.root = cloneTrie(src.root, snap.arena, snap.childLimit)
+	snap.entries, snap.nodes, snap.bytes = src.entries, src.nodes, src.bytes
+	snap.asciiRoots = src.asciiRoots
+	return snap
+}
+
+// cloneTrie 深拷贝前缀树, 节点从arena分配, limit为子节点使用切片存储的数量上限
+func cloneTrie(node *TrieNode, arena *nodeArena, limit int) *TrieNode {
+	clone := arena.alloc()
+	clone.IsEnd = node.IsEnd
+	if node.Entry != nil {
+		entry := *node.Entry
+		clone.Entry = &entry
+	}
+	for char, child := range node.Children() {
+		clone.setChild(char, cloneTrie(child, arena, limit), limit)
+	}
+	return clone
+}
@@ -0,0 +1,129 @@
+package participle
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyStore 可切换为写入失败的内存存储, Close不清空数据, 用于模拟进程崩溃后以同一存储重启
+type flakyStore struct {
+	*MemoryStore
+	fail atomic.Bool
+}
+
+var errStoreDown = errors.New("store down")
+
+func (s *flakyStore) Set(key, value []byte) error {
+	if s.fail.Load() {
+		return errStoreDown
+	}
+	return s.MemoryStore.Set(key, value)
+}
+
+func (s *flakyStore) Delete(key []byte) error {
+	if s.fail.Load() {
+		return errStoreDown
+	}
+	return s.MemoryStore.Delete(key)
+}
+
+func (s *flakyStore) Update(fn func(tx StoreTxn) error) error {
+	if s.fail.Load() {
+		return errStoreDown
+	}
+	return s.MemoryStore.Update(fn)
+}
+
+func (s *flakyStore) Close() error { return nil }
+
+func TestPromoteDeleteSurvivesCrash(t *testing.T) {
+	store := &flakyStore{MemoryStore: NewMemoryStore()}
+	journalPath := filepath.Join(t.TempDir(), "dict.journal")
+	opts := []Option{WithBaseDict(BaseDictEmpty), WithJournal(journalPath)}
+
+	d, err := NewWithStore(t.Context(), store, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddWord("灰度词条", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := d.Fork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.DeleteWord("灰度词条"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 数据库不可写时删除只存在于预写日志与待写队列中, 随后进程崩溃, 不调用Close
+	store.fail.Store(true)
+	if err := d.Promote(f); err != nil {
+		t.Fatal(err)
+	}
+	if d.Contains("灰度词条") {
+		t.Fatal("word still present after promote")
+	}
+	d.journal.close()
+	store.fail.Store(false)
+
+	restarted, err := NewWithStore(t.Context(), store, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if restarted.Contains("灰度词条") {
+		t.Error("deleted word restored by journal replay")
+	}
+	if _, err := store.Get(restarted.entryKey("灰度词条")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("deleted word still stored, err %v", err)
+	}
+}
+
+func TestPromotedForkIsolated(t *testing.T) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	f, err := d.Fork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddWord("副本词条", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Promote(f); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Contains("副本词条") {
+		t.Fatal("promoted word missing")
+	}
+	if err := f.AddWord("采纳之后", 10, "n"); !errors.Is(err, ErrForkPromoted) {
+		t.Errorf("write to promoted fork: %v, want ErrForkPromoted", err)
+	}
+
+	// 副本上的分词与原引擎的修改并发进行, 两者不共享前缀树
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			f.Segment("副本词条与原引擎词条")
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if err := d.AddWord("原引擎词条", float64(i+1), "n"); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	<-done
+
+	if f.Contains("原引擎词条") {
+		t.Error("parent write visible in promoted fork")
+	}
+}
@@ -0,0 +1,180 @@
+package participle
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FrequencyFormat 词频导出格式
+type FrequencyFormat string
+
+const (
+	FrequencyCSV       FrequencyFormat = "csv"       // CSV格式: word,value
+	FrequencyWordcloud FrequencyFormat = "wordcloud" // 词云JSON: [{"name":..,"value":..}]
+	FrequencySVG       FrequencyFormat = "svg"       // 简单词云SVG图片
+)
+
+// WordFrequency 词频
+type WordFrequency struct {
+	Name  string  `json:"name"`  // 词
+	Value float64 `json:"value"` // 词频或出现次数
+}
+
+// DictionaryFrequencies 获取词典中所有词条的词频, 按词频降序
+func (d *Engine) DictionaryFrequencies() []WordFrequency {
+	var freqs []WordFrequency
+	var walk func(node *TrieNode)
+	walk = func(node *TrieNode) {
+		if node.IsEnd && node.Entry != nil {
+			freqs = append(freqs, WordFrequency{Name: node.Entry.Content, Value: node.Entry.Frequency})
+		}
+		for _, child := range node.Children() {
+			walk(child)
+		}
+	}
+
+	d.mu.Lock()
+	walk(d.snap.Load().root)
+	d.mu.Unlock()
+
+	sortFrequencies(freqs)
+	return freqs
+}
+
+// CorpusFrequencies 对语料分词并统计词出现次数, 按次数降序
+// 空白与特殊符号不计入统计; 设置了WithExportPrivacy时次数经加噪与阈值处理
+func (d *Engine) CorpusFrequencies(texts []string) []WordFrequency {
+	counts := make(map[string]float64)
+	for _, text := range texts {
+		for _, content := range d.Segment(text) {
+			content = strings.TrimSpace(content)
+			if content == "" || IsAllPunct(content) {
+				continue
+			}
+			counts[content]++
+		}
+	}
+
+	freqs := make([]WordFrequency, 0, len(counts))
+	for name, value := range counts {
+		freqs = append(freqs, WordFrequency{Name: name, Value: value})
+	}
+	if d.opts.exportPrivacy.enabled() {
+		return d.opts.exportPrivacy.Apply(freqs)
+	}
+	sortFrequencies(freqs)
+	return freqs
+}
+
+// sortFrequencies 按词频降序排序, 词频相同时按词排序
+func sortFrequencies(freqs []WordFrequency) {
+	sort.Slice(freqs, func(i, j int) bool {
+		if freqs[i].Value != freqs[j].Value {
+			return freqs[i].Value > freqs[j].Value
+		}
+		return freqs[i].Name < freqs[j].Name
+	})
+}
+
+// EncodeFrequencies 按格式编码词频
+func EncodeFrequencies(freqs []WordFrequency, format FrequencyFormat) ([]byte, error) {
+	switch format {
+	case FrequencyCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"word", "value"})
+		for _, f := range freqs {
+			w.Write([]string{f.Name, strconv.FormatFloat(f.Value, 'f', -1, 64)})
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	case FrequencyWordcloud:
+		if freqs == nil {
+			freqs = []WordFrequency{}
+		}
+		return json.Marshal(freqs)
+	case FrequencySVG:
+		return renderWordcloudSVG(freqs), nil
+	default:
+		return nil, fmt.Errorf("unknown frequency format: %s", format)
+	}
+}
+
+const (
+	svgWidth       = 800 // 图片宽度
+	svgMinFontSize = 12  // 最小字号
+	svgMaxFontSize = 64  // 最大字号
+	svgPadding     = 8   // 词间距
+)
+
+// renderWordcloudSVG 按词频由高到低逐行排布词语, 字号按词频线性缩放
+func renderWordcloudSVG(freqs []WordFrequency) []byte {
+	minValue, maxValue := 0.0, 0.0
+	for i, f := range freqs {
+		if i == 0 || f.Value < minValue {
+			minValue = f.Value
+		}
+		if i == 0 || f.Value > maxValue {
+			maxValue = f.Value
+		}
+	}
+
+	type word struct {
+		name    string
+		x, size int
+	}
+	var body bytes.Buffer
+	var line []word
+	x, y, lineHeight := svgPadding, svgPadding, 0
+	// flush 输出当前行, 同一行的词共用底部基线
+	flush := func() {
+		for _, w := range line {
+			fmt.Fprintf(&body, `<text x="%d" y="%d" font-size="%d">%s</text>`+"\n", w.x, y+lineHeight, w.size, html.EscapeString(w.name))
+		}
+		if len(line) > 0 {
+			y += lineHeight + svgPadding
+		}
+		x, lineHeight, line = svgPadding, 0, line[:0]
+	}
+
+	for _, f := range freqs {
+		size := svgMaxFontSize
+		if maxValue > minValue {
+			size = svgMinFontSize + int((f.Value-minValue)/(maxValue-minValue)*(svgMaxFontSize-svgMinFontSize))
+		}
+		width := textWidth(f.Name, size)
+		if x+width > svgWidth-svgPadding && len(line) > 0 {
+			flush()
+		}
+		line = append(line, word{name: f.Name, x: x, size: size})
+		lineHeight = max(lineHeight, size)
+		x += width + svgPadding
+	}
+	flush()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", svgWidth, y)
+	buf.Write(body.Bytes())
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// textWidth 估算文字宽度: 全角字符按一个字号, 半角字符按0.6个字号
+func textWidth(s string, size int) int {
+	width := 0.0
+	for _, r := range s {
+		if utf8.RuneLen(r) > 1 {
+			width += float64(size)
+		} else {
+			width += float64(size) * 0.6
+		}
+	}
+	return int(width)
+}
@@ -0,0 +1,104 @@
+package participle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fuzzSeeds 分词相关模糊测试的初始语料: 非法UTF-8、超长输入与连续标点
+var fuzzSeeds = []string{
+	"",
+	"南京市长江大桥",
+	"Hello, 世界! https://example.com/a?b=1 13800138000",
+	"\xff\xfe\xfd",
+	"中\xe4\xb8文",
+	"İİ",
+	"\xe5桥",
+	"\xef",
+	"Straße ΣΑΣ İstanbul和Ǆ",
+	"！！！。。。，，，？？？……——",
+	strings.Repeat("（【《", 200),
+	strings.Repeat("分词", 1024),
+	strings.Repeat("a", 1<<13),
+}
+
+func FuzzSegment(f *testing.F) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer d.Close()
+	if err := d.AddWord("长江大桥", 10, "ns"); err != nil {
+		f.Fatal(err)
+	}
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, token := range d.Segment(text) {
+			if token == "" {
+				t.Fatalf("empty token in %q", text)
+			}
+		}
+		d.SegmentAll(text)
+		d.SegmentSearch(text)
+		d.SegmentPos(text)
+		tokens, err := d.SegmentWithOffsets(text)
+		if err != nil {
+			t.Fatalf("offsets of %q: %v", text, err)
+		}
+		prev := 0
+		for _, token := range tokens {
+			if token.Start < prev || token.Start > token.End || token.End > len(text) {
+				t.Fatalf("token %q offsets [%d,%d) out of range in %q", token.Text, token.Start, token.End, text)
+			}
+			if token.RuneStart != utf8.RuneCountInString(text[:token.Start]) || token.RuneEnd != utf8.RuneCountInString(text[:token.End]) {
+				t.Fatalf("token %q rune offsets [%d,%d) disagree with bytes [%d,%d) in %q", token.Text, token.RuneStart, token.RuneEnd, token.Start, token.End, text)
+			}
+			prev = token.End
+		}
+	})
+}
+
+// maxFuzzWordRunes FuzzAddWord添加的词的最大字符数
+const maxFuzzWordRunes = 64
+
+func FuzzAddWord(f *testing.F) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer d.Close()
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, 1.0, "n")
+	}
+	f.Add("词", -1.0, "")
+	f.Add("\x00！B\xe2\x03", 1.0, "n")
+	f.Add(" 前后空白 ", 1e308, "\x00")
+
+	f.Fuzz(func(t *testing.T, content string, frequency float64, pos string) {
+		// GSE构建有向无环图时对每个位置逐字拼接前缀查词, 词典中有长词时分词耗时随词长平方增长, 限制词长以免单次执行超时
+		if utf8.RuneCountInString(content) > maxFuzzWordRunes {
+			t.Skip()
+		}
+		if err := d.AddWord(content, frequency, pos); err != nil {
+			if validWord(content) || !errors.Is(err, ErrInvalidWord) {
+				t.Fatalf("add %q: %v", content, err)
+			}
+			return
+		}
+		if !d.Contains(content) {
+			t.Fatalf("added word %q not found", content)
+		}
+		d.Segment(content)
+		if err := d.DeleteWord(content); err != nil {
+			t.Fatalf("delete %q: %v", content, err)
+		}
+		if d.Contains(content) {
+			t.Fatalf("deleted word %q still found", content)
+		}
+	})
+}
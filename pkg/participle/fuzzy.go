@@ -0,0 +1,65 @@
+package participle
+
+import "sort"
+
+// FuzzyMatch 模糊查找结果
+type FuzzyMatch struct {
+	Entry    DictEntry `json:"entry"`    // 词条
+	Distance int       `json:"distance"` // 与查找词的编辑距离
+}
+
+// FuzzySearch 查找自定义词典中与word的编辑距离不超过maxDistance的词条, 编辑距离按字计算
+// 结果按编辑距离升序, 距离相同时按词频降序; 可用于分词前纠正OCR、语音识别中的错字
+// 沿前缀树逐字计算编辑距离矩阵的一行, 当前行的最小值超过maxDistance时剪去整棵子树
+func (d *Engine) FuzzySearch(word string, maxDistance int) []FuzzyMatch {
+	if maxDistance < 0 {
+		return nil
+	}
+	target := []rune(word)
+
+	// 第一行: 空前缀到word各前缀的距离
+	first := make([]int, len(target)+1)
+	for i := range first {
+		first[i] = i
+	}
+
+	var matches []FuzzyMatch
+	var walk func(node *TrieNode, prev []int)
+	walk = func(node *TrieNode, prev []int) {
+		if node.IsEnd && node.Entry != nil && prev[len(target)] <= maxDistance {
+			matches = append(matches, FuzzyMatch{Entry: *node.Entry, Distance: prev[len(target)]})
+		}
+		for char, child := range node.Children() {
+			r := []rune(char)[0]
+			row := make([]int, len(target)+1)
+			row[0] = prev[0] + 1
+			best := row[0]
+			for i := 1; i <= len(target); i++ {
+				cost := 1
+				if target[i-1] == r {
+					cost = 0
+				}
+				row[i] = min(row[i-1]+1, prev[i]+1, prev[i-1]+cost)
+				best = min(best, row[i])
+			}
+			if best <= maxDistance {
+				walk(child, row)
+			}
+		}
+	}
+
+	d.rw.RLock()
+	walk(d.snap.Load().root, first)
+	d.rw.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		if matches[i].Entry.Frequency != matches[j].Entry.Frequency {
+			return matches[i].Entry.Frequency > matches[j].Entry.Frequency
+		}
+		return matches[i].Entry.Content < matches[j].Entry.Content
+	})
+	return matches
+}
@@ -0,0 +1,147 @@
+package participle
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// historyKeyPrefix 词频时间序列的键前缀, 键为前缀+词+"\x00"+时间段起点(Unix秒, 大端序)
+const historyKeyPrefix = MetaKeyPrefix + "history:"
+
+// defaultHistoryBucket 词频时间序列默认的时间段长度
+const defaultHistoryBucket = 24 * time.Hour
+
+// FrequencyPoint 词在一个时间段内的出现次数
+type FrequencyPoint struct {
+	Time  time.Time `json:"time"`  // 时间段起点
+	Count uint64    `json:"count"` // 出现次数
+}
+
+// WithHistoryBucket 设置词频时间序列的时间段长度, 默认为一天, 时间段按UTC对齐
+// 修改长度后已记录的数据保持原时间段, 查询时按各自的起点返回
+func WithHistoryBucket(bucket time.Duration) Option {
+	return func(o *options) { o.historyBucket = bucket }
+}
+
+// historyKey 词在时间段中的计数键
+func historyKey(word string, bucket time.Time) []byte {
+	key := append([]byte(historyKeyPrefix+word), 0)
+	return binary.BigEndian.AppendUint64(key, uint64(bucket.Unix()))
+}
+
+// RecordFrequencies 将文档中各词的出现次数计入at所在时间段, 计入的词与关键词抽取一致
+// 用于跟踪词频随时间的变化, 如发现热词、审核词条时参考其使用趋势
+func (d *Engine) RecordFrequencies(doc string, at time.Time) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+
+	counts := make(map[string]uint64)
+	for _, term := range d.keywordTerms(doc) {
+		counts[term]++
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	bucket := at.UTC().Truncate(d.opts.historyBucket)
+	// 串行化计数更新, 避免并发事务冲突
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+	return d.store.Update(func(tx StoreTxn) error {
+		for term, n := range counts {
+			if err := addCount(tx, historyKey(term, bucket), n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FrequencyHistory 获取词在起点位于[from, to)内的各时间段的出现次数, 按时间升序, 没有出现的时间段不返回
+// from或to为零值表示不限制
+func (d *Engine) FrequencyHistory(word string, from, to time.Time) ([]FrequencyPoint, error) {
+	if d.fork != nil {
+		return nil, ErrForkNoDB
+	}
+
+	var points []FrequencyPoint
+	prefix := append([]byte(historyKeyPrefix+word), 0)
+	err := d.store.Iterate(prefix, func(key, value []byte) error {
+		point := FrequencyPoint{
+			Time:  time.Unix(int64(binary.BigEndian.Uint64(key[len(prefix):])), 0).UTC(),
+			Count: binary.BigEndian.Uint64(value),
+		}
+		if inRange(point.Time, from, to) {
+			points = append(points, point)
+		}
+		return nil
+	})
+	return points, err
+}
+
+// ExportFrequencyHistory 以CSV格式导出词频时间序列, 列为word,time,count, 按词与时间排序
+// words为空时导出所有词; 时间段的选取同FrequencyHistory
+// 设置了WithExportPrivacy时每个时间段的次数单独加噪, 低于阈值的行不导出
+func (d *Engine) ExportFrequencyHistory(w io.Writer, from, to time.Time, words ...string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"word", "time", "count"})
+	write := func(key, value []byte) error {
+		// 键尾为"\x00"与8字节的时间段起点
+		rest := key[len(historyKeyPrefix):]
+		i := len(rest) - 9
+		if i < 0 || rest[i] != 0 {
+			return nil
+		}
+		at := time.Unix(int64(binary.BigEndian.Uint64(rest[i+1:])), 0).UTC()
+		if !inRange(at, from, to) {
+			return nil
+		}
+		count := binary.BigEndian.Uint64(value)
+		if privacy := d.opts.exportPrivacy; privacy.enabled() {
+			noisy, ok := privacy.noisy(float64(count))
+			if !ok {
+				return nil
+			}
+			count = uint64(noisy)
+		}
+		return cw.Write([]string{string(rest[:i]), at.Format(time.RFC3339), strconv.FormatUint(count, 10)})
+	}
+
+	if len(words) == 0 {
+		if err := d.store.Iterate([]byte(historyKeyPrefix), write); err != nil {
+			return err
+		}
+	} else {
+		words = append([]string(nil), words...)
+		sort.Strings(words)
+		for _, word := range words {
+			if err := d.store.Iterate(append([]byte(historyKeyPrefix+word), 0), write); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ResetFrequencyHistory 清空词频时间序列
+func (d *Engine) ResetFrequencyHistory() error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	return dropPrefix(d.store, []byte(historyKeyPrefix))
+}
+
+// inRange 判断时间是否在[from, to)内, 零值表示不限制
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
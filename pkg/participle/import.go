@@ -0,0 +1,287 @@
+package participle
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Severity 导入问题的严重程度
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // 警告: 词条被跳过, 不影响其余词条
+	SeverityError                   // 错误: 该行无法解析
+)
+
+// String 严重程度名称
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ImportIssue 导入时被跳过的行
+type ImportIssue struct {
+	Line     int      `json:"line"`     // 行号, 从1开始
+	Content  string   `json:"content"`  // 原始行内容
+	Reason   string   `json:"reason"`   // 跳过原因
+	Severity Severity `json:"severity"` // 严重程度
+}
+
+// ImportReport 词典导入报告
+type ImportReport struct {
+	Lines    int           `json:"lines"`    // 读取的行数(不含空行与注释)
+	Added    int           `json:"added"`    // 成功添加的词条数
+	Warnings int           `json:"warnings"` // 警告数
+	Errors   int           `json:"errors"`   // 错误数
+	Issues   []ImportIssue `json:"issues"`   // 被跳过的行
+}
+
+// skip 记录一条被跳过的行
+func (r *ImportReport) skip(line int, content string, severity Severity, reason string) {
+	r.Issues = append(r.Issues, ImportIssue{Line: line, Content: content, Reason: reason, Severity: severity})
+	if severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// validPos GSE使用的词性标签
+var validPos = map[string]bool{
+	"a": true, "ad": true, "ag": true, "an": true, "b": true, "c": true, "d": true, "df": true, "dg": true,
+	"e": true, "eng": true, "f": true, "g": true, "h": true, "i": true, "j": true, "k": true, "l": true,
+	"m": true, "mg": true, "mq": true, "n": true, "ng": true, "nr": true, "nrfg": true, "nrt": true,
+	"ns": true, "nt": true, "nz": true, "o": true, "p": true, "q": true, "r": true, "rg": true, "rr": true,
+	"rz": true, "s": true, "t": true, "tg": true, "u": true, "ud": true, "ug": true, "uj": true, "ul": true,
+	"uv": true, "uz": true, "v": true, "vd": true, "vg": true, "vi": true, "vn": true, "vq": true,
+	"x": true, "y": true, "z": true, "zg": true,
+}
+
+// ImportDict 按GSE词典格式导入词条, 每行为"词条 [词频] [词性]", 以#开头的行为注释
+// 重复词条、格式错误或词性无效的行会被跳过并记录到报告中, 不会中断导入
+// 读取失败或添加词条失败(如超出资源限制)时中止导入, 并返回已生成的报告
+func (d *Engine) ImportDict(r io.Reader) (*ImportReport, error) {
+	report := &ImportReport{}
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		report.Lines++
+
+		entry, err := parseDictLine(line)
+		if err != nil {
+			report.skip(lineNo, line, SeverityError, err.Error())
+			continue
+		}
+		if prev, ok := seen[entry.Content]; ok {
+			report.skip(lineNo, line, SeverityWarning, fmt.Sprintf("duplicate of line %d", prev))
+			continue
+		}
+		seen[entry.Content] = lineNo
+		if d.containsWord(entry.Content) {
+			report.skip(lineNo, line, SeverityWarning, "already in dictionary")
+			continue
+		}
+
+		if err := d.addEntry(entry); err != nil {
+			return report, fmt.Errorf("import line %d fail: %v", lineNo, err)
+		}
+		report.Added++
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("read dict fail: %v", err)
+	}
+	return report, nil
+}
+
+// parseDictLine 解析一行GSE格式词典, 缺省词频为1000.0, 缺省词性为"nz"
+func parseDictLine(line string) (DictEntry, error) {
+	return parseDictFields(strings.Fields(line))
+}
+
+// parseDictFields 解析"词条 [词频] [词性]"字段, 词频或词性缺省或为空时取1000.0与"nz"
+func parseDictFields(fields []string) (DictEntry, error) {
+	if len(fields) > 3 {
+		return DictEntry{}, fmt.Errorf("too many fields: %d", len(fields))
+	}
+	if len(fields) == 0 || fields[0] == "" {
+		return DictEntry{}, errors.New("empty word")
+	}
+
+	entry := DictEntry{Content: fields[0], Frequency: 1000.0, Pos: "nz"}
+	if len(fields) > 1 && fields[1] != "" {
+		freq, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || freq <= 0 {
+			return DictEntry{}, fmt.Errorf("invalid frequency %q", fields[1])
+		}
+		entry.Frequency = freq
+	}
+	if len(fields) > 2 && fields[2] != "" {
+		if !validPos[fields[2]] {
+			return DictEntry{}, fmt.Errorf("invalid pos %q", fields[2])
+		}
+		entry.Pos = fields[2]
+	}
+	return entry, nil
+}
+
+// DictFormat 词典文件格式
+type DictFormat string
+
+const (
+	DictText DictFormat = "text" // jieba/GSE文本格式: 每行"词条 [词频] [词性]", 以#开头的行为注释
+	DictCSV  DictFormat = "csv"  // CSV格式: word,freq,pos, 首行为word或content开头时视为表头
+	DictJSON DictFormat = "json" // JSON数组: [{"content":..,"frequency":..,"pos":..}]
+)
+
+// ErrDictFormat 不支持的词典文件格式
+var ErrDictFormat = errors.New("participle: unsupported dict format")
+
+// importBatchSize 导入词典文件时每批写入的词条数
+const importBatchSize = 5000
+
+// dictRecord 从词典文件读出的一条词条及其位置
+type dictRecord struct {
+	line    int      // 行号, JSON格式为数组下标, 从1开始
+	content string   // 原始内容
+	fields  []string // 词条、词频、词性字段
+}
+
+// ImportDictFile 导入jieba/GSE文本、CSV或JSON数组格式的词典文件
+// 词条按批写入数据库, 每批在一次操作中同时加载到前缀树与GSE分词器, 适合导入大词典
+// 重复词条、已存在的词、格式错误或词性无效的词条会被跳过并记录到报告中; 写入失败时中止导入, 已写入的批次保留
+func (d *Engine) ImportDictFile(path string, format DictFormat) (*ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []dictRecord
+	switch format {
+	case DictText:
+		records, err = readDictText(file)
+	case DictCSV:
+		records, err = readDictCSV(file)
+	case DictJSON:
+		records, err = readDictJSON(file)
+	default:
+		return nil, ErrDictFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dict file fail: %v", err)
+	}
+
+	report := &ImportReport{Lines: len(records)}
+	seen := make(map[string]int)
+	batch := make([]DictEntry, 0, min(len(records), importBatchSize))
+	for _, record := range records {
+		entry, err := parseDictFields(record.fields)
+		if err != nil {
+			report.skip(record.line, record.content, SeverityError, err.Error())
+			continue
+		}
+		if prev, ok := seen[entry.Content]; ok {
+			report.skip(record.line, record.content, SeverityWarning, fmt.Sprintf("duplicate of line %d", prev))
+			continue
+		}
+		seen[entry.Content] = record.line
+		if d.containsWord(entry.Content) {
+			report.skip(record.line, record.content, SeverityWarning, "already in dictionary")
+			continue
+		}
+
+		batch = append(batch, entry)
+		if len(batch) == importBatchSize {
+			if err := d.AddWords(batch); err != nil {
+				return report, fmt.Errorf("import batch ending at line %d fail: %v", record.line, err)
+			}
+			report.Added += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := d.AddWords(batch); err != nil {
+		return report, fmt.Errorf("import last batch fail: %v", err)
+	}
+	report.Added += len(batch)
+	return report, nil
+}
+
+// readDictText 读取文本格式词典, 跳过空行与注释
+func readDictText(r io.Reader) ([]dictRecord, error) {
+	var records []dictRecord
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		records = append(records, dictRecord{line: lineNo, content: line, fields: strings.Fields(line)})
+	}
+	return records, scanner.Err()
+}
+
+// readDictCSV 读取CSV格式词典, 跳过表头、空行与注释
+func readDictCSV(r io.Reader) ([]dictRecord, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var records []dictRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(records) == 0 && (strings.EqualFold(fields[0], "word") || strings.EqualFold(fields[0], "content")) {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+		records = append(records, dictRecord{line: line, content: strings.Join(fields, ","), fields: fields})
+	}
+}
+
+// readDictJSON 读取JSON数组格式词典, 词频为0或词性为空时取缺省值
+func readDictJSON(r io.Reader) ([]dictRecord, error) {
+	var entries []DictEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	records := make([]dictRecord, len(entries))
+	for i, entry := range entries {
+		fields := []string{strings.TrimSpace(entry.Content), "", entry.Pos}
+		if entry.Frequency != 0 {
+			fields[1] = strconv.FormatFloat(entry.Frequency, 'f', -1, 64)
+		}
+		content, _ := json.Marshal(entry)
+		records[i] = dictRecord{line: i + 1, content: string(content), fields: fields}
+	}
+	return records, nil
+}
@@ -0,0 +1,135 @@
+package participle
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jobKeyPrefix 语料任务进度的键前缀
+const jobKeyPrefix = MetaKeyPrefix + "job:"
+
+// checkpointEvery 语料任务每处理多少篇文档保存一次进度
+const checkpointEvery = 100
+
+// JobProgress 语料任务进度
+type JobProgress struct {
+	Job       string    `json:"job"`        // 任务名称
+	Offset    int64     `json:"offset"`     // 已处理内容的字节偏移
+	Documents int64     `json:"documents"`  // 已处理的文档数
+	Done      bool      `json:"done"`       // 是否已完成
+	UpdatedAt time.Time `json:"updated_at"` // 进度更新时间
+}
+
+// Progress 获取语料任务进度, 任务不存在时返回零值进度
+func (d *Engine) Progress(job string) (JobProgress, error) {
+	if d.fork != nil {
+		return JobProgress{}, ErrForkNoDB
+	}
+	progress := JobProgress{Job: job}
+	data, err := d.store.Get([]byte(jobKeyPrefix + job))
+	if errors.Is(err, ErrKeyNotFound) {
+		return progress, nil
+	}
+	if err != nil {
+		return progress, err
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return progress, fmt.Errorf("decode job progress fail: %v", err)
+	}
+	return progress, nil
+}
+
+// ResetJob 清除语料任务进度, 下次运行从头开始
+func (d *Engine) ResetJob(job string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	return d.store.Delete([]byte(jobKeyPrefix + job))
+}
+
+// saveProgress 保存语料任务进度
+func (d *Engine) saveProgress(progress JobProgress) error {
+	progress.UpdatedAt = time.Now()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return d.store.Set([]byte(jobKeyPrefix+progress.Job), data)
+}
+
+// RunCorpusJob 逐行处理语料, 每行为一篇文档, 进度保存在数据库中
+// 任务中断后以相同名称再次运行时从上次保存的进度继续; 已完成的任务直接返回, 需重新运行时先调用ResetJob
+// r实现io.Seeker时按字节偏移跳转, 否则逐行跳过已处理的文档
+func (d *Engine) RunCorpusJob(ctx context.Context, job string, r io.Reader, process func(doc string) error) error {
+	progress, err := d.Progress(job)
+	if err != nil {
+		return err
+	}
+	if progress.Done {
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(progress.Offset, io.SeekStart); err != nil {
+			return fmt.Errorf("seek corpus fail: %v", err)
+		}
+		br.Reset(r)
+	} else {
+		for i := int64(0); i < progress.Documents; i++ {
+			if _, err := br.ReadString('\n'); err != nil {
+				return fmt.Errorf("skip processed documents fail: %v", err)
+			}
+		}
+	}
+
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			if saveErr := d.saveProgress(progress); saveErr != nil {
+				return fmt.Errorf("save job progress fail: %v", saveErr)
+			}
+			return err
+		}
+
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("read corpus fail: %v", readErr)
+		}
+		if line != "" {
+			if doc := strings.TrimSpace(line); doc != "" {
+				if err := process(doc); err != nil {
+					if saveErr := d.saveProgress(progress); saveErr != nil {
+						return fmt.Errorf("save job progress fail: %v", saveErr)
+					}
+					return fmt.Errorf("process document %d fail: %v", progress.Documents, err)
+				}
+			}
+			progress.Offset += int64(len(line))
+			progress.Documents++
+		}
+		if readErr == io.EOF {
+			progress.Done = true
+			return d.saveProgress(progress)
+		}
+
+		if n%checkpointEvery == 0 {
+			if err := d.saveProgress(progress); err != nil {
+				return fmt.Errorf("save job progress fail: %v", err)
+			}
+		}
+	}
+}
+
+// LearnFromCorpus 从语料中学习新词, 每行为一篇文档, 支持中断后继续, 见RunCorpusJob
+func (d *Engine) LearnFromCorpus(ctx context.Context, job string, r io.Reader) error {
+	return d.RunCorpusJob(ctx, job, r, func(doc string) error {
+		_, err := d.LearnFromTextCtx(ctx, doc)
+		return err
+	})
+}
@@ -0,0 +1,143 @@
+package participle
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TokenPattern 合并规则中匹配单个词的条件, 设置的各项须同时满足, 未设置的项不做限制
+type TokenPattern struct {
+	Types  []TokenType    // 类型, 满足其一即可
+	Pos    []string       // 词性前缀, 满足其一即可, 如"nr"可匹配"nr"、"nrt"与"nrfg"
+	Words  []string       // 词, 满足其一即可
+	Regexp *regexp.Regexp // 词须匹配的正则表达式
+	Repeat bool           // 匹配连续的一个或多个词
+}
+
+// JoinRule 词合并规则: 相邻的词依次满足Pattern中的各项条件时合并为一个词
+// 合并后的词类型取第一个词的类型, 词性为Pos, Pos为空时取第一个词的词性
+type JoinRule struct {
+	Name    string         // 规则名称
+	Pattern []TokenPattern // 依次匹配的条件
+	Pos     string         // 合并后的词性
+}
+
+var (
+	// JoinNumberUnit 数字与量词合并, 如"3"+"个"合并为"3个", "12.5"+"元"合并为"12.5元"
+	JoinNumberUnit = JoinRule{
+		Name: "number-unit",
+		Pattern: []TokenPattern{
+			{Types: []TokenType{TokenNumber}},
+			{Pos: []string{"q", "m"}, Regexp: regexp.MustCompile(`^\p{Han}{1,2}$`)},
+		},
+		Pos: "mq",
+	}
+
+	// JoinPersonName 姓氏与名字合并, 如"欧阳"+"娜娜"合并为"欧阳娜娜"
+	JoinPersonName = JoinRule{
+		Name: "person-name",
+		Pattern: []TokenPattern{
+			{Words: commonSurnames},
+			{Pos: []string{"nr"}, Regexp: regexp.MustCompile(`^\p{Han}{1,2}$`)},
+		},
+		Pos: "nr",
+	}
+
+	// JoinRegion 连续的行政区划合并, 如"广东省"+"深圳市"+"南山区"合并为"广东省深圳市南山区"
+	JoinRegion = JoinRule{
+		Name: "region",
+		Pattern: []TokenPattern{
+			{Pos: []string{"ns"}, Regexp: regionSuffix},
+			{Pos: []string{"ns"}, Regexp: regionSuffix, Repeat: true},
+		},
+		Pos: "ns",
+	}
+
+	// DefaultJoinRules 默认的词合并规则
+	DefaultJoinRules = []JoinRule{JoinNumberUnit, JoinPersonName, JoinRegion}
+)
+
+// regionSuffix 行政区划名称的后缀
+var regionSuffix = regexp.MustCompile(`(?:省|市|区|县|自治区|自治州|州|盟|旗|镇|乡|街道)$`)
+
+// commonSurnames 常见复姓与分词器常单独切出的单姓
+var commonSurnames = append(
+	[]string{"欧阳", "司马", "上官", "诸葛", "东方", "皇甫", "尉迟", "公孙", "慕容", "长孙", "宇文", "司徒", "夏侯", "令狐"},
+	strings.Split("王 李 张 刘 陈 杨 黄 赵 吴 周 徐 孙 马 朱 胡 郭 何 高 林 罗 郑 梁 谢 宋 唐 许 韩 冯 邓 曹 彭 曾 肖 田 董 袁 潘 蒋 蔡 余 杜 叶 程 苏 魏 吕 丁 任 沈 姚 卢 姜 崔 钟 谭 陆 汪 范 金 石 廖 贾 夏 韦 方 白 邹 孟 熊 秦 邱 江 尹 薛 闫 段 雷 侯 龙 史 陶 黎 贺 顾 毛 郝 龚 邵 万 钱 严 武 戴 莫 孔 汤 常 温 康 施", " ")...,
+)
+
+// WithJoinRules 分词后按规则合并相邻的词, 规则按顺序尝试, 同一位置取第一个匹配的规则
+// 仅对SegmentPos生效, 规则可依据其标注的词性; 其他分词结果可使用JoinTokens合并
+func WithJoinRules(rules ...JoinRule) SegmentOption {
+	return func(o *segmentOptions) { o.joinRules = append(o.joinRules, rules...) }
+}
+
+// JoinTokens 按规则合并相邻的词, 规则按顺序尝试, 同一位置取第一个匹配的规则
+// 合并后的词在原文中的位置取首尾两个词的位置, 词频为0
+func JoinTokens(tokens []Token, rules []JoinRule) []Token {
+	if len(rules) == 0 {
+		return tokens
+	}
+
+	result := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		end, rule := i+1, -1
+		for r := range rules {
+			if n := rules[r].match(tokens[i:]); n > 1 {
+				end, rule = i+n, r
+				break
+			}
+		}
+		if rule < 0 {
+			result = append(result, tokens[i])
+			i++
+			continue
+		}
+
+		joined := tokens[i]
+		last := tokens[end-1]
+		var text strings.Builder
+		for _, token := range tokens[i:end] {
+			text.WriteString(token.Text)
+		}
+		joined.Text = text.String()
+		if rules[rule].Pos != "" {
+			joined.Pos = rules[rule].Pos
+		}
+		joined.Frequency, joined.Learned = 0, false
+		joined.End, joined.RuneEnd = last.End, last.RuneEnd
+		result = append(result, joined)
+		i = end
+	}
+	return result
+}
+
+// match 返回从tokens开头匹配规则的词数, 不匹配时返回0
+func (r JoinRule) match(tokens []Token) int {
+	n := 0
+	for _, p := range r.Pattern {
+		if n >= len(tokens) || !p.match(tokens[n]) {
+			return 0
+		}
+		n++
+		for p.Repeat && n < len(tokens) && p.match(tokens[n]) {
+			n++
+		}
+	}
+	return n
+}
+
+// match 判断词是否满足条件
+func (p TokenPattern) match(token Token) bool {
+	if len(p.Types) > 0 && !slices.Contains(p.Types, token.Type) {
+		return false
+	}
+	if len(p.Pos) > 0 && !slices.ContainsFunc(p.Pos, func(pos string) bool { return strings.HasPrefix(token.Pos, pos) }) {
+		return false
+	}
+	if len(p.Words) > 0 && !slices.Contains(p.Words, token.Text) {
+		return false
+	}
+	return p.Regexp == nil || p.Regexp.MatchString(token.Text)
+}
@@ -0,0 +1,124 @@
+package participle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+const (
+	journalOpAdd    = "add"    // 新增或覆盖词条
+	journalOpDelete = "delete" // 删除词条
+)
+
+// journalRecord 日志记录
+type journalRecord struct {
+	Op    string    `json:"op"`    // 操作类型
+	Entry DictEntry `json:"entry"` // 词条
+}
+
+// journalRecordOf 词条对应的日志记录, 已删除的词条记为删除
+// deleted不参与序列化, 须通过Op区分, 否则重放时会把删除还原为新增
+func journalRecordOf(entry DictEntry) journalRecord {
+	if entry.deleted {
+		return journalRecord{Op: journalOpDelete, Entry: DictEntry{Content: entry.Content}}
+	}
+	return journalRecord{Op: journalOpAdd, Entry: entry}
+}
+
+// journal 词典修改预写日志
+// 修改应用到内存后追加并落盘, 数据库写入失败且无法转入待写队列时截断回追加前的长度,
+// 待写队列清空后整体清空; 启动时日志非空说明上次未正常关闭, 需要重放
+type journal struct {
+	f    *os.File
+	size int64 // 已写入的完整记录长度
+}
+
+// openJournal 打开预写日志并读取未完成的记录
+// 损坏或末尾被截断的记录及其后的内容视为未写入, 截断丢弃后再追加新记录
+func openJournal(path string) (*journal, []journalRecord, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []journalRecord
+	var size int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(bytes.TrimSuffix(line, []byte("\n")), &rec); err != nil {
+			break
+		}
+		records = append(records, rec)
+		size += int64(len(line))
+	}
+
+	j := &journal{f: f, size: size}
+	if err := j.truncate(size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return j, records, nil
+}
+
+// append 追加一条记录并同步到磁盘
+func (j *journal) append(rec journalRecord) error {
+	return j.appendAll([]journalRecord{rec})
+}
+
+// appendAll 追加多条记录, 全部写入后同步一次磁盘
+func (j *journal) appendAll(recs []journalRecord) error {
+	var buf []byte
+	for _, rec := range recs {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+	// 写入不完整时截断残留的部分记录, 避免之后追加的记录与其连在一行
+	if _, err := j.f.Write(buf); err != nil {
+		j.truncate(j.size)
+		return err
+	}
+	if err := j.f.Sync(); err != nil {
+		j.truncate(j.size)
+		return err
+	}
+	j.size += int64(len(buf))
+	return nil
+}
+
+// truncate 将日志截断到size并同步到磁盘, 丢弃其后追加的记录
+func (j *journal) truncate(size int64) error {
+	if err := j.f.Truncate(size); err != nil {
+		return err
+	}
+	j.size = size
+	return j.f.Sync()
+}
+
+// reset 清空日志
+func (j *journal) reset() error {
+	if err := j.truncate(0); err != nil {
+		return err
+	}
+	_, err := j.f.Seek(0, 0)
+	return err
+}
+
+// close 关闭日志
+func (j *journal) close() error {
+	return j.f.Close()
+}
@@ -0,0 +1,51 @@
+package participle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.journal")
+	j, _, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.append(journalRecord{Op: journalOpAdd, Entry: DictEntry{Content: "完整词条"}}); err != nil {
+		t.Fatal(err)
+	}
+	j.close()
+
+	// 崩溃时写了一半的记录, 没有换行结尾
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"op":"add","entry":{"content":"半条`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	j, records, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Entry.Content != "完整词条" {
+		t.Fatalf("records = %+v, want the complete record only", records)
+	}
+	if err := j.append(journalRecord{Op: journalOpDelete, Entry: DictEntry{Content: "新词条"}}); err != nil {
+		t.Fatal(err)
+	}
+	j.close()
+
+	// 新记录不会接在残留的半条记录后面
+	j, records, err = openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	if len(records) != 2 || records[1].Op != journalOpDelete || records[1].Entry.Content != "新词条" {
+		t.Errorf("records after append = %+v", records)
+	}
+}
@@ -0,0 +1,139 @@
+package participle
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	idfDocsKey       = MetaKeyPrefix + "idf:docs" // 语料文档数
+	idfTermKeyPrefix = MetaKeyPrefix + "idf:df:"  // 词的文档频率
+)
+
+// keywordTerms 抽取可作为关键词的词: 两个字及以上的汉字与拉丁字母词
+func (d *Engine) keywordTerms(text string) []string {
+	var terms []string
+	for _, token := range d.SegmentTokens(text) {
+		if token.Type != TokenHan && token.Type != TokenLatin {
+			continue
+		}
+		if utf8.RuneCountInString(token.Text) < 2 {
+			continue
+		}
+		terms = append(terms, strings.ToLower(token.Text))
+	}
+	return terms
+}
+
+// AddIDFDocument 将一篇文档计入语料的逆文档频率表, 表保存在数据库中
+func (d *Engine) AddIDFDocument(doc string) error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+
+	seen := make(map[string]bool)
+	for _, term := range d.keywordTerms(doc) {
+		seen[term] = true
+	}
+	return d.store.Update(func(tx StoreTxn) error {
+		if err := incrCount(tx, []byte(idfDocsKey)); err != nil {
+			return err
+		}
+		for term := range seen {
+			if err := incrCount(tx, []byte(idfTermKeyPrefix+term)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BuildIDF 逐行读取语料构建逆文档频率表, 每行为一篇文档, 进度按任务名称保存, 见RunCorpusJob
+func (d *Engine) BuildIDF(ctx context.Context, job string, r io.Reader) error {
+	return d.RunCorpusJob(ctx, job, r, d.AddIDFDocument)
+}
+
+// ResetIDF 清空逆文档频率表
+func (d *Engine) ResetIDF() error {
+	if d.fork != nil {
+		return ErrForkNoDB
+	}
+	if err := d.store.Delete([]byte(idfDocsKey)); err != nil {
+		return err
+	}
+	return dropPrefix(d.store, []byte(idfTermKeyPrefix))
+}
+
+// ExtractKeywords 按TF-IDF权重抽取文本的关键词, 按权重降序, topN为保留的数量, 0表示全部保留
+// 逆文档频率取自AddIDFDocument或BuildIDF构建的语料表, 语料为空时按词频排序
+func (d *Engine) ExtractKeywords(text string, topN int) ([]WordFrequency, error) {
+	if d.fork != nil {
+		return nil, ErrForkNoDB
+	}
+
+	tf := make(map[string]int)
+	terms := d.keywordTerms(text)
+	for _, term := range terms {
+		tf[term]++
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	keywords := make([]WordFrequency, 0, len(tf))
+	err := d.store.Update(func(tx StoreTxn) error {
+		docs, err := readCount(tx, []byte(idfDocsKey))
+		if err != nil {
+			return err
+		}
+		for term, n := range tf {
+			df, err := readCount(tx, []byte(idfTermKeyPrefix+term))
+			if err != nil {
+				return err
+			}
+			idf := math.Log(float64(docs+1)/float64(df+1)) + 1
+			keywords = append(keywords, WordFrequency{Name: term, Value: float64(n) / float64(len(terms)) * idf})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortFrequencies(keywords)
+	if topN > 0 && len(keywords) > topN {
+		keywords = keywords[:topN]
+	}
+	return keywords, nil
+}
+
+// readCount 读取计数, 不存在时为0
+func readCount(tx StoreTxn, key []byte) (uint64, error) {
+	val, err := tx.Get(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
+
+// incrCount 计数加一
+func incrCount(tx StoreTxn, key []byte) error {
+	return addCount(tx, key, 1)
+}
+
+// addCount 计数加n
+func addCount(tx StoreTxn, key []byte, n uint64) error {
+	count, err := readCount(tx, key)
+	if err != nil {
+		return err
+	}
+	return tx.Set(key, binary.BigEndian.AppendUint64(nil, count+n))
+}
@@ -0,0 +1,135 @@
+package participle
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// LearnOptions 从文本学习新词的策略, 可在多次调用间复用
+type LearnOptions struct {
+	MinLen     int              // 新词最少字数, 0表示使用默认值2
+	MaxLen     int              // 新词最多字数, 0表示不限制
+	MinCount   int              // 新词在本次文本中的最少出现次数, 0表示使用默认值1
+	AllowedPos []string         // 允许学习的词性(GSE基础词典中的词性, 未知词性为空字符串), 空表示不限制
+	Frequency  float64          // 新词的词频, 0表示使用默认值1000
+	Pos        string           // 新词的词性, 空表示使用默认值nz(其他专名)
+	Blacklist  []*regexp.Regexp // 匹配任一表达式的词不学习
+}
+
+// DefaultLearnOptions 默认的学习策略: 两字及以上的汉字或拉丁字母词, 词频1000, 词性nz
+func DefaultLearnOptions() LearnOptions {
+	return LearnOptions{MinLen: 2, MinCount: 1, Frequency: 1000.0, Pos: "nz"}
+}
+
+// WithLearnOptions 设置LearnFromText与LearnFromCorpus使用的学习策略
+func WithLearnOptions(policy LearnOptions) Option {
+	return func(o *options) { o.learn = policy }
+}
+
+// normalize 以默认值填充未设置的字段
+func (p LearnOptions) normalize() LearnOptions {
+	def := DefaultLearnOptions()
+	if p.MinLen <= 0 {
+		p.MinLen = def.MinLen
+	}
+	if p.MinCount <= 0 {
+		p.MinCount = def.MinCount
+	}
+	if p.Frequency == 0 {
+		p.Frequency = def.Frequency
+	}
+	if p.Pos == "" {
+		p.Pos = def.Pos
+	}
+	return p
+}
+
+// accept 判断词是否满足策略中的字数、词性与黑名单要求
+func (p LearnOptions) accept(token Token) bool {
+	if token.Type != TokenHan && token.Type != TokenLatin {
+		return false
+	}
+	n := utf8.RuneCountInString(token.Text)
+	if n < p.MinLen || (p.MaxLen > 0 && n > p.MaxLen) {
+		return false
+	}
+	if len(p.AllowedPos) > 0 && !slices.Contains(p.AllowedPos, token.Pos) {
+		return false
+	}
+	for _, re := range p.Blacklist {
+		if re.MatchString(token.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// LearnFromTextWith 按指定策略从文本中学习新词汇, 返回学习到的新词
+// 新词通过AddWords一次写入, 失败时不保留任何新词
+func (d *Engine) LearnFromTextWith(text string, policy LearnOptions) ([]DictEntry, error) {
+	return d.learn(context.Background(), text, policy)
+}
+
+// LearnFromTextCtx 从文本中学习新词汇, 见LearnFromText
+// 分词按行检查ctx, ctx取消时不写入任何新词并返回ctx的错误
+func (d *Engine) LearnFromTextCtx(ctx context.Context, text string) ([]DictEntry, error) {
+	return d.learn(ctx, text, d.opts.learn)
+}
+
+// learn 按策略从文本中学习新词汇, 全部候选词确定后一次写入
+func (d *Engine) learn(ctx context.Context, text string, policy LearnOptions) ([]DictEntry, error) {
+	policy = policy.normalize()
+
+	counts := make(map[string]int)
+	var candidates []Token
+	for line := range strings.Lines(text) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var tokens []Token
+		if len(policy.AllowedPos) > 0 {
+			tokens = d.SegmentPos(line)
+		} else {
+			tokens = d.SegmentTokens(line)
+		}
+		for _, token := range tokens {
+			if !policy.accept(token) {
+				continue
+			}
+			if counts[token.Text]++; counts[token.Text] == 1 {
+				candidates = append(candidates, token)
+			}
+		}
+	}
+
+	var learned []DictEntry
+	for _, token := range candidates {
+		content := token.Text
+		if counts[content] < policy.MinCount || d.Blacklisted(content) || d.containsWord(content) {
+			continue
+		}
+		learned = append(learned, DictEntry{
+			Content:   content,
+			Frequency: policy.Frequency,
+			Pos:       policy.Pos,
+			Examples:  sentencesAround(text, content, d.opts.maxExample),
+		})
+	}
+	if len(learned) == 0 {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := d.AddWords(learned); err != nil {
+		return nil, fmt.Errorf("添加新词失败: %v", err)
+	}
+	for _, entry := range learned {
+		d.recordLearned(entry)
+	}
+	return learned, nil
+}
@@ -0,0 +1,93 @@
+package participle
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLearnedBuffer 未被取走的学习记录上限, 超出后丢弃最早的记录
+const maxLearnedBuffer = 10000
+
+// sentenceDelimiters 句子分隔符
+const sentenceDelimiters = "。！？!?；;\n"
+
+// LearnedWord 学习到的新词
+type LearnedWord struct {
+	Entry     DictEntry `json:"entry"`      // 词条
+	LearnedAt time.Time `json:"learned_at"` // 学习时间
+	Context   string    `json:"context"`    // 样例上下文
+}
+
+// recordLearned 记录学习到的新词
+func (d *Engine) recordLearned(entry DictEntry) {
+	var context string
+	if len(entry.Examples) > 0 {
+		context = entry.Examples[0]
+	}
+
+	d.learnedMu.Lock()
+	defer d.learnedMu.Unlock()
+
+	if len(d.learned) >= maxLearnedBuffer {
+		d.learned = d.learned[1:]
+	}
+	d.learned = append(d.learned, LearnedWord{Entry: entry, LearnedAt: time.Now(), Context: context})
+}
+
+// DrainLearned 取走自上次取走以来学习到的新词
+func (d *Engine) DrainLearned() []LearnedWord {
+	d.learnedMu.Lock()
+	defer d.learnedMu.Unlock()
+
+	learned := d.learned
+	d.learned = nil
+	return learned
+}
+
+// Examples 获取词条的样例句子
+func (d *Engine) Examples(content string) []string {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	node := d.snap.Load().root
+	for _, char := range SplitString(content) {
+		if node = node.Child(char); node == nil {
+			return nil
+		}
+	}
+	if !node.IsEnd || node.Entry == nil {
+		return nil
+	}
+	return append([]string(nil), node.Entry.Examples...)
+}
+
+// sentencesAround 获取文本中包含词的句子, 最多n句且不重复
+func sentencesAround(text, content string, n int) []string {
+	var sentences []string
+	for offset := 0; len(sentences) < n; {
+		i := strings.Index(text[offset:], content)
+		if i < 0 {
+			break
+		}
+		i += offset
+
+		start := 0
+		if j := strings.LastIndexAny(text[:i], sentenceDelimiters); j >= 0 {
+			_, size := utf8.DecodeRuneInString(text[j:])
+			start = j + size
+		}
+		end := len(text)
+		if j := strings.IndexAny(text[i+len(content):], sentenceDelimiters); j >= 0 {
+			end = i + len(content) + j
+		}
+		offset = end
+
+		sentence := strings.TrimSpace(text[start:end])
+		if sentence != "" && !slices.Contains(sentences, sentence) {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package participle
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile 当前平台不支持文件锁
+func lockFile(f *os.File) error {
+	return errors.New("participle: file lease is not supported on this platform")
+}
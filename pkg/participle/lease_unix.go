@@ -0,0 +1,18 @@
+//go:build unix
+
+package participle
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile 以非阻塞方式对文件加排他锁
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrLeaseHeld
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package participle
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+const (
+	// trieNodeBytes 单个前缀树节点的估算内存: 节点结构体与其子节点容器
+	trieNodeBytes = int64(unsafe.Sizeof(TrieNode{})) + 48
+	// trieEdgeBytes 单条子节点边的估算内存(不含字符本身): 字符串头、指针与map桶开销
+	trieEdgeBytes = int64(unsafe.Sizeof("")) + int64(unsafe.Sizeof(&TrieNode{})) + 8
+)
+
+// entryBytes 估算词条占用内存
+func entryBytes(entry *DictEntry) int64 {
+	bytes := int64(unsafe.Sizeof(*entry)) + int64(len(entry.Content)) + int64(len(entry.Pos))
+	for _, example := range entry.Examples {
+		bytes += int64(unsafe.Sizeof(example)) + int64(len(example))
+	}
+	return bytes
+}
+
+// LimitError 超出资源限制错误
+type LimitError struct {
+	Resource string // 资源名称: entries 或 memory
+	Limit    int64  // 上限
+	Value    int64  // 操作完成后的值
+}
+
+// Error 实现error接口
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("participle: %s limit exceeded: %d > %d", e.Resource, e.Value, e.Limit)
+}
+
+// MemoryStats 词典资源统计
+// 内存为前缀树的估算值, 不包含GSE分词器内部词典
+type MemoryStats struct {
+	Version    uint64 `json:"version"`     // 快照版本
+	Entries    int64  `json:"entries"`     // 词条数量
+	Nodes      int64  `json:"nodes"`       // 前缀树节点数量
+	TrieBytes  int64  `json:"trie_bytes"`  // 前缀树估算内存(字节)
+	MaxEntries int64  `json:"max_entries"` // 词条数量上限, 0表示不限制
+	MaxBytes   int64  `json:"max_bytes"`   // 估算内存上限, 0表示不限制
+}
+
+// MemoryStats 获取词典资源统计
+func (d *Engine) MemoryStats() MemoryStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snap := d.snap.Load()
+	return MemoryStats{
+		Version:    snap.version,
+		Entries:    snap.entries,
+		Nodes:      snap.nodes,
+		TrieBytes:  snap.bytes,
+		MaxEntries: d.opts.maxEntries,
+		MaxBytes:   d.opts.maxBytes,
+	}
+}
+
+// checkLimits 检查插入词条后是否超出资源限制
+func (d *Engine) checkLimits(snap *snapshot, content string, entry *DictEntry) error {
+	entries, bytes := snap.planInsert(content, entry)
+	if d.opts.maxEntries > 0 && entries > 0 && snap.entries+entries > d.opts.maxEntries {
+		return &LimitError{Resource: "entries", Limit: d.opts.maxEntries, Value: snap.entries + entries}
+	}
+	if d.opts.maxBytes > 0 && bytes > 0 && snap.bytes+bytes > d.opts.maxBytes {
+		return &LimitError{Resource: "memory", Limit: d.opts.maxBytes, Value: snap.bytes + bytes}
+	}
+	return nil
+}
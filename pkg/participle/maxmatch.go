@@ -0,0 +1,166 @@
+package participle
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// SegmentMode 分词方式
+type SegmentMode int
+
+const (
+	SegmentGSE              SegmentMode = iota // GSE统计模型分词, 词典中的词优先, 默认方式
+	SegmentMaxMatchForward                     // 仅按自定义词典正向最大匹配
+	SegmentMaxMatchBackward                    // 仅按自定义词典逆向最大匹配
+	SegmentBidirectional                       // 双向最大匹配, 取词数较少、单字较少的结果, 相同时取逆向结果
+)
+
+// maxMatchRunes 逆向最大匹配时候选词的最大字数
+const maxMatchRunes = 32
+
+// WithDefaultSegmentMode 设置引擎默认的分词方式, 默认为SegmentGSE
+// 最大匹配方式只使用自定义词典与临时词条, 结果确定, 不依赖GSE的统计模型
+func WithDefaultSegmentMode(mode SegmentMode) Option {
+	return func(o *options) { o.segmentMode = mode }
+}
+
+// WithSegmentMode 设置本次分词的分词方式, 覆盖引擎默认的分词方式
+func WithSegmentMode(mode SegmentMode) SegmentOption {
+	return func(o *segmentOptions) { o.mode, o.hasMode = mode, true }
+}
+
+// maxMatcher 按最大匹配切分文本
+// 未匹配的部分中, 连续的ASCII字母与数字作为一个词, 其余字符各自作为一个词
+type maxMatcher struct {
+	snap  *snapshot
+	extra map[string]bool // 临时词条
+	match func(text string, i int) int
+}
+
+// newMaxMatcher 创建最大匹配切分器, extra为临时词条
+func newMaxMatcher(snap *snapshot, extra []DictEntry) *maxMatcher {
+	m := &maxMatcher{snap: snap, match: snap.longestMatch}
+	if len(extra) == 0 {
+		return m
+	}
+	m.extra = make(map[string]bool, len(extra))
+	for _, entry := range extra {
+		m.extra[entry.Content] = true
+	}
+	extraMatch, _ := wordsMatcher(extra)
+	m.match = func(text string, i int) int {
+		return max(snap.longestMatch(text, i), extraMatch(text, i))
+	}
+	return m
+}
+
+// segment 按分词方式切分
+func (m *maxMatcher) segment(text string, mode SegmentMode) []string {
+	switch mode {
+	case SegmentMaxMatchForward:
+		return m.forward(text)
+	case SegmentMaxMatchBackward:
+		return m.backward(text)
+	default:
+		fwd, bwd := m.forward(text), m.backward(text)
+		if len(fwd) != len(bwd) {
+			if len(fwd) < len(bwd) {
+				return fwd
+			}
+			return bwd
+		}
+		if singleRunes(fwd) < singleRunes(bwd) {
+			return fwd
+		}
+		return bwd
+	}
+}
+
+// forward 正向最大匹配
+func (m *maxMatcher) forward(text string) []string {
+	return overlaySegment(text, m.match, true, splitUnmatched)
+}
+
+// backward 逆向最大匹配: 从文本末尾开始, 每次切出以当前位置结尾的最长词
+func (m *maxMatcher) backward(text string) []string {
+	var reversed []string
+	end := len(text) // 尚未切分片段的结束位置
+	for j := len(text); j > 0; {
+		start := -1
+		for i, n := j, 0; i > 0 && n < maxMatchRunes; n++ {
+			_, size := utf8.DecodeLastRuneInString(text[:i])
+			i -= size
+			if m.contains(text[i:j]) {
+				start = i
+			}
+		}
+		if start < 0 {
+			_, size := utf8.DecodeLastRuneInString(text[:j])
+			j -= size
+			continue
+		}
+
+		if j < end {
+			reversed = appendReversed(reversed, splitUnmatched(text[j:end]))
+		}
+		reversed = append(reversed, text[start:j])
+		j, end = start, start
+	}
+	if end > 0 {
+		reversed = appendReversed(reversed, splitUnmatched(text[:end]))
+	}
+
+	for i, k := 0, len(reversed)-1; i < k; i, k = i+1, k-1 {
+		reversed[i], reversed[k] = reversed[k], reversed[i]
+	}
+	return reversed
+}
+
+// contains 判断词是否在词典或临时词条中
+func (m *maxMatcher) contains(word string) bool {
+	return m.snap.contains(word) || m.extra[word]
+}
+
+// appendReversed 将tokens逆序追加到dst
+func appendReversed(dst, tokens []string) []string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		dst = append(dst, tokens[i])
+	}
+	return dst
+}
+
+// splitUnmatched 切分未匹配的片段: 连续的ASCII字母与数字作为一个词, 其余字符各自作为一个词
+func splitUnmatched(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		if isASCIIAlnum(s[i]) {
+			j := i + 1
+			for j < len(s) && isASCIIAlnum(s[j]) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		tokens = append(tokens, s[i:i+size])
+		i += size
+	}
+	return tokens
+}
+
+// isASCIIAlnum 判断字节是否为ASCII字母或数字
+func isASCIIAlnum(c byte) bool {
+	return c < utf8.RuneSelf && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+// singleRunes 单字词的数量
+func singleRunes(tokens []string) int {
+	n := 0
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) == 1 {
+			n++
+		}
+	}
+	return n
+}
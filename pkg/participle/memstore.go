@@ -0,0 +1,148 @@
+package participle
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore 内存词典存储, 不在磁盘上创建任何文件, 关闭或进程退出后数据丢失
+// 适用于单元测试与短时运行的命令行程序; 遍历时对键排序, 不适合保存大量数据
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryStore 创建内存词典存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+// NewMemory 创建基于内存词典存储的分词引擎, 词典修改只保存在内存中
+func NewMemory(opts ...Option) (*Engine, error) {
+	return NewWithStore(context.Background(), NewMemoryStore(), opts...)
+}
+
+// Get 读取键的值
+func (s *MemoryStore) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return bytes.Clone(value), nil
+}
+
+// Set 写入键值
+func (s *MemoryStore) Set(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+// Delete 删除键
+func (s *MemoryStore) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, string(key))
+	return nil
+}
+
+// Iterate 按键的字节序遍历以prefix开头的键值, 遍历的是调用时的数据, 回调中可修改存储
+func (s *MemoryStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	s.mu.RLock()
+	keys := make([]string, 0)
+	for key := range s.data {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		values[key] = s.data[key]
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn([]byte(key), values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update 在一个事务中执行fn, 事务期间其他写入等待, fn返回错误时放弃全部修改
+func (s *MemoryStore) Update(fn func(tx StoreTxn) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := &memoryTxn{store: s, writes: make(map[string][]byte)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	for key, value := range tx.writes {
+		if value == nil {
+			delete(s.data, key)
+		} else {
+			s.data[key] = value
+		}
+	}
+	return nil
+}
+
+// DropPrefix 删除以prefix开头的全部键
+func (s *MemoryStore) DropPrefix(prefix []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.data {
+		if strings.HasPrefix(key, string(prefix)) {
+			delete(s.data, key)
+		}
+	}
+	return nil
+}
+
+// Close 清空数据
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.data)
+	return nil
+}
+
+// memoryTxn 内存词典存储的事务, 修改在提交前暂存, nil表示删除
+type memoryTxn struct {
+	store  *MemoryStore
+	writes map[string][]byte
+}
+
+// Get 读取键的值, 包含本事务中的修改
+func (t *memoryTxn) Get(key []byte) ([]byte, error) {
+	value, ok := t.writes[string(key)]
+	if !ok {
+		value, ok = t.store.data[string(key)]
+	}
+	if !ok || value == nil {
+		return nil, ErrKeyNotFound
+	}
+	return bytes.Clone(value), nil
+}
+
+// Set 写入键值
+func (t *memoryTxn) Set(key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	t.writes[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+// Delete 删除键
+func (t *memoryTxn) Delete(key []byte) error {
+	t.writes[string(key)] = nil
+	return nil
+}
@@ -0,0 +1,90 @@
+package participle
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidNamespace 命名空间名称不合法
+var ErrInvalidNamespace = errors.New("participle: invalid namespace")
+
+// namespaceKeyPrefix 命名空间词条的键前缀, 键为前缀+命名空间+":"+词
+// 位于MetaKeyPrefix之下, 默认命名空间加载词典时会整段跳过
+const namespaceKeyPrefix = MetaKeyPrefix + "ns:"
+
+// WithNamespace 将引擎绑定到词典命名空间, 同一数据库中不同命名空间的自定义词典相互隔离, 如电商、医疗、法律
+// base为组合的只读命名空间, 按优先级从低到高排列, 同一个词以优先级高的为准, 绑定的命名空间优先级最高
+// 空字符串表示默认命名空间, 即未指定命名空间的引擎所用的词典
+// 词典修改只写入绑定的命名空间; 删除来自组合命名空间的词只影响内存, 重载后恢复
+// 学习黑名单、任务进度与IDF统计等非词条数据不区分命名空间
+func WithNamespace(name string, base ...string) Option {
+	return func(o *options) {
+		o.namespace = name
+		o.baseNamespaces = append([]string(nil), base...)
+	}
+}
+
+// namespaces 加载词典的命名空间, 按优先级从低到高排列
+func (o *options) namespaces() []string {
+	return append(slices.Clip(o.baseNamespaces), o.namespace)
+}
+
+// checkNamespaces 检查命名空间名称, 名称不能包含":"
+func (o *options) checkNamespaces() error {
+	for _, ns := range o.namespaces() {
+		if strings.Contains(ns, ":") {
+			return ErrInvalidNamespace
+		}
+	}
+	return nil
+}
+
+// namespacePrefix 命名空间中词条的键前缀, 默认命名空间为空
+func namespacePrefix(ns string) string {
+	if ns == "" {
+		return ""
+	}
+	return namespaceKeyPrefix + ns + ":"
+}
+
+// EntryKey 命名空间中的词条在词典存储中的键, namespace为空表示默认命名空间
+func EntryKey(namespace, content string) []byte {
+	return []byte(namespacePrefix(namespace) + content)
+}
+
+// entryKey 词条在数据库中的键
+func (d *Engine) entryKey(content string) []byte {
+	return EntryKey(d.opts.namespace, content)
+}
+
+// Namespace 引擎绑定的命名空间, 空表示默认命名空间
+func (d *Engine) Namespace() string {
+	return d.opts.namespace
+}
+
+// Namespaces 列出词典存储中已有词条的命名空间, 按名称排序, 不含默认命名空间
+// badger数据库可使用NewBadgerStore包装
+func Namespaces(store DictStore) ([]string, error) {
+	var names []string
+	err := store.Iterate([]byte(namespaceKeyPrefix), func(key, _ []byte) error {
+		name, _, _ := strings.Cut(string(key[len(namespaceKeyPrefix):]), ":")
+		// 键按字节序遍历, 同一命名空间的键相邻
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+		return nil
+	})
+	// 键以"名称:"排序, 与按名称排序不完全一致, 如"a-b:"排在"a:"之前
+	slices.Sort(names)
+	return names, err
+}
+
+// DropNamespace 删除词典存储中命名空间的全部词条, 不能删除默认命名空间
+// 已绑定该命名空间的引擎需调用Reload才会生效
+func DropNamespace(store DictStore, name string) error {
+	if name == "" || strings.Contains(name, ":") {
+		return ErrInvalidNamespace
+	}
+	return dropPrefix(store, []byte(namespacePrefix(name)))
+}
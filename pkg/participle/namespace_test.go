@@ -0,0 +1,44 @@
+package participle
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNamespacesOnStore(t *testing.T) {
+	store := NewMemoryStore()
+	for _, ns := range []string{"", "legal", "a-b", "a"} {
+		d, err := NewWithStore(t.Context(), store, WithBaseDict(BaseDictEmpty), WithNamespace(ns))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.AddWords([]DictEntry{{Content: "词条一", Frequency: 1}, {Content: "词条二", Frequency: 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := Namespaces(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "a-b", "legal"}; !slices.Equal(names, want) {
+		t.Errorf("Namespaces = %q, want %q", names, want)
+	}
+
+	if err := DropNamespace(store, ""); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("drop default namespace: %v, want ErrInvalidNamespace", err)
+	}
+	if err := DropNamespace(store, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := Namespaces(store); !slices.Equal(names, []string{"a-b", "legal"}) {
+		t.Errorf("after drop Namespaces = %q", names)
+	}
+	if _, err := store.Get(EntryKey("a-b", "词条一")); err != nil {
+		t.Errorf("neighbouring namespace dropped: %v", err)
+	}
+	if _, err := store.Get(EntryKey("", "词条一")); err != nil {
+		t.Errorf("default namespace dropped: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package participle
+
+import "time"
+
+// Option 分词引擎配置项
+type Option func(*options)
+
+// options 分词引擎配置
+type options struct {
+	maxEntries int64        // 词条数量上限, 0表示不限制
+	maxBytes   int64        // 前缀树估算内存上限(字节), 0表示不限制
+	maxPending int          // 降级模式下待写队列长度上限
+	journal    string       // 预写日志路径, 空表示不启用
+	maxExample int          // 学习新词时保存的样例句子数量上限
+	mergeSpan  int          // 分词后按用户词典合并的最大相邻词数, 0表示不合并
+	learn      LearnOptions // 学习新词的策略
+	childLimit int          // 前缀树子节点使用有序切片存储的数量上限, 0表示始终使用map
+
+	prefetchSize int // 加载词典时预取值的数量, 0表示使用默认值
+
+	primary Primary // 读写分离时接收词典修改的主节点, nil表示本地写入
+
+	compactThreshold int64         // 触发前缀树压缩的移除次数, 0表示不压缩
+	compactInterval  time.Duration // 两次前缀树压缩的最小间隔
+
+	sharedSegmenter bool                      // 是否使用进程内共享的基础分词器
+	baseDict        BaseDict                  // GSE基础词典
+	newSegmenter    func() (Segmenter, error) // 自定义分词器, nil表示使用GSE
+
+	historyBucket time.Duration // 词频时间序列的时间段长度
+	segmentMode   SegmentMode   // 默认的分词方式
+
+	namespace      string   // 词典命名空间, 空表示默认命名空间
+	baseNamespaces []string // 组合的只读命名空间, 按优先级从低到高排列
+
+	exportPrivacy Privacy // 导出由用户内容统计的词频时的隐私保护
+}
+
+// BaseDict GSE基础词典
+type BaseDict int
+
+const (
+	BaseDictFull  BaseDict = iota // 完整词典: 简体与繁体
+	BaseDictSmall                 // 精简词典: 仅简体
+	BaseDictEmpty                 // 空词典: 只使用数据库中的词
+	baseDictCount
+)
+
+// defaultOptions 默认配置
+func defaultOptions() options {
+	return options{
+		maxPending: 1024,
+		maxExample: 3,
+		learn:      DefaultLearnOptions(),
+		mergeSpan:  4,
+		childLimit: defaultChildLimit,
+
+		historyBucket: defaultHistoryBucket,
+	}
+}
+
+// WithMaxEntries 设置词条数量上限
+// 超出上限的AddWord将返回*LimitError
+func WithMaxEntries(n int64) Option {
+	return func(o *options) { o.maxEntries = n }
+}
+
+// WithMaxMemory 设置前缀树估算内存上限(字节)
+// 超出上限的AddWord将返回*LimitError
+func WithMaxMemory(bytes int64) Option {
+	return func(o *options) { o.maxBytes = bytes }
+}
+
+// WithMaxPending 设置降级模式下待写队列长度上限
+// 队列已满时AddWord返回ErrPendingFull
+func WithMaxPending(n int) Option {
+	return func(o *options) { o.maxPending = n }
+}
+
+// WithJournal 启用词典修改预写日志
+// 每次修改应用到内存后写入日志再保存到数据库, 未正常关闭时下次启动会重放日志
+func WithJournal(path string) Option {
+	return func(o *options) { o.journal = path }
+}
+
+// WithMaxExamples 设置学习新词时保存的样例句子数量上限, 0表示不保存
+func WithMaxExamples(n int) Option {
+	return func(o *options) { o.maxExample = n }
+}
+
+// WithUserWordPriority 设置分词后按用户词典合并的最大相邻词数, 0表示不合并
+// GSE可能将用户添加的词再次切开, 合并时按最长匹配还原词典中的词, 默认最多合并4个相邻词
+func WithUserWordPriority(span int) Option {
+	return func(o *options) { o.mergeSpan = span }
+}
+
+// WithTrieChildren 设置前缀树子节点使用有序切片存储的数量上限, 超过上限的节点使用map, 0表示始终使用map
+// 默认为8, 绝大多数节点只有少量子节点, 切片比map更省内存且查找更快
+func WithTrieChildren(limit int) Option {
+	return func(o *options) { o.childLimit = limit }
+}
+
+// WithPrefetchSize 设置从数据库加载词典时预取值的数量, 默认为100
+// 词典较大时调大可减少加载时的随机读取
+func WithPrefetchSize(n int) Option {
+	return func(o *options) { o.prefetchSize = n }
+}
+
+// WithSharedSegmenter 使用进程内共享的基础分词器, 多个引擎只加载一份GSE内置词典
+// 词典中的词不写入共享分词器, 分词时先按最长匹配切出词典中的词, 其余片段交由基础分词器切分
+// 适用于同一进程中承载多个租户或大量测试引擎的场景
+func WithSharedSegmenter() Option {
+	return func(o *options) { o.sharedSegmenter = true }
+}
+
+// WithBaseDict 设置GSE基础词典, 默认为完整词典
+// 只匹配领域词汇时可使用空词典, 减少内存占用与启动时间
+func WithBaseDict(base BaseDict) Option {
+	return func(o *options) { o.baseDict = base }
+}
+
+// WithExportPrivacy 导出由用户内容统计的词频时加噪并压制低频词, 作用于CorpusFrequencies与ExportFrequencyHistory
+// 词典词频来自词典本身, 不受影响
+func WithExportPrivacy(p Privacy) Option {
+	return func(o *options) { o.exportPrivacy = p }
+}
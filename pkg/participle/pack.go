@@ -0,0 +1,106 @@
+package participle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrPackChecksum 词典包校验和不匹配
+	ErrPackChecksum = errors.New("participle: dictionary pack checksum mismatch")
+	// ErrPackSignature 词典包签名缺失或无效
+	ErrPackSignature = errors.New("participle: dictionary pack signature invalid")
+)
+
+// DictionaryPack 可分发的词典包
+// Checksum为名称、版本与词条的SHA-256, Signature为对Checksum的ed25519签名
+type DictionaryPack struct {
+	Name      string      `json:"name"`                // 词典包名称
+	Version   string      `json:"version"`             // 词典包版本
+	Entries   []DictEntry `json:"entries"`             // 词条
+	Checksum  string      `json:"checksum"`            // 十六进制SHA-256
+	Signature []byte      `json:"signature,omitempty"` // ed25519签名, 可选
+}
+
+// packBody 参与校验和计算的内容
+type packBody struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Entries []DictEntry `json:"entries"`
+}
+
+// digest 计算词典包内容的SHA-256
+func (p *DictionaryPack) digest() ([]byte, error) {
+	data, err := json.Marshal(packBody{Name: p.Name, Version: p.Version, Entries: p.Entries})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// Seal 计算校验和, key不为nil时同时签名
+func (p *DictionaryPack) Seal(key ed25519.PrivateKey) error {
+	sum, err := p.digest()
+	if err != nil {
+		return err
+	}
+	p.Checksum = hex.EncodeToString(sum)
+	p.Signature = nil
+	if key != nil {
+		p.Signature = ed25519.Sign(key, sum)
+	}
+	return nil
+}
+
+// Verify 校验词典包, pub不为nil时要求签名有效
+func (p *DictionaryPack) Verify(pub ed25519.PublicKey) error {
+	sum, err := p.digest()
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(sum) != p.Checksum {
+		return ErrPackChecksum
+	}
+	if pub != nil && !ed25519.Verify(pub, sum, p.Signature) {
+		return ErrPackSignature
+	}
+	return nil
+}
+
+// WritePack 写出词典包, 写出前计算校验和并按key签名
+func WritePack(w io.Writer, p *DictionaryPack, key ed25519.PrivateKey) error {
+	if err := p.Seal(key); err != nil {
+		return fmt.Errorf("seal pack fail: %v", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(p)
+}
+
+// LoadPack 读取并校验词典包
+// pub为nil时仅校验校验和, 否则同时要求签名有效
+func LoadPack(r io.Reader, pub ed25519.PublicKey) (*DictionaryPack, error) {
+	var p DictionaryPack
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode pack fail: %v", err)
+	}
+	if err := p.Verify(pub); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ApplyPack 将已校验的词典包词条添加到词典
+// 词条通过AddWords批量写入, 任一词条失败时不保留词典包中的任何词条
+func (d *Engine) ApplyPack(p *DictionaryPack) error {
+	if err := d.AddWords(p.Entries); err != nil {
+		return fmt.Errorf("apply pack %s@%s fail: %v", p.Name, p.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package participle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestApplyPackAtomic(t *testing.T) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty), WithMaxEntries(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := func(entries ...string) *DictionaryPack {
+		t.Helper()
+		p := &DictionaryPack{Name: "medical", Version: "1.0.0"}
+		for _, content := range entries {
+			p.Entries = append(p.Entries, DictEntry{Content: content, Frequency: 10, Pos: "n"})
+		}
+		var buf bytes.Buffer
+		if err := WritePack(&buf, p, key); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadPack(&buf, pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loaded
+	}
+
+	// 超出词条数上限时整个词典包都不生效
+	if err := d.ApplyPack(load("阿司匹林", "布洛芬", "对乙酰氨基酚", "头孢克肟")); err == nil {
+		t.Fatal("pack over the entry limit applied")
+	}
+	for _, word := range []string{"阿司匹林", "布洛芬", "对乙酰氨基酚"} {
+		if d.Contains(word) {
+			t.Errorf("%s kept after failed pack", word)
+		}
+	}
+
+	if err := d.ApplyPack(load("阿司匹林", "布洛芬")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Contains("阿司匹林") || !d.Contains("布洛芬") {
+		t.Error("pack entries missing")
+	}
+}
@@ -0,0 +1,71 @@
+package participle
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// RedactedPhone 电话号码脱敏后的占位符
+	RedactedPhone = "<phone>"
+	// RedactedIDCard 身份证号脱敏后的占位符
+	RedactedIDCard = "<id>"
+)
+
+// numberRunPattern 可能是电话号码或身份证号的连续数字串
+var numberRunPattern = regexp.MustCompile(`\+?\d[\d\-]*[\dXx]`)
+
+// idCardWeights 18位身份证号前17位的加权因子
+var idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// IsIDCard 判断是否为身份证号: 校验码正确的18位号码或15位旧号码
+func IsIDCard(s string) bool {
+	switch len(s) {
+	case 15:
+		return isDigits(s)
+	case 18:
+		if !isDigits(s[:17]) {
+			return false
+		}
+		sum := 0
+		for i, w := range idCardWeights {
+			sum += int(s[i]-'0') * w
+		}
+		check := "10X98765432"[sum%11]
+		last := s[17]
+		if last == 'x' {
+			last = 'X'
+		}
+		return last == check
+	default:
+		return false
+	}
+}
+
+// RedactPII 将文本中的电话号码与身份证号替换为占位符, 用于写日志等需要脱敏的场景
+// 号码须是完整的数字串, 更长数字串中的片段(如订单号)不作处理
+func RedactPII(text string) string {
+	if !strings.ContainsAny(text, "0123456789") {
+		return text
+	}
+	return numberRunPattern.ReplaceAllStringFunc(text, func(s string) string {
+		switch {
+		case phonePattern.MatchString(s):
+			return RedactedPhone
+		case IsIDCard(s):
+			return RedactedIDCard
+		default:
+			return s
+		}
+	})
+}
+
+// isDigits 判断是否全部为ASCII数字
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,40 @@
+package participle
+
+import "sort"
+
+// PrefixSearch 查找自定义词典中以prefix开头的词条, 按词频降序, 词频相同时按词排序
+// limit为返回数量上限, 0表示不限制; 可用于在学习到的词典上实现输入联想
+func (d *Engine) PrefixSearch(prefix string, limit int) []DictEntry {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	node := d.snap.Load().root
+	for _, char := range SplitString(prefix) {
+		if node = node.Child(char); node == nil {
+			return nil
+		}
+	}
+
+	var entries []DictEntry
+	var collect func(n *TrieNode)
+	collect = func(n *TrieNode) {
+		if n.IsEnd && n.Entry != nil {
+			entries = append(entries, *n.Entry)
+		}
+		for _, child := range n.Children() {
+			collect(child)
+		}
+	}
+	collect(node)
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Frequency != entries[j].Frequency {
+			return entries[i].Frequency > entries[j].Frequency
+		}
+		return entries[i].Content < entries[j].Content
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
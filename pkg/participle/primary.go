@@ -0,0 +1,40 @@
+package participle
+
+import "errors"
+
+// Primary 读写分离时接收词典修改的主节点
+// *Engine实现了该接口, 也可以是转发到远程主节点的客户端
+type Primary interface {
+	AddWords(entries []DictEntry) error
+	DeleteWord(content string) error
+}
+
+// WithPrimary 启用读写分离, 本地引擎作为只读副本提供分词, 词典修改转发到主节点
+// AddWord、AddWords、UpdateWord、DeleteWord、LearnFromText与导入等修改不再写入本地数据库与前缀树,
+// 由主节点发布后经Follow或Reload同步到本地, 因此修改在下一次同步后才对本地分词可见
+func WithPrimary(p Primary) Option {
+	return func(o *options) { o.primary = p }
+}
+
+// forward 将修改转发到主节点, 新增与修改合并为一次AddWords, 删除逐个转发
+func (d *Engine) forward(entries []DictEntry) error {
+	var adds []DictEntry
+	for _, entry := range entries {
+		if !entry.deleted {
+			adds = append(adds, entry)
+		}
+	}
+	if len(adds) > 0 {
+		if err := d.opts.primary.AddWords(adds); err != nil {
+			return err
+		}
+	}
+	for _, entry := range entries {
+		if entry.deleted {
+			if err := d.opts.primary.DeleteWord(entry.Content); err != nil && !errors.Is(err, ErrWordNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package participle
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Privacy 导出由用户内容统计的词频时的隐私保护: 先加拉普拉斯噪声, 再压制低频词
+// 低频词可能只出现在个别用户的内容中, 导出后可据此识别个人; 噪声使单条内容对结果的影响不可区分
+type Privacy struct {
+	Epsilon  float64 // 隐私预算, 噪声尺度为1/Epsilon, 越小噪声越大, 0表示不加噪声
+	MinCount float64 // 加噪后低于该值的词不导出, 0表示不压制
+}
+
+// enabled 是否启用隐私保护
+func (p Privacy) enabled() bool {
+	return p.Epsilon > 0 || p.MinCount > 0
+}
+
+// noisy 对次数加噪并取整, 返回加噪后的次数以及是否导出
+func (p Privacy) noisy(count float64) (float64, bool) {
+	if p.Epsilon > 0 {
+		count = math.Max(0, math.Round(count+laplace(1/p.Epsilon)))
+	}
+	return count, count > 0 && count >= p.MinCount
+}
+
+// Apply 对词频加噪并移除低于阈值的词, 返回按词频降序的新切片, 不修改freqs
+func (p Privacy) Apply(freqs []WordFrequency) []WordFrequency {
+	out := make([]WordFrequency, 0, len(freqs))
+	for _, f := range freqs {
+		if value, ok := p.noisy(f.Value); ok {
+			out = append(out, WordFrequency{Name: f.Name, Value: value})
+		}
+	}
+	sortFrequencies(out)
+	return out
+}
+
+// laplace 生成尺度为b的拉普拉斯噪声
+func laplace(b float64) float64 {
+	u := rand.Float64() - 0.5
+	if u < 0 {
+		return b * math.Log(1+2*u)
+	}
+	return -b * math.Log(1-2*u)
+}
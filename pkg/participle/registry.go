@@ -0,0 +1,145 @@
+package participle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// PackInfo 词典包仓库索引中的一项
+type PackInfo struct {
+	Name    string `json:"name"`    // 词典包名称
+	Version string `json:"version"` // 词典包版本
+	File    string `json:"file"`    // 相对索引地址的词典包文件路径
+}
+
+// RegistryIndex 词典包仓库索引(index.json)
+type RegistryIndex struct {
+	Packs []PackInfo `json:"packs"`
+}
+
+// cachedResponse 带ETag的缓存响应
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// RegistryClient 词典包仓库客户端
+// 仓库为静态HTTP目录: index.json列出所有词典包, 词典包文件为WritePack的输出
+// 响应按ETag缓存, 再次请求时携带If-None-Match
+type RegistryClient struct {
+	base      *url.URL          // 仓库地址
+	client    *http.Client      // HTTP客户端
+	publicKey ed25519.PublicKey // 校验签名的公钥, nil表示仅校验校验和
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+// NewRegistryClient 创建仓库客户端
+// client为nil时使用http.DefaultClient, publicKey为nil时不要求签名
+func NewRegistryClient(baseURL string, client *http.Client, publicKey ed25519.PublicKey) (*RegistryClient, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse registry url fail: %v", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RegistryClient{base: base, client: client, publicKey: publicKey, cache: make(map[string]cachedResponse)}, nil
+}
+
+// List 获取仓库中的词典包列表
+func (c *RegistryClient) List(ctx context.Context) ([]PackInfo, error) {
+	data, err := c.get(ctx, "index.json")
+	if err != nil {
+		return nil, err
+	}
+	var index RegistryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("decode registry index fail: %v", err)
+	}
+	return index.Packs, nil
+}
+
+// Fetch 下载并校验词典包
+// version为空时取索引中该名称最后列出的版本, 否则固定为指定版本
+func (c *RegistryClient) Fetch(ctx context.Context, name, version string) (*DictionaryPack, error) {
+	packs, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var info *PackInfo
+	for i := range packs {
+		if packs[i].Name == name && (version == "" || packs[i].Version == version) {
+			info = &packs[i]
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("pack %s@%s not found in registry", name, version)
+	}
+
+	data, err := c.get(ctx, info.File)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := LoadPack(bytes.NewReader(data), c.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if pack.Name != info.Name || pack.Version != info.Version {
+		return nil, fmt.Errorf("pack %s@%s does not match registry entry %s@%s", pack.Name, pack.Version, info.Name, info.Version)
+	}
+	return pack, nil
+}
+
+// get 请求仓库中的文件, 未修改时返回缓存内容
+func (c *RegistryClient) get(ctx context.Context, path string) ([]byte, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	u := c.base.ResolveReference(ref).String()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	cached, ok := c.cache[u]
+	c.mu.Unlock()
+	if ok {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		return cached.body, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("registry %s response status: %s", u, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		c.cache[u] = cachedResponse{etag: etag, body: body}
+		c.mu.Unlock()
+	}
+	return body, nil
+}
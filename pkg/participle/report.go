@@ -0,0 +1,165 @@
+package participle
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ReportFormat 报告格式
+type ReportFormat string
+
+const (
+	ReportJSON ReportFormat = "json" // JSON格式
+	ReportCSV  ReportFormat = "csv"  // CSV格式
+)
+
+// LearnedReport 新词学习报告
+type LearnedReport struct {
+	From  time.Time     `json:"from"`  // 统计开始时间
+	To    time.Time     `json:"to"`    // 统计结束时间
+	Words []LearnedWord `json:"words"` // 学习到的新词
+}
+
+// Encode 按格式编码报告
+func (r *LearnedReport) Encode(format ReportFormat) ([]byte, error) {
+	switch format {
+	case ReportJSON:
+		return json.MarshalIndent(r, "", "  ")
+	case ReportCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"content", "frequency", "pos", "learned_at", "context"})
+		for _, word := range r.Words {
+			w.Write([]string{
+				word.Entry.Content,
+				strconv.FormatFloat(word.Entry.Frequency, 'f', -1, 64),
+				word.Entry.Pos,
+				word.LearnedAt.Format(time.RFC3339),
+				word.Context,
+			})
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	default:
+		return nil, fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// ReportSink 报告投递目标
+type ReportSink func(report *LearnedReport) error
+
+// DirSink 将报告写入目录, 文件名为统计结束时间
+func DirSink(dir string, format ReportFormat) ReportSink {
+	return func(report *LearnedReport) error {
+		data, err := report.Encode(format)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("learned_%s.%s", report.To.Format("20060102T150405"), format)
+		return os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+}
+
+// WebhookSink 将报告POST到webhook地址
+func WebhookSink(url string, format ReportFormat) ReportSink {
+	contentType := "application/json"
+	if format == ReportCSV {
+		contentType = "text/csv"
+	}
+	return func(report *LearnedReport) error {
+		data, err := report.Encode(format)
+		if err != nil {
+			return err
+		}
+		resp, err := http.Post(url, contentType, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s response status: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// ReportMessage 报告投递错误处理
+type ReportMessage func(err error)
+
+// Reporter 定时新词学习报告
+type Reporter struct {
+	engine   *Engine
+	interval time.Duration
+	sinks    []ReportSink
+	rm       ReportMessage
+
+	last time.Time     // 上次报告时间
+	done chan struct{} // 退出信号
+	stop chan struct{} // 退出成功信号
+}
+
+// StartReporter 启动定时报告
+// 每个周期取走期间学习到的新词并投递到所有目标, 没有新词时不投递
+// 投递错误交由rm处理, rm可为nil
+func (d *Engine) StartReporter(interval time.Duration, rm ReportMessage, sinks ...ReportSink) *Reporter {
+	r := &Reporter{
+		engine:   d,
+		interval: interval,
+		sinks:    sinks,
+		rm:       rm,
+		last:     time.Now(),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	go r.listener()
+	return r
+}
+
+// listener 监听报告周期
+func (r *Reporter) listener() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	defer close(r.stop)
+
+	for {
+		select {
+		case <-ticker.C:
+			r.report()
+		case <-r.done:
+			r.report()
+			return
+		}
+	}
+}
+
+// report 生成并投递报告
+func (r *Reporter) report() {
+	now := time.Now()
+	report := &LearnedReport{From: r.last, To: now, Words: r.engine.DrainLearned()}
+	r.last = now
+	if len(report.Words) == 0 {
+		return
+	}
+
+	for _, sink := range r.sinks {
+		if err := sink(report); err != nil && r.rm != nil {
+			r.rm(err)
+		}
+	}
+}
+
+// Stop 停止定时报告, 停止前投递最后一次报告
+func (r *Reporter) Stop() {
+	close(r.done)
+	<-r.stop
+}
@@ -0,0 +1,105 @@
+package participle_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/miajio/nla/pkg/participle"
+	"github.com/miajio/nla/pkg/participle/testutil"
+)
+
+var errInjected = errors.New("injected fault")
+
+// dictionary 读取引擎自定义词典中的词与词频
+func dictionary(d *participle.Engine) map[string]float64 {
+	words := make(map[string]float64)
+	d.Walk(func(entry participle.DictEntry) bool {
+		words[entry.Content] = entry.Frequency
+		return true
+	})
+	return words
+}
+
+// newFaultyEngine 创建使用可注入故障的分词器与存储的引擎, 预置"自然语言"
+func newFaultyEngine(t *testing.T, opts ...participle.Option) (*participle.Engine, *testutil.Faults, *testutil.Store) {
+	t.Helper()
+	faults := &testutil.Faults{}
+	store := testutil.NewStore()
+	if err := testutil.SeedNamespace(store, "", testutil.Entry("自然语言", testutil.WithFrequency(10))); err != nil {
+		t.Fatal(err)
+	}
+	d, err := participle.NewWithStore(t.Context(), store, append([]participle.Option{testutil.WithFakeSegmenter(faults)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d, faults, store
+}
+
+func TestApplyRollsBackTrie(t *testing.T) {
+	d, faults, store := newFaultyEngine(t)
+	before := dictionary(d)
+	const text = "自然语言处理"
+	segments := d.Segment(text)
+
+	faults.FailAdd(errInjected)
+	if err := d.AddWord("语言处理", 5, "n"); err == nil {
+		t.Error("AddWord succeeded when the segmenter failed")
+	}
+	if err := d.UpdateWord("自然语言", 99, "v"); err == nil {
+		t.Error("UpdateWord succeeded when the segmenter failed")
+	}
+	if err := d.AddWords(testutil.Entries("处理", "语言")); err == nil {
+		t.Error("AddWords succeeded when the segmenter failed")
+	}
+	faults.Reset()
+
+	faults.FailRemove(errInjected)
+	if err := d.DeleteWord("自然语言"); err == nil {
+		t.Error("DeleteWord succeeded when the segmenter failed")
+	}
+	faults.Reset()
+
+	if after := dictionary(d); !maps.Equal(after, before) {
+		t.Errorf("trie after failed changes = %v, want %v", after, before)
+	}
+	if got := d.Segment(text); !slices.Equal(got, segments) {
+		t.Errorf("Segment = %q, want %q", got, segments)
+	}
+	if _, err := store.Get(participle.EntryKey("", "语言处理")); !errors.Is(err, participle.ErrKeyNotFound) {
+		t.Errorf("failed word stored, err %v", err)
+	}
+	if d.Version() == 0 {
+		t.Error("engine not usable after rollback")
+	}
+}
+
+func TestPersistFailureRollsBackSegmenter(t *testing.T) {
+	// 待写队列长度为0, 数据库写入失败时立即撤销内存修改
+	d, _, store := newFaultyEngine(t, participle.WithMaxPending(0))
+	before := dictionary(d)
+	const text = "自然语言处理"
+	segments := d.Segment(text)
+
+	store.FailWrites(errInjected)
+	if err := d.AddWords(testutil.Entries("语言处理", "处理")); !errors.Is(err, participle.ErrPendingFull) {
+		t.Errorf("AddWords = %v, want ErrPendingFull", err)
+	}
+	if err := d.UpdateWord("自然语言", 99, "v"); !errors.Is(err, participle.ErrPendingFull) {
+		t.Errorf("UpdateWord = %v, want ErrPendingFull", err)
+	}
+	if err := d.DeleteWord("自然语言"); !errors.Is(err, participle.ErrPendingFull) {
+		t.Errorf("DeleteWord = %v, want ErrPendingFull", err)
+	}
+	store.FailWrites(nil)
+
+	// 分词器与前缀树都已恢复: 新词不再切出, 删除的词仍然切出
+	if after := dictionary(d); !maps.Equal(after, before) {
+		t.Errorf("trie after rollback = %v, want %v", after, before)
+	}
+	if got := d.Segment(text); !slices.Equal(got, segments) {
+		t.Errorf("Segment = %q, want %q", got, segments)
+	}
+}
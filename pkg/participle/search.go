@@ -0,0 +1,43 @@
+package participle
+
+// SegmentSearch 搜索引擎模式分词: 在Segment结果的基础上, 长词再切出其中的短词
+// 结果中的词可能相互重叠, 适用于建立索引; 展示与统计仍应使用Segment
+// 不受WithSegmentMode影响, 支持WithExtraWords; 自定义分词器未实现SearchSegmenter时与Segment相同
+func (d *Engine) SegmentSearch(text string, opts ...SegmentOption) []string {
+	return d.segmentFine(text, opts, func(seg SearchSegmenter, text string) []string {
+		return seg.CutSearch(text)
+	})
+}
+
+// SegmentAll 全模式分词: 切出文本中所有可以成词的词
+// 结果中的词相互重叠、数量较多, 适用于召回优先的索引; 不受WithSegmentMode影响, 支持WithExtraWords
+func (d *Engine) SegmentAll(text string, opts ...SegmentOption) []string {
+	return d.segmentFine(text, opts, func(seg SearchSegmenter, text string) []string {
+		return seg.CutAll(text)
+	})
+}
+
+// segmentFine 按cut细粒度切分, 分词器不支持细粒度切分时使用Cut; 共享分词器中没有词典中的词, 先按前缀树切出
+func (d *Engine) segmentFine(text string, opts []SegmentOption, cut func(SearchSegmenter, string) []string) []string {
+	var o segmentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+	snap := d.snap.Load()
+	base := snap.segmenter.Cut
+	if seg, ok := snap.segmenter.(SearchSegmenter); ok {
+		base = func(s string) []string { return cut(seg, s) }
+	}
+	if snap.shared {
+		fine := base
+		base = func(s string) []string { return overlaySegment(s, snap.longestMatch, snap.asciiRoots > 0, fine) }
+	}
+	if len(o.extraWords) == 0 {
+		return base(text)
+	}
+	match, ascii := wordsMatcher(o.extraWords)
+	return overlaySegment(text, match, ascii, base)
+}
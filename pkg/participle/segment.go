@@ -0,0 +1,166 @@
+package participle
+
+import "unicode/utf8"
+
+// SegmentOption 单次分词配置项
+type SegmentOption func(*segmentOptions)
+
+// segmentOptions 单次分词配置
+type segmentOptions struct {
+	extraWords []DictEntry // 仅对本次分词生效的临时词条
+	joinRules  []JoinRule  // 分词后合并相邻词的规则, 仅SegmentPos使用
+	mode       SegmentMode // 分词方式
+	hasMode    bool        // 是否指定了分词方式
+}
+
+// WithExtraWords 为本次分词临时叠加词条, 不写入词典也不影响其他分词请求
+// 临时词条按最长匹配优先于词典切分
+func WithExtraWords(entries []DictEntry) SegmentOption {
+	return func(o *segmentOptions) { o.extraWords = append(o.extraWords, entries...) }
+}
+
+// Segment 对文本进行分词, 分词方式见WithSegmentMode
+func (d *Engine) Segment(text string, opts ...SegmentOption) []string {
+	var o segmentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mode := d.opts.segmentMode
+	if o.hasMode {
+		mode = o.mode
+	}
+
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+	snap := d.snap.Load()
+	if mode != SegmentGSE {
+		return newMaxMatcher(snap, o.extraWords).segment(text, mode)
+	}
+	cut := func(s string) []string {
+		return snap.mergeUserWords(snap.segmenter.Cut(s), d.opts.mergeSpan)
+	}
+	// 共享分词器中没有词典中的词, 先按前缀树切出
+	if snap.shared {
+		base := snap.segmenter.Cut
+		cut = func(s string) []string { return overlaySegment(s, snap.longestMatch, snap.asciiRoots > 0, base) }
+	}
+	if len(o.extraWords) == 0 {
+		return cut(text)
+	}
+	match, ascii := wordsMatcher(o.extraWords)
+	return overlaySegment(text, match, ascii, cut)
+}
+
+// mergeUserWords 按最长匹配合并相邻的词, 使词典中的词不被切开
+// span为参与合并的最大相邻词数
+func (s *snapshot) mergeUserWords(tokens []string, span int) []string {
+	if span < 2 {
+		return tokens
+	}
+
+	result := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		end, word := i+1, tokens[i]
+		joined := tokens[i]
+		for j := i + 1; j < len(tokens) && j < i+span; j++ {
+			joined += tokens[j]
+			if s.contains(joined) {
+				end, word = j+1, joined
+			}
+		}
+		result = append(result, word)
+		i = end
+	}
+	return result
+}
+
+// longestMatch 返回前缀树中从text[i]开始的最长词的结束位置, 不存在时返回0
+func (s *snapshot) longestMatch(text string, i int) int {
+	match, node := 0, s.root
+	for end := i; end < len(text); {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		if node = node.Child(text[end : end+size]); node == nil {
+			break
+		}
+		end += size
+		if node.IsEnd {
+			match = end
+		}
+	}
+	return match
+}
+
+// wordsMatcher 返回按最长匹配查找词条的函数, ascii表示是否存在以ASCII字符开头的词条
+func wordsMatcher(entries []DictEntry) (func(text string, i int) int, bool) {
+	words := make(map[string]bool, len(entries))
+	maxLen, ascii := 0, false
+	for _, entry := range entries {
+		if entry.Content == "" {
+			continue
+		}
+		words[entry.Content] = true
+		maxLen = max(maxLen, utf8.RuneCountInString(entry.Content))
+		ascii = ascii || entry.Content[0] < utf8.RuneSelf
+	}
+
+	return func(text string, i int) int {
+		match, end := 0, i
+		for n := 1; n <= maxLen && end < len(text); n++ {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+			if words[text[i:end]] {
+				match = end
+			}
+		}
+		return match
+	}, ascii
+}
+
+// overlaySegment 先按match的最长匹配切出词, 其余片段交由cut切分
+// match返回从text[i]开始匹配到的词的结束位置, 未匹配时返回0
+// ascii为false表示没有以ASCII字符开头的词, 连续的ASCII字符整段跳过, 不逐字匹配
+func overlaySegment(text string, match func(text string, i int) int, ascii bool, cut func(string) []string) []string {
+	var result []string
+	start := 0 // 尚未切分片段的起始位置
+	for i := 0; i < len(text); {
+		if !ascii && text[i] < utf8.RuneSelf {
+			i = skipASCII(text, i)
+			continue
+		}
+		end := match(text, i)
+		if end == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+
+		if start < i {
+			result = append(result, cut(text[start:i])...)
+		}
+		result = append(result, text[i:end])
+		i, start = end, end
+	}
+	if start < len(text) {
+		result = append(result, cut(text[start:])...)
+	}
+	return result
+}
+
+// skipASCII 返回text中从i开始第一个非ASCII字节的位置, 不存在时返回len(text)
+// 每次检查8个字节, 全部为ASCII时整块跳过
+func skipASCII(text string, i int) int {
+	for ; i+8 <= len(text); i += 8 {
+		chunk := uint64(text[i]) | uint64(text[i+1])<<8 | uint64(text[i+2])<<16 | uint64(text[i+3])<<24 |
+			uint64(text[i+4])<<32 | uint64(text[i+5])<<40 | uint64(text[i+6])<<48 | uint64(text[i+7])<<56
+		if chunk&0x8080808080808080 != 0 {
+			break
+		}
+	}
+	for ; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return i
+		}
+	}
+	return i
+}
@@ -0,0 +1,111 @@
+package participle
+
+import (
+	"fmt"
+
+	"github.com/go-ego/gse"
+)
+
+// Segmenter 分词器, 为引擎提供切分能力; 词典的持久化、前缀树与学习仍由引擎负责
+// 引擎在词典修改时调用AddToken、RemoveToken同步词条, 此时持有写锁; Cut与Find在读锁下并发调用
+// 每个快照使用独立的分词器实例, 重建快照时重新创建并通过LoadDict加载词典中的全部词条
+type Segmenter interface {
+	// Cut 切分文本
+	Cut(text string) []string
+	// AddToken 添加词条, 已存在时以新的词频与词性覆盖
+	AddToken(word string, frequency float64, pos string) error
+	// RemoveToken 移除词条
+	RemoveToken(word string) error
+	// Find 查找词条的词频与词性, ok表示词条存在
+	Find(word string) (frequency float64, pos string, ok bool)
+	// LoadDict 批量加载词条
+	LoadDict(entries []DictEntry) error
+}
+
+// SearchSegmenter 支持细粒度切分的分词器, 未实现时SegmentSearch与SegmentAll使用Cut的结果
+type SearchSegmenter interface {
+	// CutSearch 搜索引擎模式切分, 长词再切出其中的短词
+	CutSearch(text string) []string
+	// CutAll 全模式切分, 切出所有可以成词的词
+	CutAll(text string) []string
+}
+
+// WithSegmenter 使用自定义分词器替换GSE, newSegmenter在每次构建快照时调用, 须返回新的实例
+// 自定义分词器不受WithBaseDict与WithSharedSegmenter影响
+func WithSegmenter(newSegmenter func() (Segmenter, error)) Option {
+	return func(o *options) { o.newSegmenter = newSegmenter }
+}
+
+// WithTokenizer 使用切分函数替换GSE, 适用于不维护词典的自定义切分
+// 与共享分词器相同, 分词时先按最长匹配切出词典中的词, 其余片段交由tokenize切分
+func WithTokenizer(tokenize func(text string) []string) Option {
+	return func(o *options) {
+		o.newSegmenter = func() (Segmenter, error) { return tokenizer(tokenize), nil }
+	}
+}
+
+// tokenizer 将切分函数包装为Segmenter, 不保存词条
+type tokenizer func(text string) []string
+
+// Cut 切分文本
+func (t tokenizer) Cut(text string) []string { return t(text) }
+
+// AddToken 不保存词条
+func (t tokenizer) AddToken(string, float64, string) error { return nil }
+
+// RemoveToken 不保存词条
+func (t tokenizer) RemoveToken(string) error { return nil }
+
+// Find 不保存词条, 始终返回不存在
+func (t tokenizer) Find(string) (float64, string, bool) { return 0, "", false }
+
+// LoadDict 不保存词条
+func (t tokenizer) LoadDict([]DictEntry) error { return nil }
+
+// gseSegmenter 基于GSE的分词器, 引擎的默认分词器
+type gseSegmenter struct {
+	seg *gse.Segmenter
+}
+
+// Cut 使用HMM切分文本
+func (g gseSegmenter) Cut(text string) []string { return g.seg.Cut(text, true) }
+
+// CutSearch 搜索引擎模式切分
+func (g gseSegmenter) CutSearch(text string) []string { return g.seg.CutSearch(text, true) }
+
+// CutAll 全模式切分
+func (g gseSegmenter) CutAll(text string) []string { return g.seg.CutAll(text) }
+
+// AddToken 添加词条, 已存在时重新添加
+func (g gseSegmenter) AddToken(word string, frequency float64, pos string) error {
+	if _, _, err := g.seg.Value(word); err == nil {
+		return g.seg.ReAddToken(word, frequency, pos)
+	}
+	return g.seg.AddToken(word, frequency, pos)
+}
+
+// RemoveToken 移除词条
+func (g gseSegmenter) RemoveToken(word string) error { return g.seg.RemoveToken(word) }
+
+// Find 查找词条, GSE对只是其他词前缀的字符串同样返回ok, 这里只认可完整的词条
+func (g gseSegmenter) Find(word string) (float64, string, bool) {
+	if _, _, err := g.seg.Value(word); err != nil {
+		return 0, "", false
+	}
+	return g.seg.Find(word)
+}
+
+// LoadDict 批量加载词条, 全部添加后重新计算词的距离
+// 不使用LoadDictStr: 其会丢弃词频低于MinTokenFreq的词, 这些词随后无法从分词器中移除
+func (g gseSegmenter) LoadDict(entries []DictEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		if err := g.seg.AddToken(entry.Content, entry.Frequency, entry.Pos); err != nil {
+			return fmt.Errorf("add token %s fail: %v", entry.Content, err)
+		}
+	}
+	g.seg.CalcToken()
+	return nil
+}
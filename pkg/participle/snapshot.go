@@ -0,0 +1,354 @@
+package participle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-ego/gse"
+)
+
+// snapshot 分词引擎快照
+// 前缀树与GSE分词器作为一个整体切换, 正在执行的Segment始终使用其开始时的快照
+// 旧快照在最后一个读者结束后由GC回收
+type snapshot struct {
+	version    uint64     // 快照版本
+	arena      *nodeArena // 前缀树节点分配器
+	childLimit int        // 子节点使用有序切片存储的数量上限
+	root       *TrieNode  // 前缀树根节点
+	segmenter  Segmenter  // 分词器
+	shared     bool       // 分词器中没有词典中的词(共享的基础分词器或切分函数), 分词时先按前缀树切出
+
+	entries int64 // 词条数量
+	nodes   int64 // 前缀树节点数量
+	bytes   int64 // 前缀树估算内存(字节)
+
+	removals int64 // 移除词条次数, 压缩后清零
+
+	asciiRoots int // 以ASCII字符开头的根节点子节点数量, 为0时按最长匹配切分可整段跳过ASCII字符
+}
+
+// newSnapshot 创建只有根节点的空快照
+func newSnapshot(version uint64, o options) *snapshot {
+	arena := newNodeArena()
+	return &snapshot{
+		version:    version,
+		arena:      arena,
+		childLimit: o.childLimit,
+		root:       arena.alloc(),
+		nodes:      1,
+		bytes:      trieNodeBytes,
+	}
+}
+
+// buildSnapshot 从数据库构建一个新的快照, ctx取消时返回ctx的错误
+func buildSnapshot(ctx context.Context, store DictStore, version uint64, o options) (*snapshot, error) {
+	// 初始化前缀树根节点
+	snap := newSnapshot(version, o)
+
+	// 从数据库加载已有词典到前缀树, 组合的命名空间先加载, 同一个词以后加载的为准
+	for _, ns := range o.namespaces() {
+		if err := loadDictionary(ctx, store, snap, namespacePrefix(ns), o.prefetchSize); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("read db load dict fail: %v", err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := snap.initSegmenter(o); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// initSegmenter 初始化快照的分词器
+// 设置了自定义分词器时使用自定义分词器, 启用共享时使用进程内共享的基础分词器,
+// 否则创建独立的GSE分词器; 共享的分词器之外均加载前缀树中的词典
+func (s *snapshot) initSegmenter(o options) error {
+	if o.newSegmenter != nil {
+		seg, err := o.newSegmenter()
+		if err != nil {
+			return fmt.Errorf("create segmenter fail: %v", err)
+		}
+		_, s.shared = seg.(tokenizer)
+		s.segmenter = seg
+		return seg.LoadDict(collectEntries(s.root))
+	}
+
+	s.shared = o.sharedSegmenter
+	if s.shared {
+		seg, err := sharedSegmenter(o.baseDict)
+		s.segmenter = gseSegmenter{seg}
+		return err
+	}
+
+	seg, err := newSegmenter(s.root, o.baseDict)
+	s.segmenter = gseSegmenter{seg}
+	return err
+}
+
+// sharedSegmenters 进程内共享的基础分词器, 每种基础词典一个, 只加载GSE内置词典, 首次使用时初始化
+var sharedSegmenters [baseDictCount]struct {
+	once sync.Once
+	seg  *gse.Segmenter
+	err  error
+}
+
+// sharedSegmenter 获取进程内共享的基础分词器
+func sharedSegmenter(base BaseDict) (*gse.Segmenter, error) {
+	shared := &sharedSegmenters[base]
+	shared.once.Do(func() {
+		shared.seg, shared.err = loadBaseDict(base)
+	})
+	return shared.seg, shared.err
+}
+
+// hmmModel GSE的HMM模型是包级变量, 每个分词器初始化时都会重新写入
+// 进程内只加载一次, 避免重建快照时与其他快照上进行中的分词产生数据竞争
+var hmmModel sync.Once
+
+// loadBaseDict 创建加载了基础词典的GSE分词器
+func loadBaseDict(base BaseDict) (*gse.Segmenter, error) {
+	hmmModel.Do(func() { new(gse.Segmenter).LoadModel() })
+
+	seg := gse.Segmenter{NotLoadHMM: true}
+	var err error
+	switch base {
+	case BaseDictFull:
+		err = seg.LoadDict()
+	case BaseDictSmall:
+		seg.SkipLog = true
+		err = seg.LoadDictEmbed("zh_s")
+	case BaseDictEmpty:
+		seg.SkipLog = true
+		err = seg.LoadDictStr("")
+	default:
+		err = fmt.Errorf("unknown base dict: %d", base)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法初始化GSE分词器: %v", err)
+	}
+	return &seg, nil
+}
+
+// newSegmenter 创建GSE分词器并加载前缀树中的词典
+func newSegmenter(root *TrieNode, base BaseDict) (*gse.Segmenter, error) {
+	// 初始化GSE分词器
+	seg, err := loadBaseDict(base)
+	if err != nil {
+		return nil, err
+	}
+
+	// 从前缀树加载词典到GSE
+	gseSegmenter{seg}.LoadDict(collectEntries(root))
+	return seg, nil
+}
+
+// insert 将词条插入前缀树并更新资源统计, 返回被覆盖的词条
+func (s *snapshot) insert(content string, entry *DictEntry) *DictEntry {
+	node := s.root
+	for _, char := range SplitString(content) {
+		child := node.Child(char)
+		if child == nil {
+			child = s.arena.alloc()
+			if node == s.root && isASCIIChar(char) {
+				s.asciiRoots++
+			}
+			node.setChild(char, child, s.childLimit)
+			s.nodes++
+			s.bytes += trieNodeBytes + trieEdgeBytes + int64(len(char))
+		}
+		node = child
+	}
+
+	prev := node.Entry
+	if node.IsEnd && prev != nil {
+		s.bytes -= entryBytes(prev)
+	} else {
+		s.entries++
+	}
+	node.IsEnd = true
+	node.Entry = entry
+	s.bytes += entryBytes(entry)
+	return prev
+}
+
+// contains 检查前缀树中是否包含指定的词
+func (s *snapshot) contains(content string) bool {
+	node := s.root
+	for _, char := range SplitString(content) {
+		if node = node.Child(char); node == nil {
+			return false
+		}
+	}
+	return node.IsEnd
+}
+
+// lookup 获取前缀树中的词条, 不存在时返回nil
+func (s *snapshot) lookup(content string) *DictEntry {
+	node := s.root
+	for _, char := range SplitString(content) {
+		if node = node.Child(char); node == nil {
+			return nil
+		}
+	}
+	if !node.IsEnd {
+		return nil
+	}
+	return node.Entry
+}
+
+// remove 从前缀树移除词条并剪除不再使用的分支, 返回被移除的词条
+func (s *snapshot) remove(content string) *DictEntry {
+	chars := SplitString(content)
+	path := make([]*TrieNode, 0, len(chars)+1)
+	node := s.root
+	path = append(path, node)
+	for _, char := range chars {
+		node = node.Child(char)
+		if node == nil {
+			return nil
+		}
+		path = append(path, node)
+	}
+	if !node.IsEnd || node.Entry == nil {
+		return nil
+	}
+
+	prev := node.Entry
+	node.IsEnd = false
+	node.Entry = nil
+	s.entries--
+	s.removals++
+	s.bytes -= entryBytes(prev)
+
+	// 自底向上剪除空分支
+	for i := len(chars); i > 0; i-- {
+		child := path[i]
+		if child.IsEnd || child.ChildCount() > 0 {
+			break
+		}
+		path[i-1].deleteChild(chars[i-1])
+		if i == 1 && isASCIIChar(chars[0]) {
+			s.asciiRoots--
+		}
+		s.nodes--
+		s.bytes -= trieNodeBytes + trieEdgeBytes + int64(len(chars[i-1]))
+	}
+	return prev
+}
+
+// countASCIIRoots 统计以ASCII字符开头的根节点子节点数量
+func countASCIIRoots(root *TrieNode) int {
+	n := 0
+	for char := range root.Children() {
+		if isASCIIChar(char) {
+			n++
+		}
+	}
+	return n
+}
+
+// isASCIIChar 判断字符是否为ASCII字符
+func isASCIIChar(char string) bool {
+	return len(char) == 1 && char[0] < utf8.RuneSelf
+}
+
+// apply 将词条应用到前缀树与GSE分词器, 返回撤销函数
+// 带删除标记的词条从前缀树与分词器中移除; GSE更新失败时前缀树已回滚
+func (s *snapshot) apply(entry DictEntry) (undo func(), err error) {
+	if entry.deleted {
+		return s.delete(entry.Content)
+	}
+
+	prev := s.insert(entry.Content, &entry)
+	undoTrie := func() {
+		if prev != nil {
+			s.insert(entry.Content, prev)
+		} else {
+			s.remove(entry.Content)
+		}
+	}
+
+	undoToken, err := s.addToken(entry)
+	if err != nil {
+		undoTrie()
+		return nil, err
+	}
+
+	return func() {
+		undoToken()
+		undoTrie()
+	}, nil
+}
+
+// delete 从前缀树与分词器移除词条, 返回撤销函数, 词条不存在时不做修改
+func (s *snapshot) delete(content string) (undo func(), err error) {
+	prev := s.remove(content)
+	if prev == nil {
+		return func() {}, nil
+	}
+	undoTrie := func() { s.insert(content, prev) }
+
+	if !s.shared {
+		if err := s.segmenter.RemoveToken(content); err != nil {
+			undoTrie()
+			return nil, err
+		}
+	}
+
+	return func() {
+		if !s.shared {
+			s.segmenter.AddToken(prev.Content, prev.Frequency, prev.Pos)
+		}
+		undoTrie()
+	}, nil
+}
+
+// addToken 将词条写入分词器, 返回撤销函数
+// 已存在的词会以新的词频与词性覆盖, 撤销时恢复原值
+func (s *snapshot) addToken(entry DictEntry) (undo func(), err error) {
+	if s.shared {
+		return func() {}, nil
+	}
+
+	seg := s.segmenter
+	prevFreq, prevPos, existed := seg.Find(entry.Content)
+	if err := seg.AddToken(entry.Content, entry.Frequency, entry.Pos); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if existed {
+			seg.AddToken(entry.Content, prevFreq, prevPos)
+		} else {
+			seg.RemoveToken(entry.Content)
+		}
+	}, nil
+}
+
+// planInsert 计算插入词条后新增的词条数与估算内存, 不修改前缀树
+func (s *snapshot) planInsert(content string, entry *DictEntry) (entries, bytes int64) {
+	node := s.root
+	for _, char := range SplitString(content) {
+		if node != nil {
+			node = node.Child(char)
+		}
+		if node == nil {
+			bytes += trieNodeBytes + trieEdgeBytes + int64(len(char))
+		}
+	}
+
+	if node != nil && node.IsEnd && node.Entry != nil {
+		return 0, bytes + entryBytes(entry) - entryBytes(node.Entry)
+	}
+	return 1, bytes + entryBytes(entry)
+}
@@ -0,0 +1,65 @@
+package participle
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSegmentDuringReload(t *testing.T) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err := d.AddWord("快照切换", 10, "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	const text = "快照切换期间分词不受影响"
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// 每次分词都使用开始时的完整快照, 结果须覆盖原文
+				tokens := d.Segment(text)
+				if joined := strings.Join(tokens, ""); joined != text {
+					t.Errorf("Segment = %q, covers %q", tokens, joined)
+					return
+				}
+				if len(tokens) == 0 || tokens[0] != "快照切换" {
+					t.Errorf("Segment = %q, dictionary word lost during reload", tokens)
+					return
+				}
+				d.Contains("快照切换")
+				d.Version()
+			}
+		}()
+	}
+
+	start := d.Version()
+	for i := 0; i < 50; i++ {
+		if err := d.Reload(); err != nil {
+			t.Error(err)
+			break
+		}
+		if err := d.AddWord("期间", float64(i+1), "n"); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if d.Version() <= start {
+		t.Errorf("version %d did not advance from %d", d.Version(), start)
+	}
+}
@@ -0,0 +1,74 @@
+package participle
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitOption 特殊字符切分配置项
+type SplitOption func(*splitOptions)
+
+// splitOptions 特殊字符切分配置
+type splitOptions struct {
+	keepDelimiters bool          // 是否保留分隔符
+	nonBreaking    map[rune]bool // 不作为分隔符的字符
+	breaking       func(rune) bool
+}
+
+// KeepDelimiters 保留分隔符, 每个分隔符作为单独的一段
+func KeepDelimiters() SplitOption {
+	return func(o *splitOptions) { o.keepDelimiters = true }
+}
+
+// NonBreaking 指定不作为分隔符的字符, 如商品编码中的"-"
+func NonBreaking(chars string) SplitOption {
+	return func(o *splitOptions) {
+		for _, r := range chars {
+			o.nonBreaking[r] = true
+		}
+	}
+}
+
+// BreakOn 使用自定义的分隔符集合代替默认的标点、符号与空格
+func BreakOn(chars string) SplitOption {
+	return func(o *splitOptions) {
+		o.breaking = func(r rune) bool { return strings.ContainsRune(chars, r) }
+	}
+}
+
+// BreakFunc 使用自定义函数判断分隔符
+func BreakFunc(f func(rune) bool) SplitOption {
+	return func(o *splitOptions) { o.breaking = f }
+}
+
+// SplitBySpecialChar 按特殊字符切分字符串, 默认以标点、符号与空格为分隔符并丢弃分隔符
+// 返回结果不包含空字符串
+func SplitBySpecialChar(s string, opts ...SplitOption) []string {
+	o := splitOptions{nonBreaking: make(map[rune]bool), breaking: IsPunct}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var parts []string
+	start := 0 // 当前段的起始位置
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if o.nonBreaking[r] || !o.breaking(r) {
+			i += size
+			continue
+		}
+
+		if start < i {
+			parts = append(parts, s[start:i])
+		}
+		if o.keepDelimiters {
+			parts = append(parts, s[i:i+size])
+		}
+		i += size
+		start = i
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
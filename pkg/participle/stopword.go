@@ -0,0 +1,152 @@
+package participle
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultStopwordMinDocRatio 停用词候选的默认最小文档比例
+	defaultStopwordMinDocRatio = 0.3
+	// defaultStopwordMinEntropy 停用词候选的默认最小分布熵
+	defaultStopwordMinEntropy = 0.8
+	// defaultStopwordMinDocs 建议停用词所需的默认最少文档数
+	defaultStopwordMinDocs = 10
+)
+
+// StopwordCandidate 根据语料统计建议的停用词
+type StopwordCandidate struct {
+	Word     string  `json:"word"`      // 词
+	Docs     int     `json:"docs"`      // 包含该词的文档数
+	Count    int     `json:"count"`     // 出现次数
+	DocRatio float64 `json:"doc_ratio"` // 文档比例: 包含该词的文档数/文档总数
+	Entropy  float64 `json:"entropy"`   // 分布熵: 出现次数在各文档间分布的信息熵, 按文档总数归一化到[0,1]
+	Score    float64 `json:"score"`     // 得分: 文档比例与分布熵之积, 越高越像停用词
+}
+
+// StopwordOption 停用词建议配置项
+type StopwordOption func(*stopwordOptions)
+
+// stopwordOptions 停用词建议配置
+type stopwordOptions struct {
+	minDocRatio float64
+	minEntropy  float64
+	minDocs     int
+	limit       int
+}
+
+// StopwordMinDocRatio 设置候选词的最小文档比例, 默认为0.3
+func StopwordMinDocRatio(v float64) StopwordOption {
+	return func(o *stopwordOptions) { o.minDocRatio = v }
+}
+
+// StopwordMinEntropy 设置候选词的最小分布熵, 默认为0.8, 分布越均匀熵越高
+func StopwordMinEntropy(v float64) StopwordOption {
+	return func(o *stopwordOptions) { o.minEntropy = v }
+}
+
+// StopwordMinDocs 设置建议停用词所需的最少文档数, 默认为10, 文档过少时统计没有意义, 不返回候选词
+func StopwordMinDocs(n int) StopwordOption {
+	return func(o *stopwordOptions) { o.minDocs = max(n, 2) }
+}
+
+// StopwordLimit 设置返回的候选词数量上限, 默认为0表示不限制
+func StopwordLimit(n int) StopwordOption {
+	return func(o *stopwordOptions) { o.limit = n }
+}
+
+// stopwordStat 词的文档分布统计
+type stopwordStat struct {
+	docs  int     // 包含该词的文档数
+	count int     // 出现次数
+	clogc float64 // 各文档中出现次数c的c*ln(c)之和, 用于计算分布熵
+}
+
+// SuggestStopwords 从语料中统计停用词候选, 每行为一篇文档, 结果供人工审核
+// 停用词出现在大量文档中(文档比例高), 且在各文档间分布均匀、不集中于少数文档(分布熵高), 携带的信息量低
+// 分布熵为各文档中出现次数占比的信息熵除以ln(文档总数), 结果按得分降序, 空白与特殊符号不参与统计
+func (d *Engine) SuggestStopwords(ctx context.Context, r io.Reader, opts ...StopwordOption) ([]StopwordCandidate, error) {
+	o := stopwordOptions{
+		minDocRatio: defaultStopwordMinDocRatio,
+		minEntropy:  defaultStopwordMinEntropy,
+		minDocs:     defaultStopwordMinDocs,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	stats := make(map[string]*stopwordStat)
+	total := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		doc := scanner.Text()
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		total++
+
+		counts := make(map[string]int)
+		for _, content := range d.Segment(doc) {
+			content = strings.TrimSpace(content)
+			if content == "" || IsAllPunct(content) {
+				continue
+			}
+			counts[content]++
+		}
+		for content, c := range counts {
+			stat := stats[content]
+			if stat == nil {
+				stat = &stopwordStat{}
+				stats[content] = stat
+			}
+			stat.docs++
+			stat.count += c
+			stat.clogc += float64(c) * math.Log(float64(c))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read corpus fail: %v", err)
+	}
+	if total < o.minDocs {
+		return nil, nil
+	}
+
+	var candidates []StopwordCandidate
+	for word, stat := range stats {
+		ratio := float64(stat.docs) / float64(total)
+		// H = ln(T) - Σc*ln(c)/T, T为出现次数
+		n := float64(stat.count)
+		entropy := (math.Log(n) - stat.clogc/n) / math.Log(float64(total))
+		if ratio < o.minDocRatio || entropy < o.minEntropy {
+			continue
+		}
+		candidates = append(candidates, StopwordCandidate{
+			Word:     word,
+			Docs:     stat.docs,
+			Count:    stat.count,
+			DocRatio: ratio,
+			Entropy:  entropy,
+			Score:    ratio * entropy,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Word < candidates[j].Word
+	})
+	if o.limit > 0 && len(candidates) > o.limit {
+		candidates = candidates[:o.limit]
+	}
+	return candidates, nil
+}
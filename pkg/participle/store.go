@@ -0,0 +1,137 @@
+package participle
+
+import (
+	"errors"
+
+	bd "github.com/dgraph-io/badger/v4"
+	"github.com/miajio/nla/pkg/badger"
+)
+
+// ErrKeyNotFound 键不存在
+var ErrKeyNotFound = errors.New("participle: key not found")
+
+// DictStore 词典存储, 保存词条、黑名单、逆文档频率等引擎数据, 默认实现为BadgerStore
+// 键按字节序比较, 非词条数据位于MetaKeyPrefix之下; 实现须支持并发调用
+type DictStore interface {
+	// Get 读取键的值, 不存在时返回ErrKeyNotFound
+	Get(key []byte) ([]byte, error)
+	// Set 写入键值
+	Set(key, value []byte) error
+	// Delete 删除键, 键不存在时不返回错误
+	Delete(key []byte) error
+	// Iterate 按键的字节序遍历以prefix开头的键值, prefix为空时遍历全部
+	// key与value只在回调期间有效; fn返回错误时停止遍历并返回该错误
+	Iterate(prefix []byte, fn func(key, value []byte) error) error
+	// Update 在一个事务中执行fn, fn返回错误时放弃全部修改
+	Update(fn func(tx StoreTxn) error) error
+	// Close 关闭存储
+	Close() error
+}
+
+// StoreTxn 词典存储的事务
+type StoreTxn interface {
+	// Get 读取键的值, 不存在时返回ErrKeyNotFound
+	Get(key []byte) ([]byte, error)
+	// Set 写入键值
+	Set(key, value []byte) error
+	// Delete 删除键
+	Delete(key []byte) error
+}
+
+// PrefixDropper 支持按前缀批量删除的存储, 未实现时逐个删除
+type PrefixDropper interface {
+	// DropPrefix 删除以prefix开头的全部键
+	DropPrefix(prefix []byte) error
+}
+
+// dropPrefix 删除存储中以prefix开头的全部键
+func dropPrefix(store DictStore, prefix []byte) error {
+	if dropper, ok := store.(PrefixDropper); ok {
+		return dropper.DropPrefix(prefix)
+	}
+	var keys [][]byte
+	err := store.Iterate(prefix, func(key, _ []byte) error {
+		keys = append(keys, append([]byte(nil), key...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return store.Update(func(tx StoreTxn) error {
+		for _, key := range keys {
+			if err := tx.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BadgerStore 基于badger的词典存储
+type BadgerStore struct {
+	db *badger.Engine
+}
+
+// NewBadgerStore 创建基于badger的词典存储, 关闭存储时一并关闭数据库
+func NewBadgerStore(db *badger.Engine) *BadgerStore {
+	return &BadgerStore{db: db}
+}
+
+// Engine 获取badger引擎
+func (s *BadgerStore) Engine() *badger.Engine { return s.db }
+
+// Get 读取键的值
+func (s *BadgerStore) Get(key []byte) ([]byte, error) {
+	value, err := s.db.Get(key)
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	return value, err
+}
+
+// Set 写入键值
+func (s *BadgerStore) Set(key, value []byte) error { return s.db.Set(key, value) }
+
+// Delete 删除键
+func (s *BadgerStore) Delete(key []byte) error { return s.db.Del(key) }
+
+// Iterate 按键的字节序遍历以prefix开头的键值
+func (s *BadgerStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return s.db.Scan(prefix, fn)
+}
+
+// Update 在一个事务中执行fn
+func (s *BadgerStore) Update(fn func(tx StoreTxn) error) error {
+	return s.db.Update(func(tx *bd.Txn) error { return fn(badgerTxn{tx}) })
+}
+
+// DropPrefix 删除以prefix开头的全部键
+func (s *BadgerStore) DropPrefix(prefix []byte) error {
+	return s.db.DB().DropPrefix(prefix)
+}
+
+// Close 关闭数据库
+func (s *BadgerStore) Close() error { return s.db.Close() }
+
+// badgerTxn badger事务
+type badgerTxn struct {
+	tx *bd.Txn
+}
+
+// Get 读取键的值
+func (t badgerTxn) Get(key []byte) ([]byte, error) {
+	item, err := t.tx.Get(key)
+	if errors.Is(err, bd.ErrKeyNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+// Set 写入键值
+func (t badgerTxn) Set(key, value []byte) error { return t.tx.Set(key, value) }
+
+// Delete 删除键
+func (t badgerTxn) Delete(key []byte) error { return t.tx.Delete(key) }
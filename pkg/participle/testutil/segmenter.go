@@ -0,0 +1,178 @@
+package testutil
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// Faults 分词器的故障注入开关, 同一Faults可被多个分词器实例共享
+// 设置的错误在清除前持续生效, 传入nil清除对应的故障
+type Faults struct {
+	mu     sync.Mutex
+	add    error
+	remove error
+	load   error
+}
+
+// FailAdd 使AddToken返回err
+func (f *Faults) FailAdd(err error) {
+	f.mu.Lock()
+	f.add = err
+	f.mu.Unlock()
+}
+
+// FailRemove 使RemoveToken返回err
+func (f *Faults) FailRemove(err error) {
+	f.mu.Lock()
+	f.remove = err
+	f.mu.Unlock()
+}
+
+// FailLoad 使LoadDict返回err
+func (f *Faults) FailLoad(err error) {
+	f.mu.Lock()
+	f.load = err
+	f.mu.Unlock()
+}
+
+// Reset 清除全部故障
+func (f *Faults) Reset() {
+	f.mu.Lock()
+	f.add, f.remove, f.load = nil, nil, nil
+	f.mu.Unlock()
+}
+
+// addErr AddToken的故障, f为nil时不注入故障
+func (f *Faults) addErr() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add
+}
+
+// removeErr RemoveToken的故障
+func (f *Faults) removeErr() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.remove
+}
+
+// loadErr LoadDict的故障
+func (f *Faults) loadErr() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load
+}
+
+// Segmenter 基于正向最长匹配的分词器, 不依赖GSE词典, 结果只由已添加的词条决定
+// 未收录的字符逐字切分
+type Segmenter struct {
+	mu     sync.RWMutex
+	words  map[string]participle.DictEntry
+	maxLen int // 最长词条的字符数
+	faults *Faults
+}
+
+// NewSegmenter 创建空的分词器, faults为nil时不注入故障
+func NewSegmenter(faults *Faults) *Segmenter {
+	return &Segmenter{words: make(map[string]participle.DictEntry), faults: faults}
+}
+
+// WithFakeSegmenter 使用Segmenter替换GSE, 每次构建快照时创建新的实例并共享faults
+func WithFakeSegmenter(faults *Faults) participle.Option {
+	return participle.WithSegmenter(func() (participle.Segmenter, error) {
+		return NewSegmenter(faults), nil
+	})
+}
+
+// Cut 按正向最长匹配切分文本
+func (s *Segmenter) Cut(text string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []string
+	for text != "" {
+		// 从起始位置向后逐字扩展, 记录最长的已收录词条, 未匹配时取一个字符
+		_, n := utf8.DecodeRuneInString(text)
+		end, runes := 0, 0
+		for end < len(text) && runes < s.maxLen {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+			runes++
+			if _, ok := s.words[text[:end]]; ok {
+				n = end
+			}
+		}
+		tokens = append(tokens, text[:n])
+		text = text[n:]
+	}
+	return tokens
+}
+
+// AddToken 添加词条, 已存在时以新的词频与词性覆盖
+func (s *Segmenter) AddToken(word string, frequency float64, pos string) error {
+	if err := s.faults.addErr(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addLocked(participle.DictEntry{Content: word, Frequency: frequency, Pos: pos})
+	return nil
+}
+
+// RemoveToken 移除词条
+func (s *Segmenter) RemoveToken(word string) error {
+	if err := s.faults.removeErr(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.words, word)
+	return nil
+}
+
+// Find 查找词条的词频与词性
+func (s *Segmenter) Find(word string) (float64, string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.words[word]
+	return entry.Frequency, entry.Pos, ok
+}
+
+// LoadDict 批量加载词条
+func (s *Segmenter) LoadDict(entries []participle.DictEntry) error {
+	if err := s.faults.loadErr(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.addLocked(entry)
+	}
+	return nil
+}
+
+// Len 返回词条数量
+func (s *Segmenter) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.words)
+}
+
+// addLocked 保存词条, 调用方须持有写锁
+func (s *Segmenter) addLocked(entry participle.DictEntry) {
+	s.words[entry.Content] = entry
+	if n := utf8.RuneCountInString(entry.Content); n > s.maxLen {
+		s.maxLen = n
+	}
+}
@@ -0,0 +1,94 @@
+package testutil
+
+import (
+	"sync"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// Store 可注入读写故障的内存词典存储
+// Close不清空数据, 关闭后可用同一Store重新创建分词引擎, 以模拟进程重启
+type Store struct {
+	*participle.MemoryStore
+
+	mu       sync.Mutex
+	writeErr error
+	readErr  error
+}
+
+// NewStore 创建空的内存词典存储
+func NewStore() *Store {
+	return &Store{MemoryStore: participle.NewMemoryStore()}
+}
+
+// FailWrites 使Set、Delete、Update与DropPrefix返回err, 传入nil恢复写入
+func (s *Store) FailWrites(err error) {
+	s.mu.Lock()
+	s.writeErr = err
+	s.mu.Unlock()
+}
+
+// FailReads 使Get与Iterate返回err, 传入nil恢复读取
+func (s *Store) FailReads(err error) {
+	s.mu.Lock()
+	s.readErr = err
+	s.mu.Unlock()
+}
+
+// faults 读取当前注入的读写故障
+func (s *Store) faults() (write, read error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeErr, s.readErr
+}
+
+// Get 读取键的值
+func (s *Store) Get(key []byte) ([]byte, error) {
+	if _, err := s.faults(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.Get(key)
+}
+
+// Set 写入键值
+func (s *Store) Set(key, value []byte) error {
+	if err, _ := s.faults(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Set(key, value)
+}
+
+// Delete 删除键
+func (s *Store) Delete(key []byte) error {
+	if err, _ := s.faults(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Delete(key)
+}
+
+// Iterate 遍历以prefix开头的键值
+func (s *Store) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	if _, err := s.faults(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Iterate(prefix, fn)
+}
+
+// Update 在一个事务中执行fn
+func (s *Store) Update(fn func(tx participle.StoreTxn) error) error {
+	if err, _ := s.faults(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Update(fn)
+}
+
+// DropPrefix 删除以prefix开头的全部键
+func (s *Store) DropPrefix(prefix []byte) error {
+	if err, _ := s.faults(); err != nil {
+		return err
+	}
+	return s.MemoryStore.DropPrefix(prefix)
+}
+
+// Close 不清空数据
+func (s *Store) Close() error { return nil }
@@ -0,0 +1,104 @@
+// Package testutil 分词引擎测试辅助
+// 提供内存数据库、可注入故障的词典存储与分词器, 以及词条构造方法, 便于下游应用在不落盘的情况下测试
+package testutil
+
+import (
+	"encoding/json"
+
+	bd "github.com/dgraph-io/badger/v4"
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// NewMemoryDB 创建内存badger引擎, 不在磁盘上创建任何文件
+func NewMemoryDB() (*badger.Engine, error) {
+	return badger.New(bd.DefaultOptions("").WithInMemory(true).WithLoggingLevel(bd.WARNING))
+}
+
+// NewEngine 创建基于内存数据库的分词引擎, 并将词条预置到opts指定的命名空间
+func NewEngine(entries []participle.DictEntry, opts ...participle.Option) (*participle.Engine, error) {
+	db, err := NewMemoryDB()
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := participle.New(db, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return engine, nil
+	}
+	if err := SeedNamespace(participle.NewBadgerStore(db), engine.Namespace(), entries...); err != nil {
+		engine.Close()
+		return nil, err
+	}
+	if err := engine.Reload(); err != nil {
+		engine.Close()
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Seed 将词条直接写入数据库的默认命名空间, 写入后需重新创建或Reload分词引擎才会生效
+func Seed(db *badger.Engine, entries ...participle.DictEntry) error {
+	return SeedNamespace(participle.NewBadgerStore(db), "", entries...)
+}
+
+// SeedNamespace 将词条直接写入词典存储的命名空间, namespace为空表示默认命名空间
+// 写入后需重新创建或Reload绑定该命名空间的分词引擎才会生效
+func SeedNamespace(store participle.DictStore, namespace string, entries ...participle.DictEntry) error {
+	return store.Update(func(tx participle.StoreTxn) error {
+		for _, entry := range entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := tx.Set(participle.EntryKey(namespace, entry.Content), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EntryOption 词条构造配置项
+type EntryOption func(*participle.DictEntry)
+
+// WithFrequency 设置词频
+func WithFrequency(frequency float64) EntryOption {
+	return func(e *participle.DictEntry) { e.Frequency = frequency }
+}
+
+// WithPos 设置词性
+func WithPos(pos string) EntryOption {
+	return func(e *participle.DictEntry) { e.Pos = pos }
+}
+
+// WithExamples 设置样例句子
+func WithExamples(examples ...string) EntryOption {
+	return func(e *participle.DictEntry) { e.Examples = examples }
+}
+
+// Entry 构造词条, 默认词频与词性与LearnFromText学习到的新词一致(1000, nz)
+func Entry(content string, opts ...EntryOption) participle.DictEntry {
+	entry := participle.DictEntry{
+		Content:   content,
+		Frequency: 1000.0,
+		Pos:       "nz",
+	}
+	for _, opt := range opts {
+		opt(&entry)
+	}
+	return entry
+}
+
+// Entries 使用默认词频与词性批量构造词条
+func Entries(contents ...string) []participle.DictEntry {
+	entries := make([]participle.DictEntry, 0, len(contents))
+	for _, content := range contents {
+		entries = append(entries, Entry(content))
+	}
+	return entries
+}
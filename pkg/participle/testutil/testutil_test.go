@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+func TestSeedNamespace(t *testing.T) {
+	store := NewStore()
+	if err := SeedNamespace(store, "tenant", Entry("租户词条", WithFrequency(10))); err != nil {
+		t.Fatal(err)
+	}
+	if err := SeedNamespace(store, "", Entry("默认词条")); err != nil {
+		t.Fatal(err)
+	}
+
+	tenant, err := participle.NewWithStore(t.Context(), store, participle.WithBaseDict(participle.BaseDictEmpty), participle.WithNamespace("tenant"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+	if !tenant.Contains("租户词条") || tenant.Contains("默认词条") {
+		t.Errorf("tenant namespace sees 租户词条=%v 默认词条=%v, want true false", tenant.Contains("租户词条"), tenant.Contains("默认词条"))
+	}
+
+	engine, err := NewEngine(Entries("命名空间词条"), participle.WithBaseDict(participle.BaseDictEmpty), participle.WithNamespace("tenant"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if !engine.Contains("命名空间词条") {
+		t.Error("NewEngine did not seed the engine namespace")
+	}
+}
+
+func TestFakeSegmenter(t *testing.T) {
+	faults := &Faults{}
+	engine, err := NewEngine(Entries("自然语言", "语言处理"), WithFakeSegmenter(faults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	if got, want := engine.Segment("自然语言处理"), []string{"自然语言", "处", "理"}; !slices.Equal(got, want) {
+		t.Errorf("Segment = %q, want %q", got, want)
+	}
+
+	errFault := errors.New("segmenter fault")
+	faults.FailAdd(errFault)
+	if err := engine.AddWord("处理", 10, "v"); err == nil {
+		t.Error("AddWord succeeded with an injected fault")
+	}
+	faults.Reset()
+	if err := engine.AddWord("处理", 10, "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := engine.Segment("自然语言处理"), []string{"自然语言", "处理"}; !slices.Equal(got, want) {
+		t.Errorf("Segment = %q, want %q", got, want)
+	}
+}
+
+func TestStoreFaults(t *testing.T) {
+	store := NewStore()
+	errDown := errors.New("store down")
+	store.FailWrites(errDown)
+	if err := store.Set([]byte("k"), []byte("v")); !errors.Is(err, errDown) {
+		t.Errorf("Set = %v, want %v", err, errDown)
+	}
+	store.FailWrites(nil)
+	if err := store.Set([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	store.FailReads(errDown)
+	if _, err := store.Get([]byte("k")); !errors.Is(err, errDown) {
+		t.Errorf("Get = %v, want %v", err, errDown)
+	}
+	store.FailReads(nil)
+
+	// 关闭后数据仍然保留
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.Get([]byte("k")); err != nil || string(value) != "v" {
+		t.Errorf("Get after Close = %q, %v", value, err)
+	}
+}
@@ -0,0 +1,262 @@
+package participle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrTokenOffset 无法在原文中定位分词结果中的词
+var ErrTokenOffset = errors.New("participle: cannot locate token in text")
+
+// TokenType 词的粗粒度类型
+type TokenType int
+
+const (
+	TokenOther  TokenType = iota // 其他
+	TokenHan                     // 含汉字
+	TokenLatin                   // 拉丁字母, 可含数字
+	TokenNumber                  // 数字
+	TokenPunct                   // 标点、符号与空白
+	TokenEmoji                   // 表情符号
+	TokenURL                     // 网址
+	TokenPhone                   // 电话号码
+)
+
+// String 类型名称
+func (t TokenType) String() string {
+	switch t {
+	case TokenHan:
+		return "HAN"
+	case TokenLatin:
+		return "LATIN"
+	case TokenNumber:
+		return "NUMBER"
+	case TokenPunct:
+		return "PUNCT"
+	case TokenEmoji:
+		return "EMOJI"
+	case TokenURL:
+		return "URL"
+	case TokenPhone:
+		return "PHONE"
+	default:
+		return "OTHER"
+	}
+}
+
+// Token 带类型的词
+type Token struct {
+	Text string    // 词
+	Type TokenType // 类型
+
+	// 以下字段仅由SegmentPos填写
+	Pos       string  // 词性, 未知时为空
+	Frequency float64 // 词频, 未知时为0
+	Learned   bool    // 是否来自自定义词典(含学习到的新词)
+
+	// 以下字段仅由SegmentWithOffsets填写, 均为左闭右开区间
+	Start     int // 在原文中的起始字节位置
+	End       int // 在原文中的结束字节位置
+	RuneStart int // 在原文中的起始字符位置
+	RuneEnd   int // 在原文中的结束字符位置
+}
+
+var (
+	// urlPattern 网址, 分词前整体切出以免被拆开
+	urlPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[a-z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+`)
+	// phonePattern 手机号或带区号的固定电话
+	phonePattern = regexp.MustCompile(`^(?:(?:\+?86)?1[3-9]\d{9}|0\d{2,3}-?\d{7,8})$`)
+)
+
+// SegmentTokens 对文本进行分词并标注每个词的类型
+// 网址在分词前整体切出, 其余片段按Segment切分
+func (d *Engine) SegmentTokens(text string, opts ...SegmentOption) []Token {
+	var tokens []Token
+	appendSegments := func(s string) {
+		for _, content := range d.Segment(s, opts...) {
+			tokens = append(tokens, Token{Text: content, Type: classifyToken(content)})
+		}
+	}
+
+	start := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		if start < loc[0] {
+			appendSegments(text[start:loc[0]])
+		}
+		tokens = append(tokens, Token{Text: text[loc[0]:loc[1]], Type: TokenURL})
+		start = loc[1]
+	}
+	if start < len(text) {
+		appendSegments(text[start:])
+	}
+	return tokens
+}
+
+// SegmentPos 对文本进行分词并标注每个词的类型、词性与词频
+// 自定义词典中的词取词典中的词性与词频并标记Learned, 临时词条取临时词条的词性与词频,
+// 其余词取自GSE基础词典, 均不存在时词性为空; 指定WithJoinRules时标注后按规则合并相邻的词
+func (d *Engine) SegmentPos(text string, opts ...SegmentOption) []Token {
+	var o segmentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	extra := make(map[string]DictEntry, len(o.extraWords))
+	for _, entry := range o.extraWords {
+		extra[entry.Content] = entry
+	}
+
+	tokens := d.SegmentTokens(text, opts...)
+
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+	snap := d.snap.Load()
+	for i := range tokens {
+		token := &tokens[i]
+		if entry, ok := extra[token.Text]; ok {
+			token.Pos, token.Frequency = entry.Pos, entry.Frequency
+			continue
+		}
+		if entry := snap.lookup(token.Text); entry != nil {
+			token.Pos, token.Frequency, token.Learned = entry.Pos, entry.Frequency, true
+			continue
+		}
+		if freq, pos, ok := snap.segmenter.Find(token.Text); ok && pos != "" {
+			token.Pos, token.Frequency = pos, freq
+		}
+	}
+	return JoinTokens(tokens, o.joinRules)
+}
+
+// SegmentWithOffsets 对文本进行分词并标注每个词的类型与在原文中的字节、字符位置
+// 分词器会将字母转为小写、将非法UTF-8字节替换为U+FFFD, 因此按字符逐个比较原文与其小写形式来定位词, 原文片段可通过text[Start:End]取得
+// 某个词无法在原文中定位时返回ErrTokenOffset
+func (d *Engine) SegmentWithOffsets(text string, opts ...SegmentOption) ([]Token, error) {
+	tokens := d.SegmentTokens(text, opts...)
+	offset, runes := 0, 0
+	for i := range tokens {
+		token := &tokens[i]
+		start, end, ok := locateToken(text, offset, token.Text)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q at byte %d", ErrTokenOffset, token.Text, offset)
+		}
+
+		token.Start, token.End = start, end
+		token.RuneStart = runes + utf8.RuneCountInString(text[offset:start])
+		token.RuneEnd = token.RuneStart + utf8.RuneCountInString(text[start:end])
+		offset, runes = end, token.RuneEnd
+	}
+	return tokens, nil
+}
+
+// locateToken 从from开始查找词在原文中第一次出现的位置, 分词时被过滤的片段会被跳过
+func locateToken(text string, from int, token string) (start, end int, ok bool) {
+	for start = from; start < len(text); {
+		if end, ok = matchToken(text, start, token); ok {
+			return start, end, true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		start += size
+	}
+	return from, from, token == ""
+}
+
+// matchToken 判断原文从pos开始是否与词一致, 返回原文中的结束位置
+// 每个字符与原文或其小写形式比较, 非法字节对应U+FFFD
+func matchToken(text string, pos int, token string) (int, bool) {
+	for token != "" {
+		if pos >= len(text) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		orig := text[pos : pos+size]
+		if r == utf8.RuneError && size == 1 {
+			orig = string(utf8.RuneError)
+		}
+		if strings.HasPrefix(token, orig) {
+			token = token[len(orig):]
+		} else if lower := strings.ToLower(string(r)); strings.HasPrefix(token, lower) {
+			token = token[len(lower):]
+		} else {
+			return 0, false
+		}
+		pos += size
+	}
+	return pos, true
+}
+
+// classifyToken 判断词的类型
+func classifyToken(s string) TokenType {
+	if s == "" {
+		return TokenOther
+	}
+	if phonePattern.MatchString(s) {
+		return TokenPhone
+	}
+	if urlPattern.FindString(s) == s {
+		return TokenURL
+	}
+
+	var han, letter, digit, punct, emoji, other bool
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han = true
+		case isEmoji(r):
+			emoji = true
+		case unicode.In(r, unicode.Latin):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		case IsPunct(r) || unicode.IsSpace(r):
+			punct = true
+		default:
+			other = true
+		}
+	}
+
+	switch {
+	case han:
+		return TokenHan
+	case emoji && !letter && !digit && !other:
+		return TokenEmoji
+	case letter && !other:
+		return TokenLatin
+	case digit && !letter && !other:
+		if punct && !isNumeric(s) {
+			return TokenOther
+		}
+		return TokenNumber
+	case punct && !other:
+		return TokenPunct
+	default:
+		return TokenOther
+	}
+}
+
+// isNumeric 判断是否为带小数点、千分位或百分号的数字
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' && r != '%' && r != '-' && r != '+' {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmoji 判断字符是否为表情符号或其组合字符
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 表情、图形符号与国旗
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号与装饰符号
+		return true
+	case r == 0x200D || r == 0xFE0F || r == 0x20E3: // 零宽连接符、变体选择符与组合键帽
+		return true
+	default:
+		return false
+	}
+}
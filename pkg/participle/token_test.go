@@ -0,0 +1,46 @@
+package participle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegmentWithOffsetsFolding(t *testing.T) {
+	d, err := NewMemory(WithBaseDict(BaseDictEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// "İ"转为小写后由2字节变为1字节, 非法字节被替换为3字节的U+FFFD, 位置须仍以原文计算
+	for _, text := range []string{"İİ", "İİ和İstanbul", "\xe5桥ABC", "Straße ΣΑΣ"} {
+		tokens, err := d.SegmentWithOffsets(text)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		var spans []string
+		end := 0
+		for _, token := range tokens {
+			if token.Start != end {
+				t.Errorf("%q: token %q starts at %d, previous ended at %d", text, token.Text, token.Start, end)
+			}
+			spans = append(spans, text[token.Start:token.End])
+			end = token.End
+		}
+		if joined := strings.Join(spans, ""); joined != text {
+			t.Errorf("%q: spans %q cover %q", text, spans, joined)
+		}
+	}
+}
+
+func TestLocateTokenMissing(t *testing.T) {
+	if _, _, ok := locateToken("南京市", 0, "北京"); ok {
+		t.Error("located a token that is not in the text")
+	}
+	if start, end, ok := locateToken("ABC", 3, ""); !ok || start != 3 || end != 3 {
+		t.Errorf("empty token at end = [%d,%d) %v, want [3,3) true", start, end, ok)
+	}
+	if start, end, ok := locateToken("x İİ", 0, "ii"); !ok || start != 2 || end != 6 {
+		t.Errorf("folded token = [%d,%d) %v, want [2,6) true", start, end, ok)
+	}
+}
@@ -1,17 +1,110 @@
 package participle
 
+import (
+	"iter"
+	"sort"
+)
+
+// defaultChildLimit 子节点使用有序切片存储的默认数量上限
+const defaultChildLimit = 8
+
 // TrieNode 前缀树节点
 type TrieNode struct {
-	Children map[string]*TrieNode // 子节点，使用完整字符作为键
-	IsEnd    bool                 // 是否是一个词的结尾
-	Entry    *DictEntry           // 如果是词尾，存储词条信息
+	children children   // 子节点，使用完整字符作为键
+	IsEnd    bool       // 是否是一个词的结尾
+	Entry    *DictEntry // 如果是词尾，存储词条信息
+}
+
+// children 子节点容器
+// 绝大多数节点只有少量子节点, 使用按字符排序的切片比map更省内存且查找更快, 数量超过上限后转为map
+type children struct {
+	keys  []string             // 切片表示: 字符, 升序
+	nodes []*TrieNode          // 切片表示: 与keys一一对应的子节点
+	m     map[string]*TrieNode // map表示, 非nil时切片为空
 }
 
 // NewTrieNode 创建一个新的前缀树节点
 func NewTrieNode() *TrieNode {
-	return &TrieNode{
-		Children: make(map[string]*TrieNode),
-		IsEnd:    false,
-		Entry:    nil,
+	return &TrieNode{}
+}
+
+// Child 获取字符对应的子节点, 不存在时返回nil
+func (n *TrieNode) Child(char string) *TrieNode {
+	if n.children.m != nil {
+		return n.children.m[char]
+	}
+	for i, key := range n.children.keys {
+		if key == char {
+			return n.children.nodes[i]
+		}
+	}
+	return nil
+}
+
+// ChildCount 子节点数量
+func (n *TrieNode) ChildCount() int {
+	if n.children.m != nil {
+		return len(n.children.m)
+	}
+	return len(n.children.keys)
+}
+
+// Children 遍历子节点, 切片表示时按字符升序, map表示时顺序不定
+func (n *TrieNode) Children() iter.Seq2[string, *TrieNode] {
+	return func(yield func(string, *TrieNode) bool) {
+		if n.children.m != nil {
+			for char, child := range n.children.m {
+				if !yield(char, child) {
+					return
+				}
+			}
+			return
+		}
+		for i, char := range n.children.keys {
+			if !yield(char, n.children.nodes[i]) {
+				return
+			}
+		}
+	}
+}
+
+// setChild 设置字符对应的子节点, 子节点数超过limit时转为map, limit为0表示始终使用map
+func (n *TrieNode) setChild(char string, child *TrieNode, limit int) {
+	c := &n.children
+	if c.m == nil && (limit <= 0 || len(c.keys) >= limit) && n.Child(char) == nil {
+		c.m = make(map[string]*TrieNode, len(c.keys)+1)
+		for i, key := range c.keys {
+			c.m[key] = c.nodes[i]
+		}
+		c.keys, c.nodes = nil, nil
+	}
+	if c.m != nil {
+		c.m[char] = child
+		return
+	}
+
+	i := sort.SearchStrings(c.keys, char)
+	if i < len(c.keys) && c.keys[i] == char {
+		c.nodes[i] = child
+		return
+	}
+	c.keys = append(c.keys, "")
+	c.nodes = append(c.nodes, nil)
+	copy(c.keys[i+1:], c.keys[i:])
+	copy(c.nodes[i+1:], c.nodes[i:])
+	c.keys[i], c.nodes[i] = char, child
+}
+
+// deleteChild 删除字符对应的子节点
+func (n *TrieNode) deleteChild(char string) {
+	c := &n.children
+	if c.m != nil {
+		delete(c.m, char)
+		return
+	}
+	i := sort.SearchStrings(c.keys, char)
+	if i < len(c.keys) && c.keys[i] == char {
+		c.keys = append(c.keys[:i], c.keys[i+1:]...)
+		c.nodes = append(c.nodes[:i], c.nodes[i+1:]...)
 	}
 }
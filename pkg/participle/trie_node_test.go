@@ -0,0 +1,96 @@
+package participle
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// benchEntries 基准测试使用的合成词条数量
+const benchEntries = 100_000
+
+// syntheticWords 生成n个2到4字的合成词, 字符取自常用汉字区间, 种子固定以便前后对比
+func syntheticWords(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	words := make([]string, n)
+	for i := range words {
+		chars := make([]rune, 2+r.Intn(3))
+		for j := range chars {
+			chars[j] = rune(0x4e00 + r.Intn(3000))
+		}
+		words[i] = string(chars)
+	}
+	return words
+}
+
+// childLimits 对比的子节点表示: 0为始终使用map, 其余为有序切片的数量上限
+var childLimits = []int{0, defaultChildLimit}
+
+func TestTrieNodeChildren(t *testing.T) {
+	for _, limit := range []int{0, 1, 2, defaultChildLimit} {
+		node := NewTrieNode()
+		chars := []string{"丙", "甲", "丁", "乙", "a"}
+		for _, char := range chars {
+			node.setChild(char, NewTrieNode(), limit)
+		}
+		if node.ChildCount() != len(chars) {
+			t.Fatalf("limit %d: ChildCount = %d, want %d", limit, node.ChildCount(), len(chars))
+		}
+		if wantMap := limit < len(chars); (node.children.m != nil) != wantMap {
+			t.Errorf("limit %d: map representation = %v, want %v", limit, node.children.m != nil, wantMap)
+		}
+		for _, char := range chars {
+			if node.Child(char) == nil {
+				t.Errorf("limit %d: child %q missing", limit, char)
+			}
+		}
+		node.deleteChild("丁")
+		if node.Child("丁") != nil || node.ChildCount() != len(chars)-1 {
+			t.Errorf("limit %d: child not deleted", limit)
+		}
+		// 切片表示按字符升序遍历
+		if node.children.m == nil {
+			prev := ""
+			for char := range node.Children() {
+				if char <= prev {
+					t.Errorf("limit %d: children out of order: %q after %q", limit, char, prev)
+				}
+				prev = char
+			}
+		}
+	}
+}
+
+func BenchmarkTrieInsert(b *testing.B) {
+	words := syntheticWords(benchEntries)
+	entry := &DictEntry{Frequency: 1}
+	for _, limit := range childLimits {
+		b.Run("limit="+strconv.Itoa(limit), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				snap := newSnapshot(0, options{childLimit: limit})
+				for _, word := range words {
+					snap.insert(word, entry)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTrieContains(b *testing.B) {
+	words := syntheticWords(benchEntries)
+	entry := &DictEntry{Frequency: 1}
+	for _, limit := range childLimits {
+		b.Run("limit="+strconv.Itoa(limit), func(b *testing.B) {
+			snap := newSnapshot(0, options{childLimit: limit})
+			for _, word := range words {
+				snap.insert(word, entry)
+			}
+			i := 0
+			for b.Loop() {
+				snap.contains(words[i%len(words)])
+				i++
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package participle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"strings"
+	"testing"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+)
+
+// propertyAlphabet 随机词的字符集, 字符较少以产生大量公共前缀, 含ASCII字符以覆盖asciiRoots统计
+var propertyAlphabet = []string{"中", "国", "人", "民", "大", "a", "B", "1"}
+
+// randomWord 生成1到5个字符的随机词
+func randomWord(r *rand.Rand) string {
+	var b strings.Builder
+	for n := 1 + r.Intn(5); n > 0; n-- {
+		b.WriteString(propertyAlphabet[r.Intn(len(propertyAlphabet))])
+	}
+	return b.String()
+}
+
+// countNodes 统计前缀树中可达的节点数量
+func countNodes(node *TrieNode) int64 {
+	n := int64(1)
+	for _, child := range node.Children() {
+		n += countNodes(child)
+	}
+	return n
+}
+
+// storedEntries 读取存储中默认命名空间的全部词条
+func storedEntries(t *testing.T, store DictStore) map[string]float64 {
+	t.Helper()
+	stored := make(map[string]float64)
+	err := store.Iterate(nil, func(key, value []byte) error {
+		if bytes.HasPrefix(key, []byte(MetaKeyPrefix)) {
+			return nil
+		}
+		var entry DictEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return err
+		}
+		stored[string(key)] = entry.Frequency
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+// checkConsistent 检查前缀树、统计信息与存储均与参照表一致
+func checkConsistent(t *testing.T, d *Engine, store DictStore, ref map[string]float64) {
+	t.Helper()
+	walked := make(map[string]float64)
+	d.Walk(func(entry DictEntry) bool {
+		walked[entry.Content] = entry.Frequency
+		return true
+	})
+	if !maps.Equal(walked, ref) {
+		t.Fatalf("trie has %d words, reference has %d: %v != %v", len(walked), len(ref), walked, ref)
+	}
+	if stored := storedEntries(t, store); !maps.Equal(stored, ref) {
+		t.Fatalf("store has %d words, reference has %d: %v != %v", len(stored), len(ref), stored, ref)
+	}
+
+	snap := d.snap.Load()
+	if snap.entries != int64(len(ref)) {
+		t.Fatalf("entries = %d, want %d", snap.entries, len(ref))
+	}
+	if nodes := countNodes(snap.root); snap.nodes != nodes {
+		t.Fatalf("nodes = %d, reachable %d", snap.nodes, nodes)
+	}
+	if roots := countASCIIRoots(snap.root); snap.asciiRoots != roots {
+		t.Fatalf("asciiRoots = %d, actual %d", snap.asciiRoots, roots)
+	}
+}
+
+func TestTrieMatchesReference(t *testing.T) {
+	stores := map[string]func(t *testing.T) DictStore{
+		"memory": func(t *testing.T) DictStore { return NewMemoryStore() },
+		"badger": func(t *testing.T) DictStore {
+			db, err := badger.New(bd.DefaultOptions("").WithInMemory(true).WithLoggingLevel(bd.WARNING))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return NewBadgerStore(db)
+		},
+	}
+
+	for name, newStore := range stores {
+		for seed := int64(1); seed <= 8; seed++ {
+			t.Run(fmt.Sprintf("%s/seed%d", name, seed), func(t *testing.T) {
+				store := newStore(t)
+				d, err := NewWithStore(t.Context(), store, WithBaseDict(BaseDictEmpty))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer d.Close()
+
+				r := rand.New(rand.NewSource(seed))
+				ref := make(map[string]float64)
+				for i := 0; i < 400; i++ {
+					word := randomWord(r)
+					_, exists := ref[word]
+					// 已有的词删除的概率更高, 使词典大小在增删之间保持波动
+					if r.Intn(3) == 0 || (exists && r.Intn(2) == 0) {
+						err := d.DeleteWord(word)
+						switch {
+						case exists && err != nil:
+							t.Fatalf("op %d: delete %q: %v", i, word, err)
+						case !exists && !errors.Is(err, ErrWordNotFound):
+							t.Fatalf("op %d: delete missing %q: %v, want ErrWordNotFound", i, word, err)
+						}
+						delete(ref, word)
+					} else {
+						freq := float64(1 + r.Intn(100))
+						if err := d.AddWord(word, freq, "n"); err != nil {
+							t.Fatalf("op %d: add %q: %v", i, word, err)
+						}
+						ref[word] = freq
+					}
+
+					if d.Contains(word) != (ref[word] != 0) {
+						t.Fatalf("op %d: Contains(%q) = %v, reference %v", i, word, d.Contains(word), ref[word] != 0)
+					}
+					if i%20 == 0 {
+						checkConsistent(t, d, store, ref)
+					}
+				}
+				checkConsistent(t, d, store, ref)
+
+				// 从存储重新加载后应得到相同的词典
+				if err := d.Reload(); err != nil {
+					t.Fatal(err)
+				}
+				checkConsistent(t, d, store, ref)
+			})
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package participle
+
+import (
+	"context"
+	"time"
+)
+
+// warmupText 预热使用的样例文本, 覆盖中文、英文、数字与标点
+const warmupText = "欢迎来到直播间，今天这款产品只要9.9元，3天内发货。Welcome to nla!"
+
+// WarmupStat 预热组件耗时
+type WarmupStat struct {
+	Component string        `json:"component"` // 组件名称
+	Duration  time.Duration `json:"duration"`  // 耗时
+}
+
+// warmupStep 预热步骤
+type warmupStep struct {
+	component string
+	run       func(snap *snapshot)
+}
+
+// Warmup 预热分词引擎
+// 遍历前缀树并使用样例文本驱动分词器的各个切分模式, 使首次请求不再承担冷启动开销
+// 返回每个组件的耗时, ctx取消时返回已完成部分的统计与ctx错误
+func (d *Engine) Warmup(ctx context.Context) ([]WarmupStat, error) {
+	snap := d.snap.Load()
+
+	steps := []warmupStep{
+		{"trie", func(snap *snapshot) { countTrieNodes(snap.root) }},
+		{"segmenter", func(snap *snapshot) {
+			snap.segmenter.Cut(warmupText)
+			if seg, ok := snap.segmenter.(SearchSegmenter); ok {
+				seg.CutSearch(warmupText)
+			}
+		}},
+	}
+
+	stats := make([]WarmupStat, 0, len(steps))
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		start := time.Now()
+		d.rw.RLock()
+		step.run(snap)
+		d.rw.RUnlock()
+		stats = append(stats, WarmupStat{Component: step.component, Duration: time.Since(start)})
+	}
+	return stats, nil
+}
+
+// countTrieNodes 统计前缀树节点数
+func countTrieNodes(node *TrieNode) int {
+	count := 1
+	for _, child := range node.Children() {
+		count += countTrieNodes(child)
+	}
+	return count
+}
@@ -0,0 +1,252 @@
+package pattern
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+const (
+	// Slot 模板中的槽位
+	Slot = "<*>"
+	// templateKeyPrefix 模板的键前缀
+	templateKeyPrefix = participle.MetaKeyPrefix + "template:"
+	// defaultSimilarity 合并到已有模板所需的最小相似度
+	defaultSimilarity = 0.6
+	// defaultSupport 参与匹配的模板所需的最少消息数
+	defaultSupport = 2
+)
+
+// Template 从重复消息中归纳出的模板
+type Template struct {
+	ID     int      `json:"id"`     // 模板ID
+	Tokens []string `json:"tokens"` // 模板词, 槽位为Slot
+	Count  int      `json:"count"`  // 归纳该模板的消息数
+}
+
+// String 模板文本, 连续槽位合并为一个
+func (t *Template) String() string {
+	var b strings.Builder
+	for i, token := range t.Tokens {
+		if token == Slot && i > 0 && t.Tokens[i-1] == Slot {
+			continue
+		}
+		b.WriteString(token)
+	}
+	return b.String()
+}
+
+// Templates 消息模板库, 模板保存在数据库中, 可与词典共用数据库
+type Templates struct {
+	db     *badger.Engine
+	engine *participle.Engine
+
+	mu     sync.RWMutex
+	items  []*Template
+	nextID int
+
+	similarity float64
+	support    int
+}
+
+// New 创建模板库并从数据库加载已有模板
+func New(db *badger.Engine, engine *participle.Engine) (*Templates, error) {
+	t := &Templates{db: db, engine: engine, similarity: defaultSimilarity, support: defaultSupport}
+
+	err := db.View(func(tx *bd.Txn) error {
+		opts := bd.DefaultIteratorOptions
+		opts.Prefix = []byte(templateKeyPrefix)
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var tpl Template
+				if err := json.Unmarshal(val, &tpl); err != nil {
+					return err
+				}
+				t.items = append(t.items, &tpl)
+				t.nextID = max(t.nextID, tpl.ID+1)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load templates fail: %v", err)
+	}
+	sort.Slice(t.items, func(i, j int) bool { return t.items[i].ID < t.items[j].ID })
+	return t, nil
+}
+
+// span 词在原文中的字节范围
+type span struct {
+	start, end int
+}
+
+// tokenize 分词, 去掉空白, 数字、电话与网址直接作为槽位
+// spans为各词在原文中的位置, 用于取回未经小写处理的槽位值
+func (t *Templates) tokenize(text string) (tokens []string, spans []span) {
+	offset := 0
+	for _, token := range t.engine.SegmentTokens(text) {
+		start := offset
+		offset += len(token.Text)
+		if strings.TrimSpace(token.Text) == "" {
+			continue
+		}
+		spans = append(spans, span{start, min(offset, len(text))})
+		switch token.Type {
+		case participle.TokenNumber, participle.TokenPhone, participle.TokenURL:
+			tokens = append(tokens, Slot)
+		default:
+			tokens = append(tokens, token.Text)
+		}
+	}
+	return tokens, spans
+}
+
+// similarity 计算消息与模板的相似度: 非槽位位置上相同词的比例
+func similarity(tpl, tokens []string) float64 {
+	if len(tpl) != len(tokens) {
+		return 0
+	}
+	same, literal := 0, 0
+	for i, token := range tpl {
+		if token == Slot {
+			continue
+		}
+		literal++
+		if token == tokens[i] {
+			same++
+		}
+	}
+	if literal == 0 {
+		return 0
+	}
+	return float64(same) / float64(literal)
+}
+
+// Learn 从消息中归纳模板并保存
+// 词数相同且相似度足够的消息合并到同一模板, 不同的位置成为槽位
+func (t *Templates) Learn(texts []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := make(map[int]*Template)
+	for _, text := range texts {
+		tokens, _ := t.tokenize(text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		var best *Template
+		bestScore := 0.0
+		for _, tpl := range t.items {
+			if score := similarity(tpl.Tokens, tokens); score >= t.similarity && score > bestScore {
+				best, bestScore = tpl, score
+			}
+		}
+
+		if best == nil {
+			best = &Template{ID: t.nextID, Tokens: tokens}
+			t.nextID++
+			t.items = append(t.items, best)
+		} else {
+			for i, token := range tokens {
+				if best.Tokens[i] != token {
+					best.Tokens[i] = Slot
+				}
+			}
+		}
+		best.Count++
+		changed[best.ID] = best
+	}
+
+	return t.db.Update(func(tx *bd.Txn) error {
+		for id, tpl := range changed {
+			data, err := json.Marshal(tpl)
+			if err != nil {
+				return err
+			}
+			if err := tx.Set([]byte(fmt.Sprintf("%s%08d", templateKeyPrefix, id)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List 获取所有模板, 按消息数降序
+func (t *Templates) List() []Template {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]Template, 0, len(t.items))
+	for _, tpl := range t.items {
+		list = append(list, Template{ID: tpl.ID, Tokens: append([]string(nil), tpl.Tokens...), Count: tpl.Count})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Count > list[j].Count })
+	return list
+}
+
+// Match 使用模板匹配消息, 返回匹配的模板与各槽位的值
+// 只使用至少由两条消息归纳出的模板; 连续槽位合并为一个, 槽位可匹配任意多个词
+func (t *Templates) Match(text string) (*Template, []string, bool) {
+	tokens, spans := t.tokenize(text)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, tpl := range t.items {
+		if tpl.Count < t.support {
+			continue
+		}
+		if slots, ok := matchTokens(text, compact(tpl.Tokens), tokens, spans); ok {
+			match := *tpl
+			match.Tokens = append([]string(nil), tpl.Tokens...)
+			return &match, slots, true
+		}
+	}
+	return nil, nil, false
+}
+
+// compact 合并连续槽位
+func compact(tokens []string) []string {
+	var result []string
+	for i, token := range tokens {
+		if token == Slot && i > 0 && tokens[i-1] == Slot {
+			continue
+		}
+		result = append(result, token)
+	}
+	return result
+}
+
+// matchTokens 回溯匹配模板与词序列, 每个槽位至少匹配一个词, 槽位值取自原文
+func matchTokens(text string, tpl, tokens []string, spans []span) ([]string, bool) {
+	if len(tpl) == 0 {
+		return nil, len(tokens) == 0
+	}
+	if tpl[0] != Slot {
+		if len(tokens) == 0 || tokens[0] != tpl[0] {
+			return nil, false
+		}
+		return matchTokens(text, tpl[1:], tokens[1:], spans[1:])
+	}
+
+	for n := 1; n <= len(tokens); n++ {
+		if rest, ok := matchTokens(text, tpl[1:], tokens[n:], spans[n:]); ok {
+			return append([]string{text[spans[0].start:spans[n-1].end]}, rest...), true
+		}
+	}
+	return nil, false
+}
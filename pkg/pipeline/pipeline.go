@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// Document 在流水线各阶段间传递的文档及其中间结果
+type Document struct {
+	ID         int                // 文档序号, 按输入顺序从0开始
+	Text       string             // 原始文本
+	Normalized string             // 归一化后的文本
+	Tokens     []participle.Token // 分词结果
+	Extracted  map[string]any     // 抽取结果, 键为抽取阶段名称
+	Errors     map[string]error   // 各阶段错误, 仅ErrorContinue策略下记录
+}
+
+// ErrorPolicy 阶段错误处理策略
+type ErrorPolicy int
+
+const (
+	ErrorFail     ErrorPolicy = iota // 中止整个流水线
+	ErrorSkip                        // 丢弃该文档
+	ErrorContinue                    // 记录错误并继续后续阶段
+)
+
+// errorPolicyNames 错误处理策略在配置中的名称
+var errorPolicyNames = []string{"fail", "skip", "continue"}
+
+// MarshalText 以名称编码错误处理策略
+func (p ErrorPolicy) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(errorPolicyNames) {
+		return nil, fmt.Errorf("invalid error policy %d", int(p))
+	}
+	return []byte(errorPolicyNames[p]), nil
+}
+
+// UnmarshalText 按名称解码错误处理策略: fail, skip, continue
+func (p *ErrorPolicy) UnmarshalText(text []byte) error {
+	for i, name := range errorPolicyNames {
+		if string(text) == name {
+			*p = ErrorPolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid error policy %q", text)
+}
+
+// Stage 流水线阶段
+type Stage struct {
+	Name    string                                         // 阶段名称
+	Run     func(ctx context.Context, doc *Document) error // 处理函数
+	Workers int                                            // 并行处理的协程数, 小于1时为1
+	OnError ErrorPolicy                                    // 错误处理策略
+}
+
+// Pipeline 文档处理流水线: 采集 → 归一化 → 分词 → 抽取
+// 各阶段并发执行, 阶段内按Workers并行处理文档
+type Pipeline struct {
+	stages []Stage
+	buffer int
+}
+
+// New 创建流水线, buffer为阶段间通道的缓冲大小
+func New(buffer int, stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages, buffer: buffer}
+}
+
+// Then 追加阶段
+func (p *Pipeline) Then(stage Stage) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
+}
+
+// Normalize 归一化阶段, 将Text经f处理后写入Normalized
+func Normalize(f func(string) string) Stage {
+	return Stage{
+		Name: "normalize",
+		Run: func(ctx context.Context, doc *Document) error {
+			doc.Normalized = f(doc.Text)
+			return nil
+		},
+	}
+}
+
+// Segment 分词阶段, 对Normalized分词, 未归一化时使用Text
+func Segment(engine *participle.Engine, opts ...participle.SegmentOption) Stage {
+	return Stage{
+		Name: "segment",
+		Run: func(ctx context.Context, doc *Document) error {
+			text := doc.Normalized
+			if text == "" {
+				text = doc.Text
+			}
+			doc.Tokens = engine.SegmentTokens(text, opts...)
+			return nil
+		},
+	}
+}
+
+// TrackFrequencies 词频跟踪阶段, 将Normalized(未归一化时为Text)中各词的出现次数计入当前时间段, 见participle.Engine.RecordFrequencies
+func TrackFrequencies(engine *participle.Engine) Stage {
+	return Stage{
+		Name: "frequency",
+		Run: func(ctx context.Context, doc *Document) error {
+			text := doc.Normalized
+			if text == "" {
+				text = doc.Text
+			}
+			return engine.RecordFrequencies(text, time.Now())
+		},
+	}
+}
+
+// Extract 抽取阶段, 结果以name为键写入Extracted
+func Extract(name string, f func(ctx context.Context, doc *Document) (any, error)) Stage {
+	return Stage{
+		Name: name,
+		Run: func(ctx context.Context, doc *Document) error {
+			result, err := f(ctx, doc)
+			if err != nil {
+				return err
+			}
+			doc.Extracted[name] = result
+			return nil
+		},
+	}
+}
+
+// FromStrings 由字符串切片采集文档
+func FromStrings(texts []string) func(yield func(string) bool) error {
+	return func(yield func(string) bool) error {
+		for _, text := range texts {
+			if !yield(text) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// FromLines 按行采集文档, 跳过空行
+func FromLines(r io.Reader) func(yield func(string) bool) error {
+	return func(yield func(string) bool) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			if !yield(line) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+// Run 运行流水线, 每个处理完成的文档回调一次handle
+// 文档完成顺序与输入顺序无关; 阶段返回错误且策略为ErrorFail时中止并返回该错误
+func (p *Pipeline) Run(ctx context.Context, source func(yield func(string) bool) error, handle func(*Document)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// 采集
+	in := make(chan *Document, p.buffer)
+	var sourceErr error
+	go func() {
+		defer close(in)
+		id := 0
+		sourceErr = source(func(text string) bool {
+			doc := &Document{ID: id, Text: text, Extracted: make(map[string]any), Errors: make(map[string]error)}
+			id++
+			select {
+			case in <- doc:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	// 各阶段
+	var out <-chan *Document = in
+	for _, stage := range p.stages {
+		out = p.runStage(ctx, cancel, stage, out)
+	}
+
+	for doc := range out {
+		handle(doc)
+	}
+
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+	if sourceErr != nil {
+		return fmt.Errorf("read source fail: %v", sourceErr)
+	}
+	return nil
+}
+
+// runStage 启动阶段协程, 返回阶段输出通道
+func (p *Pipeline) runStage(ctx context.Context, cancel context.CancelCauseFunc, stage Stage, in <-chan *Document) <-chan *Document {
+	out := make(chan *Document, p.buffer)
+	workers := max(stage.Workers, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for doc := range in {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := stage.Run(ctx, doc); err != nil {
+					switch stage.OnError {
+					case ErrorFail:
+						cancel(fmt.Errorf("stage %s document %d fail: %v", stage.Name, doc.ID, err))
+						continue
+					case ErrorSkip:
+						continue
+					case ErrorContinue:
+						doc.Errors[stage.Name] = err
+					}
+				}
+				select {
+				case out <- doc:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
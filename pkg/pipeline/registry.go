@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// ErrUnknownPlugin 插件未注册
+var ErrUnknownPlugin = errors.New("pipeline: unknown plugin")
+
+// Extractor 抽取函数, 返回值以阶段名称为键写入Document.Extracted
+type Extractor func(ctx context.Context, doc *Document) (any, error)
+
+// TokenFilter 分词结果过滤函数, 返回值替换Document.Tokens, 可删除、改写或合并词
+type TokenFilter func(ctx context.Context, tokens []participle.Token) ([]participle.Token, error)
+
+// ExtractorFactory 按配置参数创建抽取函数
+type ExtractorFactory func(params map[string]string) (Extractor, error)
+
+// TokenFilterFactory 按配置参数创建过滤函数
+type TokenFilterFactory func(params map[string]string) (TokenFilter, error)
+
+// registry 插件注册表
+var registry = struct {
+	mu         sync.RWMutex
+	extractors map[string]ExtractorFactory
+	filters    map[string]TokenFilterFactory
+}{
+	extractors: make(map[string]ExtractorFactory),
+	filters:    make(map[string]TokenFilterFactory),
+}
+
+// RegisterExtractor 按名称注册抽取插件, 通常在插件包的init中调用
+// 名称为空、factory为nil或名称已注册时panic
+func RegisterExtractor(name string, factory ExtractorFactory) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if name == "" || factory == nil {
+		panic("pipeline: RegisterExtractor with empty name or nil factory")
+	}
+	if _, ok := registry.extractors[name]; ok {
+		panic("pipeline: RegisterExtractor called twice for " + name)
+	}
+	registry.extractors[name] = factory
+}
+
+// RegisterTokenFilter 按名称注册过滤插件, 通常在插件包的init中调用
+// 名称为空、factory为nil或名称已注册时panic
+func RegisterTokenFilter(name string, factory TokenFilterFactory) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if name == "" || factory == nil {
+		panic("pipeline: RegisterTokenFilter with empty name or nil factory")
+	}
+	if _, ok := registry.filters[name]; ok {
+		panic("pipeline: RegisterTokenFilter called twice for " + name)
+	}
+	registry.filters[name] = factory
+}
+
+// Extractors 已注册的抽取插件名称, 按字典序
+func Extractors() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	names := make([]string, 0, len(registry.extractors))
+	for name := range registry.extractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TokenFilters 已注册的过滤插件名称, 按字典序
+func TokenFilters() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	names := make([]string, 0, len(registry.filters))
+	for name := range registry.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewExtractor 按名称与参数创建已注册的抽取函数, 未注册时返回ErrUnknownPlugin
+func NewExtractor(name string, params map[string]string) (Extractor, error) {
+	registry.mu.RLock()
+	factory, ok := registry.extractors[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: extractor %s", ErrUnknownPlugin, name)
+	}
+	return factory(params)
+}
+
+// NewTokenFilter 按名称与参数创建已注册的过滤函数, 未注册时返回ErrUnknownPlugin
+func NewTokenFilter(name string, params map[string]string) (TokenFilter, error) {
+	registry.mu.RLock()
+	factory, ok := registry.filters[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: token filter %s", ErrUnknownPlugin, name)
+	}
+	return factory(params)
+}
+
+// Filter 过滤阶段, 以f处理分词结果
+func Filter(name string, f TokenFilter) Stage {
+	return Stage{
+		Name: name,
+		Run: func(ctx context.Context, doc *Document) error {
+			tokens, err := f(ctx, doc.Tokens)
+			if err != nil {
+				return err
+			}
+			doc.Tokens = tokens
+			return nil
+		},
+	}
+}
+
+// PluginKind 插件类型
+type PluginKind string
+
+const (
+	PluginExtractor   PluginKind = "extractor"    // 抽取插件
+	PluginTokenFilter PluginKind = "token_filter" // 过滤插件
+)
+
+// StageConfig 引用已注册插件的阶段配置, 可由JSON等配置文件解码
+type StageConfig struct {
+	Name    string            `json:"name"`              // 阶段名称, 为空时使用插件名称
+	Kind    PluginKind        `json:"kind"`              // 插件类型
+	Plugin  string            `json:"plugin"`            // 插件名称
+	Params  map[string]string `json:"params,omitempty"`  // 传给插件的参数
+	Workers int               `json:"workers,omitempty"` // 并行处理的协程数
+	OnError ErrorPolicy       `json:"on_error"`          // 错误处理策略
+}
+
+// StageFromConfig 按配置创建引用插件的阶段, 插件未注册时返回ErrUnknownPlugin
+func StageFromConfig(cfg StageConfig) (Stage, error) {
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Plugin
+	}
+
+	var stage Stage
+	switch cfg.Kind {
+	case PluginExtractor:
+		f, err := NewExtractor(cfg.Plugin, cfg.Params)
+		if err != nil {
+			return Stage{}, err
+		}
+		stage = Extract(name, f)
+	case PluginTokenFilter:
+		f, err := NewTokenFilter(cfg.Plugin, cfg.Params)
+		if err != nil {
+			return Stage{}, err
+		}
+		stage = Filter(name, f)
+	default:
+		return Stage{}, fmt.Errorf("unknown plugin kind %q", cfg.Kind)
+	}
+	stage.Workers = cfg.Workers
+	stage.OnError = cfg.OnError
+	return stage, nil
+}
+
+// StagesFromConfig 按配置依次创建阶段
+func StagesFromConfig(cfgs []StageConfig) ([]Stage, error) {
+	stages := make([]Stage, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		stage, err := StageFromConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d fail: %w", i, err)
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
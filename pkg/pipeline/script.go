@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/miajio/nla/pkg/expr"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// 表达式规则插件, 可在阶段配置中直接引用:
+//
+//	{"kind": "token_filter", "plugin": "script", "params": {"when": "type == \"PUNCT\"", "drop": "true"}}
+//	{"kind": "extractor", "plugin": "script", "params": {"when": "text =~ \"^\\\\d+元$\"", "value": "number(replace(text, \"元\", \"\"))"}}
+//
+// 表达式中可用的变量:
+//
+//	text     词
+//	pos      词性, 未知时为空
+//	type     类型名称, 如HAN、NUMBER、PUNCT, 见participle.TokenType
+//	freq     词频, 未知时为0
+//	learned  是否来自自定义词典
+//	index    词的序号, 从0开始
+//	prev     前一个词, 没有时为空
+//	next     后一个词, 没有时为空
+//	doc      文档原文, 仅抽取规则可用
+func init() {
+	RegisterTokenFilter("script", scriptFilterFactory)
+	RegisterExtractor("script", scriptExtractorFactory)
+}
+
+// ScriptRule 以表达式描述的词后处理规则
+type ScriptRule struct {
+	When *expr.Program // 条件, 结果须为布尔值, nil表示所有词
+	Drop bool          // 满足条件时删除该词
+	Text *expr.Program // 满足条件时改写词, nil表示不改写
+	Pos  *expr.Program // 满足条件时改写词性, nil表示不改写
+}
+
+// ScriptFilter 依次对每个词执行规则, 规则按顺序作用于前一条规则的结果
+func ScriptFilter(rules ...ScriptRule) TokenFilter {
+	return func(ctx context.Context, tokens []participle.Token) ([]participle.Token, error) {
+		for _, rule := range rules {
+			out := make([]participle.Token, 0, len(tokens))
+			for i, tok := range tokens {
+				env := tokenEnv(tokens, i)
+				ok, err := evalWhen(rule.When, env)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					out = append(out, tok)
+					continue
+				}
+				if rule.Drop {
+					continue
+				}
+				if rule.Text != nil {
+					if tok.Text, err = rule.Text.EvalString(env); err != nil {
+						return nil, fmt.Errorf("rule %s fail: %v", rule.Text, err)
+					}
+				}
+				if rule.Pos != nil {
+					if tok.Pos, err = rule.Pos.EvalString(env); err != nil {
+						return nil, fmt.Errorf("rule %s fail: %v", rule.Pos, err)
+					}
+				}
+				out = append(out, tok)
+			}
+			tokens = out
+		}
+		return tokens, nil
+	}
+}
+
+// ScriptExtractor 抽取满足when的词经value求值的结果, value为nil时取词本身, 没有满足条件的词时结果为空切片
+func ScriptExtractor(when, value *expr.Program) Extractor {
+	return func(ctx context.Context, doc *Document) (any, error) {
+		values := make([]any, 0)
+		for i, tok := range doc.Tokens {
+			env := tokenEnv(doc.Tokens, i)
+			env["doc"] = doc.Text
+			ok, err := evalWhen(when, env)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if value == nil {
+				values = append(values, tok.Text)
+				continue
+			}
+			v, err := value.Eval(env)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s fail: %v", value, err)
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+}
+
+// tokenEnv 第i个词的表达式环境
+func tokenEnv(tokens []participle.Token, i int) map[string]any {
+	tok := tokens[i]
+	env := map[string]any{
+		"text":    tok.Text,
+		"pos":     tok.Pos,
+		"type":    tok.Type.String(),
+		"freq":    tok.Frequency,
+		"learned": tok.Learned,
+		"index":   i,
+		"prev":    "",
+		"next":    "",
+	}
+	if i > 0 {
+		env["prev"] = tokens[i-1].Text
+	}
+	if i+1 < len(tokens) {
+		env["next"] = tokens[i+1].Text
+	}
+	return env
+}
+
+// evalWhen 求值条件, 条件为nil时满足
+func evalWhen(when *expr.Program, env map[string]any) (bool, error) {
+	if when == nil {
+		return true, nil
+	}
+	ok, err := when.EvalBool(env)
+	if err != nil {
+		return false, fmt.Errorf("rule %s fail: %v", when, err)
+	}
+	return ok, nil
+}
+
+// compileParam 编译参数中的表达式, 参数不存在时返回nil
+func compileParam(params map[string]string, name string) (*expr.Program, error) {
+	src, ok := params[name]
+	if !ok || src == "" {
+		return nil, nil
+	}
+	p, err := expr.Compile(src)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s fail: %v", name, err)
+	}
+	return p, nil
+}
+
+// scriptFilterFactory 按参数when、drop、text、pos创建过滤规则
+func scriptFilterFactory(params map[string]string) (TokenFilter, error) {
+	var rule ScriptRule
+	var err error
+	if rule.When, err = compileParam(params, "when"); err != nil {
+		return nil, err
+	}
+	if rule.Text, err = compileParam(params, "text"); err != nil {
+		return nil, err
+	}
+	if rule.Pos, err = compileParam(params, "pos"); err != nil {
+		return nil, err
+	}
+	if drop, ok := params["drop"]; ok {
+		if rule.Drop, err = strconv.ParseBool(drop); err != nil {
+			return nil, fmt.Errorf("invalid drop %q", drop)
+		}
+	}
+	return ScriptFilter(rule), nil
+}
+
+// scriptExtractorFactory 按参数when、value创建抽取规则
+func scriptExtractorFactory(params map[string]string) (Extractor, error) {
+	when, err := compileParam(params, "when")
+	if err != nil {
+		return nil, err
+	}
+	value, err := compileParam(params, "value")
+	if err != nil {
+		return nil, err
+	}
+	return ScriptExtractor(when, value), nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// defaultAuditBodyLimit 审计日志中记录的请求与响应内容的默认字节上限
+const defaultAuditBodyLimit = 4096
+
+// AuditRecord 审计日志记录, 请求与响应内容中的电话号码与身份证号已脱敏
+type AuditRecord struct {
+	Time      time.Time `json:"time"`                // 请求时间
+	Method    string    `json:"method"`              // 请求方法
+	Path      string    `json:"path"`                // 请求路径
+	Tenant    string    `json:"tenant,omitempty"`    // 租户名称, 启用API Key认证时记录, 不记录API Key本身
+	Status    int       `json:"status"`              // 响应状态码
+	Duration  string    `json:"duration"`            // 处理耗时
+	Request   string    `json:"request,omitempty"`   // 请求内容
+	Response  string    `json:"response,omitempty"`  // 响应内容, 分词请求即为分词结果
+	Truncated bool      `json:"truncated,omitempty"` // 请求或响应内容超出上限被截断
+}
+
+// audit 审计日志
+type audit struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	limit int // 记录的内容字节上限
+}
+
+// WithAuditLog 将每个请求及其响应以JSON行写入w用于审计, 写入前对电话号码与身份证号脱敏
+// limit为记录的请求与响应内容的字节上限, 超出部分截断, 0表示使用默认值4096, 负数表示不记录内容
+func WithAuditLog(w io.Writer, limit int) Option {
+	return func(s *Server) {
+		if limit == 0 {
+			limit = defaultAuditBodyLimit
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		s.audit = &audit{enc: enc, limit: max(limit, 0)}
+	}
+}
+
+// capture 记录不超过limit字节的内容
+type capture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write 实现io.Writer接口, 超出上限的内容丢弃
+func (c *capture) Write(p []byte) (int, error) {
+	if n := c.limit - c.buf.Len(); n < len(p) {
+		c.truncated = c.limit > 0
+		c.buf.Write(p[:max(n, 0)])
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// String 脱敏后的内容, 截断时去掉末尾不完整的字符
+func (c *capture) String() string {
+	return participle.RedactPII(string(bytes.ToValidUTF8(c.buf.Bytes(), nil)))
+}
+
+// auditWriter 记录响应状态码与内容
+type auditWriter struct {
+	http.ResponseWriter
+	status int
+	body   *capture
+}
+
+// WriteHeader 记录状态码
+func (w *auditWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write 记录响应内容
+func (w *auditWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// serveAudit 处理请求并写入审计日志
+func (s *Server) serveAudit(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	start := time.Now()
+	request := &capture{limit: s.audit.limit}
+	if r.Body != nil {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, request), r.Body}
+	}
+	aw := &auditWriter{ResponseWriter: w, body: &capture{limit: s.audit.limit}}
+	next.ServeHTTP(aw, r)
+
+	record := AuditRecord{
+		Time:      start,
+		Method:    r.Method,
+		Path:      participle.RedactPII(r.URL.RequestURI()),
+		Status:    aw.status,
+		Duration:  time.Since(start).String(),
+		Request:   request.String(),
+		Response:  aw.body.String(),
+		Truncated: request.truncated || aw.body.truncated,
+	}
+	if record.Status == 0 {
+		record.Status = http.StatusOK
+	}
+	if s.auth != nil {
+		record.Tenant = tenantOf(r)
+	}
+
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	if err := s.audit.enc.Encode(record); err != nil {
+		s.reportError(err)
+	}
+}
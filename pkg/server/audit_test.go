@@ -0,0 +1,38 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogOmitsAPIKey(t *testing.T) {
+	var log bytes.Buffer
+	s := newTestServer(t, WithAuditLog(&log, 0))
+
+	do(s, "POST", "/segment", "key-alice", `{"text":"电话13800138000"}`)
+	do(s, "GET", "/usage", "key-alice", "")
+	do(s, "POST", "/segment", "key-mallory", `{"text":"你好"}`)
+
+	out := log.String()
+	for _, secret := range []string{"key-alice", "key-mallory", "13800138000"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("audit log contains %q:\n%s", secret, out)
+		}
+	}
+
+	var tenants []string
+	dec := json.NewDecoder(&log)
+	for dec.More() {
+		var record AuditRecord
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		tenants = append(tenants, record.Tenant)
+	}
+	want := []string{"alice", "alice", anonymousTenant}
+	if strings.Join(tenants, ",") != strings.Join(want, ",") {
+		t.Errorf("tenants %v, want %v", tenants, want)
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized 未携带有效的API Key
+	ErrUnauthorized = errors.New("server: missing or invalid api key")
+	// ErrForbidden 租户无权执行该操作
+	ErrForbidden = errors.New("server: forbidden")
+)
+
+// anonymousTenant 未启用API Key认证时所有请求所属的租户
+const anonymousTenant = "anonymous"
+
+// auth API Key认证
+type auth struct {
+	header string            // 携带API Key的请求头
+	keys   map[string]string // API Key -> 租户
+	admins map[string]bool   // 管理员租户
+}
+
+// tenantContextKey 请求上下文中租户名称的键
+type tenantContextKey struct{}
+
+// WithAPIKeys 启用API Key认证, keys为API Key到租户名称的映射, header为携带API Key的请求头, 默认X-API-Key
+// 启用后所有请求须携带keys中的API Key, 否则返回401; 用量、配额、审计日志与任务按租户名称记录, 不保存API Key本身
+// 未启用时所有请求属于anonymous租户
+func WithAPIKeys(header string, keys map[string]string) Option {
+	return func(s *Server) {
+		if header == "" {
+			header = "X-API-Key"
+		}
+		if s.auth == nil {
+			s.auth = &auth{admins: make(map[string]bool)}
+		}
+		s.auth.header = header
+		s.auth.keys = make(map[string]string, len(keys))
+		for key, tenant := range keys {
+			if key != "" && tenant != "" {
+				s.auth.keys[key] = tenant
+			}
+		}
+	}
+}
+
+// WithAdminTenants 设置管理员租户, 需同时使用WithAPIKeys
+// 管理员可查询所有租户的用量与任务, 并可采纳或关闭灰度评估; 未启用API Key认证时这些管理操作一律返回403
+func WithAdminTenants(tenants ...string) Option {
+	return func(s *Server) {
+		if s.auth == nil {
+			s.auth = &auth{header: "X-API-Key", admins: make(map[string]bool)}
+		}
+		for _, tenant := range tenants {
+			s.auth.admins[tenant] = true
+		}
+	}
+}
+
+// lookup 按API Key查找租户, 逐个以常量时间比较, 避免通过响应时间猜测API Key
+func (a *auth) lookup(key string) (string, bool) {
+	var tenant string
+	for k, t := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			tenant = t
+		}
+	}
+	return tenant, tenant != ""
+}
+
+// authenticate 认证请求, 成功时返回带有租户名称的请求
+func (s *Server) authenticate(r *http.Request) (*http.Request, bool) {
+	if s.auth == nil || s.auth.keys == nil {
+		return r, true
+	}
+	key := strings.TrimSpace(r.Header.Get(s.auth.header))
+	if key == "" {
+		return r, false
+	}
+	tenant, ok := s.auth.lookup(key)
+	if !ok {
+		return r, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenant)), true
+}
+
+// tenantOf 获取请求所属的租户名称
+func tenantOf(r *http.Request) string {
+	if tenant, ok := r.Context().Value(tenantContextKey{}).(string); ok {
+		return tenant
+	}
+	return anonymousTenant
+}
+
+// isAdmin 请求是否来自管理员租户
+func (s *Server) isAdmin(r *http.Request) bool {
+	if s.auth == nil || s.auth.keys == nil {
+		return false
+	}
+	return s.auth.admins[tenantOf(r)]
+}
+
+// requireAdmin 非管理员请求写出403响应并返回false
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.isAdmin(r) {
+		return true
+	}
+	writeError(w, http.StatusForbidden, ErrForbidden)
+	return false
+}
+
+// unauthorized 写出401响应
+func unauthorized(w http.ResponseWriter, _ *http.Request) {
+	writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+	"github.com/miajio/nla/pkg/participle/testutil"
+)
+
+// newTestServer 创建基于内存数据库、启用API Key认证与用量统计的服务
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	db, err := testutil.NewMemoryDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := participle.New(db, participle.WithBaseDict(participle.BaseDictEmpty))
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close() })
+
+	opts = append([]Option{
+		WithAPIKeys("", map[string]string{"key-alice": "alice", "key-bob": "bob", "key-admin": "ops"}),
+		WithAdminTenants("ops"),
+		WithUsage(db, time.Hour),
+	}, opts...)
+	s := New(engine, opts...)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// do 以API Key发送请求
+func do(s *Server, method, path, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestUnknownKeyRejected(t *testing.T) {
+	s := newTestServer(t)
+	for _, key := range []string{"", "key-mallory"} {
+		if w := do(s, "POST", "/segment", key, `{"text":"你好"}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("key %q: status %d, want 401", key, w.Code)
+		}
+	}
+	if w := do(s, "POST", "/segment", "key-alice", `{"text":"你好"}`); w.Code != http.StatusOK {
+		t.Errorf("valid key: status %d, want 200", w.Code)
+	}
+}
+
+func TestUsageScopedToTenant(t *testing.T) {
+	s := newTestServer(t)
+	do(s, "POST", "/segment", "key-alice", `{"text":"你好"}`)
+	do(s, "POST", "/segment", "key-bob", `{"text":"再见"}`)
+
+	var list []Usage
+	w := do(s, "GET", "/usage", "key-alice", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if len(list) != 1 || list[0].Tenant != "alice" {
+		t.Errorf("alice sees %+v, want only her own usage", list)
+	}
+	if w := do(s, "GET", "/usage/bob", "key-alice", ""); w.Code != http.StatusForbidden {
+		t.Errorf("alice reading bob: status %d, want 403", w.Code)
+	}
+
+	w = do(s, "GET", "/usage", "key-admin", "")
+	list = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if len(list) != 2 {
+		t.Errorf("admin sees %d tenants, want 2", len(list))
+	}
+	for _, item := range list {
+		if strings.HasPrefix(item.Tenant, "key-") {
+			t.Errorf("usage keyed by api key %q", item.Tenant)
+		}
+	}
+}
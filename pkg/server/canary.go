@@ -0,0 +1,257 @@
+package server
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// ErrNoCanary 未开启灰度评估
+var ErrNoCanary = errors.New("server: no canary engine")
+
+const (
+	// canaryTopWords 灰度统计中保留的变化最多的词数
+	canaryTopWords = 20
+	// canaryConcurrency 同时进行的镜像分词数, 超出时丢弃镜像请求
+	canaryConcurrency = 4
+	// canaryMaxWords 统计分词差异时跟踪的不同词数上限, 超出后新出现的词只计入Untracked
+	canaryMaxWords = 10000
+)
+
+// CanaryStats 灰度评估统计
+type CanaryStats struct {
+	Percent       float64                    `json:"percent"`        // 镜像流量百分比
+	Since         time.Time                  `json:"since"`          // 开始时间
+	Mirrored      int64                      `json:"mirrored"`       // 已镜像的请求数
+	Dropped       int64                      `json:"dropped"`        // 因镜像繁忙丢弃的请求数
+	Changed       int64                      `json:"changed"`        // 分词结果不同的请求数
+	PrimaryTokens int64                      `json:"primary_tokens"` // 当前词典的分词数
+	CanaryTokens  int64                      `json:"canary_tokens"`  // 灰度词典的分词数
+	Added         []participle.WordFrequency `json:"added"`          // 灰度词典多切出的词, 按次数降序
+	Removed       []participle.WordFrequency `json:"removed"`        // 灰度词典不再切出的词, 按次数降序
+	Untracked     int64                      `json:"untracked"`      // 跟踪的词数达到上限后未计入Added与Removed的差异次数
+}
+
+// canary 灰度评估, 按比例将分词请求镜像到带有待上线修改的引擎并比较结果
+type canary struct {
+	engine  *participle.Engine
+	percent float64
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	stats   CanaryStats
+	added   map[string]int
+	removed map[string]int
+}
+
+// WithCanary 开启灰度评估, 将percent%的分词请求镜像到engine并记录分词差异
+// engine通常是当前引擎的副本(Fork), 包含待上线的词典修改, 确认无误后通过POST /canary/promote采纳
+// 灰度评估的查询、采纳与关闭仅限WithAdminTenants设置的管理员租户
+func WithCanary(engine *participle.Engine, percent float64) Option {
+	return func(s *Server) { s.canary = newCanary(engine, percent) }
+}
+
+// newCanary 创建灰度评估
+func newCanary(engine *participle.Engine, percent float64) *canary {
+	percent = min(max(percent, 0), 100)
+	return &canary{
+		engine:  engine,
+		percent: percent,
+		sem:     make(chan struct{}, canaryConcurrency),
+		stats:   CanaryStats{Percent: percent, Since: time.Now()},
+		added:   make(map[string]int),
+		removed: make(map[string]int),
+	}
+}
+
+// SetCanary 替换灰度评估引擎并重置统计, engine为nil时关闭灰度评估
+func (s *Server) SetCanary(engine *participle.Engine, percent float64) {
+	var c *canary
+	if engine != nil {
+		c = newCanary(engine, percent)
+	}
+	s.cmu.Lock()
+	old := s.canary
+	s.canary = c
+	s.cmu.Unlock()
+	if old != nil {
+		old.wg.Wait()
+	}
+}
+
+// currentCanary 获取当前的灰度评估
+func (s *Server) currentCanary() *canary {
+	s.cmu.RLock()
+	defer s.cmu.RUnlock()
+	return s.canary
+}
+
+// CanaryStats 获取灰度评估统计, 未开启时返回ErrNoCanary
+func (s *Server) CanaryStats() (CanaryStats, error) {
+	c := s.currentCanary()
+	if c == nil {
+		return CanaryStats{}, ErrNoCanary
+	}
+	return c.snapshot(), nil
+}
+
+// mirrorCanary 开启灰度评估时镜像分词请求, 持有读锁以保证关闭灰度评估时能等到所有镜像完成
+func (s *Server) mirrorCanary(text string, tokens []string) {
+	s.cmu.RLock()
+	defer s.cmu.RUnlock()
+	if s.canary != nil {
+		s.canary.mirror(text, tokens)
+	}
+}
+
+// mirror 按比例将分词请求镜像到灰度引擎, 在后台比较结果, 不影响请求耗时
+func (c *canary) mirror(text string, tokens []string) {
+	if c.percent <= 0 || rand.Float64()*100 >= c.percent {
+		return
+	}
+	select {
+	case c.sem <- struct{}{}:
+	default:
+		c.mu.Lock()
+		c.stats.Dropped++
+		c.mu.Unlock()
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() { <-c.sem }()
+		c.record(tokens, c.engine.Segment(text))
+	}()
+}
+
+// record 记录一次镜像的分词差异
+func (c *canary) record(primary, candidate []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Mirrored++
+	c.stats.PrimaryTokens += int64(len(primary))
+	c.stats.CanaryTokens += int64(len(candidate))
+	if slices.Equal(primary, candidate) {
+		return
+	}
+	c.stats.Changed++
+
+	counts := make(map[string]int, len(primary))
+	for _, token := range primary {
+		counts[token]++
+	}
+	for _, token := range candidate {
+		counts[token]--
+	}
+	for token, n := range counts {
+		switch {
+		case n > 0:
+			c.track(c.removed, token, n)
+		case n < 0:
+			c.track(c.added, token, -n)
+		}
+	}
+}
+
+// track 累加词的差异次数, 跟踪的词数达到上限时新词只计入Untracked, 调用方须持有锁
+func (c *canary) track(words map[string]int, token string, n int) {
+	if _, ok := words[token]; !ok && len(words) >= canaryMaxWords {
+		c.stats.Untracked += int64(n)
+		return
+	}
+	words[token] += n
+}
+
+// snapshot 统计快照
+func (c *canary) snapshot() CanaryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Added = topWords(c.added, canaryTopWords)
+	stats.Removed = topWords(c.removed, canaryTopWords)
+	return stats
+}
+
+// topWords 次数最多的n个词
+func topWords(counts map[string]int, n int) []participle.WordFrequency {
+	words := make([]participle.WordFrequency, 0, len(counts))
+	for word, count := range counts {
+		words = append(words, participle.WordFrequency{Name: participle.RedactPII(word), Value: float64(count)})
+	}
+	sort.Slice(words, func(a, b int) bool {
+		if words[a].Value != words[b].Value {
+			return words[a].Value > words[b].Value
+		}
+		return words[a].Name < words[b].Name
+	})
+	return truncate(words, n)
+}
+
+// truncate 保留前n个元素
+func truncate[T any](list []T, n int) []T {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
+
+// handleCanary GET /canary 查询灰度评估统计, 仅限管理员租户
+func (s *Server) handleCanary(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	stats, err := s.CanaryStats()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handlePromoteCanary POST /canary/promote 采纳灰度引擎的词典并关闭灰度评估
+// 灰度引擎须是当前引擎的副本, 创建副本后当前词典有修改时返回409; 仅限管理员租户
+func (s *Server) handlePromoteCanary(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	c := s.currentCanary()
+	if c == nil {
+		writeError(w, http.StatusNotFound, ErrNoCanary)
+		return
+	}
+	stats := c.snapshot()
+	err := s.engine.Promote(c.engine)
+	switch {
+	case err == nil:
+	case errors.Is(err, participle.ErrNotFork), errors.Is(err, participle.ErrStaleFork), errors.Is(err, participle.ErrForkPromoted):
+		writeError(w, http.StatusConflict, err)
+		return
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.SetCanary(nil, 0)
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleDeleteCanary DELETE /canary 关闭灰度评估, 返回最终统计; 仅限管理员租户
+func (s *Server) handleDeleteCanary(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	stats, err := s.CanaryStats()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	s.SetCanary(nil, 0)
+	writeJSON(w, http.StatusOK, stats)
+}
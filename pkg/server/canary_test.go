@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCanaryRequiresAdmin(t *testing.T) {
+	s := newTestServer(t)
+	fork, err := s.engine.Fork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetCanary(fork, 100)
+
+	for _, req := range [][2]string{{"GET", "/canary"}, {"DELETE", "/canary"}, {"POST", "/canary/promote"}} {
+		if w := do(s, req[0], req[1], "key-alice", ""); w.Code != http.StatusForbidden {
+			t.Errorf("tenant %s %s: status %d, want 403", req[0], req[1], w.Code)
+		}
+		if w := do(s, req[0], req[1], "", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous %s %s: status %d, want 401", req[0], req[1], w.Code)
+		}
+	}
+	if w := do(s, "POST", "/canary/promote", "key-admin", ""); w.Code != http.StatusOK {
+		t.Errorf("admin promote: status %d %s, want 200", w.Code, w.Body)
+	}
+}
+
+func TestCanaryWordsBounded(t *testing.T) {
+	c := newCanary(nil, 100)
+	for i := 0; i < canaryMaxWords+100; i++ {
+		c.record([]string{fmt.Sprint("p", i)}, []string{fmt.Sprint("c", i)})
+	}
+	stats := c.snapshot()
+	if len(c.added) != canaryMaxWords || len(c.removed) != canaryMaxWords {
+		t.Errorf("tracked %d added, %d removed words, want %d", len(c.added), len(c.removed), canaryMaxWords)
+	}
+	if stats.Untracked != 200 {
+		t.Errorf("untracked %d, want 200", stats.Untracked)
+	}
+}
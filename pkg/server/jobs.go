@@ -0,0 +1,406 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+var (
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("server: job not found")
+	// ErrJobNotDone 任务尚未成功完成, 没有结果
+	ErrJobNotDone = errors.New("server: job has no result")
+	// ErrQueueFull 任务队列已满
+	ErrQueueFull = errors.New("server: job queue is full")
+)
+
+const (
+	defaultJobTTL   = time.Hour // 已结束任务及其结果的默认保留时长
+	defaultJobLimit = 1000      // 默认保留的任务数上限
+)
+
+// JobType 任务类型
+type JobType string
+
+const (
+	JobLearn     JobType = "learn"     // 从文本学习新词
+	JobReindex   JobType = "reindex"   // 从数据库重新加载词典
+	JobExport    JobType = "export"    // 导出词典词频
+	JobStopwords JobType = "stopwords" // 统计停用词候选
+)
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"    // 等待执行
+	JobRunning   JobStatus = "running"   // 执行中
+	JobSucceeded JobStatus = "succeeded" // 执行成功
+	JobFailed    JobStatus = "failed"    // 执行失败
+	JobCanceled  JobStatus = "canceled"  // 已取消
+)
+
+// JobRequest 创建任务请求
+type JobRequest struct {
+	Type   JobType                    `json:"type"`             // 任务类型
+	Texts  []string                   `json:"texts,omitempty"`  // learn: 待学习的文本; stopwords: 待统计的文档
+	Format participle.FrequencyFormat `json:"format,omitempty"` // export: 导出格式, 默认csv
+}
+
+// Job 异步任务
+type Job struct {
+	ID         string     `json:"id"`                    // 任务ID
+	Type       JobType    `json:"type"`                  // 任务类型
+	Status     JobStatus  `json:"status"`                // 任务状态
+	Error      string     `json:"error,omitempty"`       // 失败原因
+	CreatedAt  time.Time  `json:"created_at"`            // 创建时间
+	StartedAt  *time.Time `json:"started_at,omitempty"`  // 开始时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 结束时间
+
+	tenant      string                                    // 创建任务的租户
+	run         func(ctx context.Context) ([]byte, error) // 执行函数
+	result      []byte                                    // 执行结果
+	contentType string                                    // 结果类型
+	cancel      context.CancelFunc                        // 取消函数
+}
+
+// jobs 异步任务队列
+type jobs struct {
+	mu    sync.Mutex
+	items map[string]*Job
+	ttl   time.Duration // 已结束任务的保留时长
+	limit int           // 保留的任务数上限, 含未结束的任务
+
+	queue    chan *Job
+	onFinish func(job Job) // 任务结束回调
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// WithJobRetention 设置已结束任务及其结果的保留时长与保留的任务数上限, 默认保留1小时、最多1000个
+// 任务数达到上限时先移除最早结束的任务, 全部未结束时拒绝新任务
+func WithJobRetention(ttl time.Duration, limit int) Option {
+	return func(s *Server) { s.jobTTL, s.jobLimit = ttl, limit }
+}
+
+// newJobs 创建任务队列并启动workers个处理协程, 任务结束时回调onFinish
+func newJobs(workers int, ttl time.Duration, limit int, onFinish func(job Job)) *jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	if ttl <= 0 {
+		ttl = defaultJobTTL
+	}
+	if limit <= 0 {
+		limit = defaultJobLimit
+	}
+	q := &jobs{items: make(map[string]*Job), ttl: ttl, limit: limit, queue: make(chan *Job, 256), onFinish: onFinish, ctx: ctx, cancel: cancel}
+	for i := 0; i < max(workers, 1); i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// worker 处理队列中的任务
+func (q *jobs) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case job := <-q.queue:
+			q.execute(job)
+		}
+	}
+}
+
+// execute 执行任务
+func (q *jobs) execute(job *Job) {
+	q.mu.Lock()
+	if job.Status != JobQueued {
+		q.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+	now := time.Now()
+	job.Status, job.StartedAt, job.cancel = JobRunning, &now, cancel
+	q.mu.Unlock()
+
+	result, err := job.run(ctx)
+
+	q.mu.Lock()
+	finished := time.Now()
+	job.FinishedAt = &finished
+	switch {
+	case ctx.Err() != nil:
+		job.Status = JobCanceled
+	case err != nil:
+		job.Status, job.Error = JobFailed, err.Error()
+	default:
+		job.Status, job.result = JobSucceeded, result
+	}
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.onFinish(snapshot)
+}
+
+// submit 提交任务
+func (q *jobs) submit(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(time.Now())
+	if len(q.items) >= q.limit && !q.evictOldest() {
+		return ErrQueueFull
+	}
+	select {
+	case q.queue <- job:
+		q.items[job.ID] = job
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// prune 移除结束超过保留时长的任务, 调用方须持有锁
+func (q *jobs) prune(now time.Time) {
+	for id, job := range q.items {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > q.ttl {
+			delete(q.items, id)
+		}
+	}
+}
+
+// evictOldest 移除最早结束的任务, 没有已结束的任务时返回false, 调用方须持有锁
+func (q *jobs) evictOldest() bool {
+	var oldest *Job
+	for _, job := range q.items {
+		if job.FinishedAt != nil && (oldest == nil || job.FinishedAt.Before(*oldest.FinishedAt)) {
+			oldest = job
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(q.items, oldest.ID)
+	return true
+}
+
+// get 获取任务快照
+func (q *jobs) get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(time.Now())
+	job, ok := q.items[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// cancelJob 取消任务, 已结束的任务不受影响
+func (q *jobs) cancelJob(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.items[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	switch job.Status {
+	case JobQueued:
+		now := time.Now()
+		job.Status, job.FinishedAt = JobCanceled, &now
+		q.onFinish(*job)
+	case JobRunning:
+		job.cancel()
+	}
+	return *job, nil
+}
+
+// close 取消所有任务并等待处理协程退出
+func (q *jobs) close() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// newJobID 生成任务ID
+func newJobID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// newJob 根据请求创建任务
+func (s *Server) newJob(req JobRequest, tenant string) (*Job, error) {
+	job := &Job{ID: newJobID(), Type: req.Type, Status: JobQueued, CreatedAt: time.Now(), tenant: tenant, contentType: "application/json"}
+
+	switch req.Type {
+	case JobLearn:
+		if len(req.Texts) == 0 {
+			return nil, errors.New("learn job requires texts")
+		}
+		corpus := strings.Join(req.Texts, "\n")
+		name := "server:" + job.ID
+		job.run = func(ctx context.Context) ([]byte, error) {
+			if err := s.engine.LearnFromCorpus(ctx, name, strings.NewReader(corpus)); err != nil {
+				return nil, err
+			}
+			progress, err := s.engine.Progress(name)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.engine.ResetJob(name); err != nil {
+				return nil, err
+			}
+			return json.Marshal(progress)
+		}
+	case JobStopwords:
+		if len(req.Texts) == 0 {
+			return nil, errors.New("stopwords job requires texts")
+		}
+		// 文档按行切分, 文本中的换行视为空白
+		docs := make([]string, len(req.Texts))
+		for i, text := range req.Texts {
+			docs[i] = strings.Join(strings.Fields(text), " ")
+		}
+		corpus := strings.Join(docs, "\n")
+		job.run = func(ctx context.Context) ([]byte, error) {
+			candidates, err := s.engine.SuggestStopwords(ctx, strings.NewReader(corpus), participle.StopwordMinDocs(2))
+			if err != nil {
+				return nil, err
+			}
+			if candidates == nil {
+				candidates = []participle.StopwordCandidate{}
+			}
+			return json.Marshal(candidates)
+		}
+	case JobReindex:
+		job.run = func(ctx context.Context) ([]byte, error) {
+			if err := s.engine.ReloadContext(ctx); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]uint64{"version": s.engine.Version()})
+		}
+	case JobExport:
+		format := req.Format
+		if format == "" {
+			format = participle.FrequencyCSV
+		}
+		switch format {
+		case participle.FrequencyCSV:
+			job.contentType = "text/csv; charset=utf-8"
+		case participle.FrequencySVG:
+			job.contentType = "image/svg+xml"
+		case participle.FrequencyWordcloud:
+		default:
+			return nil, fmt.Errorf("unknown export format: %s", format)
+		}
+		job.run = func(ctx context.Context) ([]byte, error) {
+			return participle.EncodeFrequencies(s.engine.DictionaryFrequencies(), format)
+		}
+	default:
+		return nil, fmt.Errorf("unknown job type: %s", req.Type)
+	}
+	return job, nil
+}
+
+// jobUsage 任务计入的用量: 学习任务按文本字符数计入分词用量、按文本数计入词典修改次数,
+// 停用词统计任务按文本字符数计入分词用量, 重建任务计一次词典修改
+func jobUsage(req JobRequest) (chars, mutations int64) {
+	switch req.Type {
+	case JobLearn:
+		return textChars(req.Texts...), int64(len(req.Texts))
+	case JobStopwords:
+		return textChars(req.Texts...), 0
+	case JobReindex:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// handleCreateJob POST /jobs 创建异步任务, 返回任务ID
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode job request fail: %v", err))
+		return
+	}
+	job, err := s.newJob(req, tenantOf(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	chars, mutations := jobUsage(req)
+	if !s.chargeRequest(w, r, chars, mutations) {
+		return
+	}
+	if err := s.jobs.submit(job); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// ownJob 获取请求租户可访问的任务, 其他租户的任务视为不存在, 管理员租户可访问所有任务
+func (s *Server) ownJob(r *http.Request) (Job, error) {
+	job, err := s.jobs.get(r.PathValue("id"))
+	if err != nil {
+		return Job{}, err
+	}
+	if job.tenant != tenantOf(r) && !s.isAdmin(r) {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// handleGetJob GET /jobs/{id} 查询任务状态
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.ownJob(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleCancelJob DELETE /jobs/{id} 取消任务
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.ownJob(r); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	job, err := s.jobs.cancelJob(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleJobResult GET /jobs/{id}/result 下载任务结果
+func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	job, err := s.ownJob(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if job.Status != JobSucceeded {
+		writeError(w, http.StatusConflict, ErrJobNotDone)
+		return
+	}
+	w.Header().Set("Content-Type", job.contentType)
+	w.Write(job.result)
+}
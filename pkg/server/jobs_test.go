@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// createJob 创建重建任务并等待结束, 返回任务ID
+func createJob(t *testing.T, s *Server, key string) string {
+	t.Helper()
+	w := do(s, "POST", "/jobs", key, `{"type":"reindex"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("create job: status %d %s", w.Code, w.Body)
+	}
+	var job Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if j, err := s.jobs.get(job.ID); err == nil && j.FinishedAt != nil {
+			return job.ID
+		}
+	}
+	t.Fatalf("job %s did not finish", job.ID)
+	return ""
+}
+
+func TestJobsRequireAuthAndOwner(t *testing.T) {
+	s := newTestServer(t)
+	if w := do(s, "POST", "/jobs", "", `{"type":"reindex"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous create: status %d, want 401", w.Code)
+	}
+
+	id := createJob(t, s, "key-alice")
+	if w := do(s, "GET", "/jobs/"+id, "key-alice", ""); w.Code != http.StatusOK {
+		t.Errorf("owner get: status %d, want 200", w.Code)
+	}
+	for _, path := range []string{"/jobs/" + id, "/jobs/" + id + "/result"} {
+		if w := do(s, "GET", path, "key-bob", ""); w.Code != http.StatusNotFound {
+			t.Errorf("other tenant %s: status %d, want 404", path, w.Code)
+		}
+	}
+	if w := do(s, "DELETE", "/jobs/"+id, "key-bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("other tenant cancel: status %d, want 404", w.Code)
+	}
+	if w := do(s, "GET", "/jobs/"+id, "key-admin", ""); w.Code != http.StatusOK {
+		t.Errorf("admin get: status %d, want 200", w.Code)
+	}
+}
+
+func TestJobsRetention(t *testing.T) {
+	s := newTestServer(t, WithJobRetention(50*time.Millisecond, 2))
+
+	first := createJob(t, s, "key-alice")
+	createJob(t, s, "key-alice")
+	createJob(t, s, "key-alice")
+	if _, err := s.jobs.get(first); err != ErrJobNotFound {
+		t.Errorf("oldest job kept beyond limit: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	s.jobs.mu.Lock()
+	s.jobs.prune(time.Now())
+	n := len(s.jobs.items)
+	s.jobs.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d expired jobs kept", n)
+	}
+}
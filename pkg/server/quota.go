@@ -0,0 +1,319 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	bd "github.com/dgraph-io/badger/v4"
+
+	"github.com/miajio/nla/pkg/badger"
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// ErrQuotaExceeded 超出租户配额
+var ErrQuotaExceeded = errors.New("server: quota exceeded")
+
+const (
+	// usageKeyPrefix 用量统计的键前缀, 键为前缀+周期+":"+租户
+	usageKeyPrefix = participle.MetaKeyPrefix + "usage:"
+	// usagePeriodLayout 用量统计周期, 按自然月统计
+	usagePeriodLayout = "2006-01"
+	// usageRetention 用量记录在数据库中的保留时长, 到期后由badger自动清除
+	usageRetention = 400 * 24 * time.Hour
+)
+
+// Quota 租户每个统计周期(自然月)的配额, 0表示不限制
+type Quota struct {
+	Chars     int64 `json:"chars"`     // 分词字符数
+	Mutations int64 `json:"mutations"` // 词典修改次数
+}
+
+// Usage 租户在一个统计周期内的用量
+type Usage struct {
+	Tenant    string `json:"tenant"`    // 租户
+	Period    string `json:"period"`    // 统计周期, 如2006-01
+	Requests  int64  `json:"requests"`  // 请求数
+	Chars     int64  `json:"chars"`     // 分词字符数
+	Mutations int64  `json:"mutations"` // 词典修改次数
+	Quota     *Quota `json:"quota,omitempty"`
+}
+
+// usage 用量统计, 先累积在内存中, 定期写入数据库
+type usage struct {
+	db           *badger.Engine
+	quotas       map[string]Quota // 租户 -> 配额
+	defaultQuota Quota            // 未单独配置的租户的配额
+	enforce      bool             // 是否拒绝超出配额的请求
+	interval     time.Duration    // 写入数据库的间隔
+
+	mu    sync.Mutex
+	items map[string]*Usage // 周期+租户 -> 用量(含已写入数据库的部分)
+	dirty map[string]bool   // 尚未写入数据库的用量
+
+	done chan struct{} // 退出信号
+	stop chan struct{} // 退出成功信号
+}
+
+// WithUsage 按租户统计分词字符数与词典修改次数, 统计结果保存在db中, 保留约13个月
+// 租户由WithAPIKeys认证得到, 未启用认证时计入anonymous租户; 用量每隔interval写入数据库, 默认10秒
+func WithUsage(db *badger.Engine, interval time.Duration) Option {
+	return func(s *Server) {
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		s.usage = &usage{
+			db:       db,
+			quotas:   make(map[string]Quota),
+			interval: interval,
+			items:    make(map[string]*Usage),
+			dirty:    make(map[string]bool),
+			done:     make(chan struct{}),
+			stop:     make(chan struct{}),
+		}
+	}
+}
+
+// WithQuota 设置租户的配额并拒绝超出配额的请求, tenant为空时设置默认配额, 需同时使用WithUsage
+// 超出配额的请求返回429
+func WithQuota(tenant string, quota Quota) Option {
+	return func(s *Server) {
+		if s.usage == nil {
+			return
+		}
+		s.usage.enforce = true
+		if tenant == "" {
+			s.usage.defaultQuota = quota
+			return
+		}
+		s.usage.quotas[tenant] = quota
+	}
+}
+
+// quota 获取租户的配额
+func (u *usage) quota(tenant string) Quota {
+	if quota, ok := u.quotas[tenant]; ok {
+		return quota
+	}
+	return u.defaultQuota
+}
+
+// get 获取周期内租户的用量, 首次访问时从数据库加载, 调用方须持有锁
+func (u *usage) get(period, tenant string) (*Usage, error) {
+	key := period + ":" + tenant
+	if item, ok := u.items[key]; ok {
+		return item, nil
+	}
+	item := &Usage{Tenant: tenant, Period: period}
+	data, err := u.db.Get([]byte(usageKeyPrefix + key))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, item); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, bd.ErrKeyNotFound):
+		return nil, err
+	}
+	u.items[key] = item
+	return item, nil
+}
+
+// charge 为租户记一次请求, 启用配额时超出配额返回ErrQuotaExceeded且不计入用量
+func (u *usage) charge(tenant string, chars, mutations int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	period := time.Now().Format(usagePeriodLayout)
+	item, err := u.get(period, tenant)
+	if err != nil {
+		return fmt.Errorf("load usage fail: %v", err)
+	}
+	if u.enforce {
+		quota := u.quota(tenant)
+		if quota.Chars > 0 && item.Chars+chars > quota.Chars {
+			return fmt.Errorf("%w: chars %d > %d", ErrQuotaExceeded, item.Chars+chars, quota.Chars)
+		}
+		if quota.Mutations > 0 && item.Mutations+mutations > quota.Mutations {
+			return fmt.Errorf("%w: mutations %d > %d", ErrQuotaExceeded, item.Mutations+mutations, quota.Mutations)
+		}
+	}
+	item.Requests++
+	item.Chars += chars
+	item.Mutations += mutations
+	u.dirty[period+":"+tenant] = true
+	return nil
+}
+
+// flush 将内存中的用量写入数据库
+func (u *usage) flush() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.dirty) == 0 {
+		return nil
+	}
+
+	err := u.db.Update(func(tx *bd.Txn) error {
+		for key := range u.dirty {
+			data, err := json.Marshal(u.items[key])
+			if err != nil {
+				return err
+			}
+			if err := tx.SetEntry(bd.NewEntry([]byte(usageKeyPrefix+key), data).WithTTL(usageRetention)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("flush usage fail: %v", err)
+	}
+	u.dirty = make(map[string]bool)
+
+	// 只保留当前周期的用量, 之前周期的用量已写入数据库
+	period := time.Now().Format(usagePeriodLayout)
+	for key := range u.items {
+		if !strings.HasPrefix(key, period+":") {
+			delete(u.items, key)
+		}
+	}
+	return nil
+}
+
+// list 获取周期内所有租户的用量
+func (u *usage) list(period string) ([]Usage, error) {
+	if err := u.flush(); err != nil {
+		return nil, err
+	}
+
+	var list []Usage
+	prefix := []byte(usageKeyPrefix + period + ":")
+	err := u.db.Scan(prefix, func(key, value []byte) error {
+		var item Usage
+		if err := json.Unmarshal(value, &item); err != nil {
+			return err
+		}
+		list = append(list, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Tenant < list[b].Tenant })
+	for i := range list {
+		if u.enforce {
+			quota := u.quota(list[i].Tenant)
+			list[i].Quota = &quota
+		}
+	}
+	return list, nil
+}
+
+// listener 定期写入用量
+func (u *usage) listener(onError func(err error)) {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	defer close(u.stop)
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := u.flush(); err != nil {
+				onError(err)
+			}
+		case <-u.done:
+			return
+		}
+	}
+}
+
+// close 停止定期写入并写入剩余的用量
+func (u *usage) close() error {
+	close(u.done)
+	<-u.stop
+	return u.flush()
+}
+
+// chargeRequest 为请求所属租户记账, 超出配额时写出429响应并返回false
+func (s *Server) chargeRequest(w http.ResponseWriter, r *http.Request, chars, mutations int64) bool {
+	if s.usage == nil {
+		return true
+	}
+	err := s.usage.charge(tenantOf(r), chars, mutations)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrQuotaExceeded):
+		writeError(w, http.StatusTooManyRequests, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+	return false
+}
+
+// textChars 统计文本字符数
+func textChars(texts ...string) int64 {
+	var n int64
+	for _, text := range texts {
+		n += int64(utf8.RuneCountInString(text))
+	}
+	return n
+}
+
+// handleUsage GET /usage 查询租户的用量, 参数period为统计周期(如2006-01), 默认为当前月
+// 管理员租户可查询所有租户, 其他租户只能查询自己的用量
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if s.usage == nil {
+		writeError(w, http.StatusNotFound, errors.New("usage accounting is disabled"))
+		return
+	}
+	admin := s.isAdmin(r)
+	tenant := r.PathValue("tenant")
+	if !admin && tenant != "" && tenant != tenantOf(r) {
+		writeError(w, http.StatusForbidden, ErrForbidden)
+		return
+	}
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = time.Now().Format(usagePeriodLayout)
+	}
+	if _, err := time.Parse(usagePeriodLayout, period); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid period: %s", period))
+		return
+	}
+
+	list, err := s.usage.list(period)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if tenant == "" && !admin {
+		own := tenantOf(r)
+		list = slices.DeleteFunc(list, func(item Usage) bool { return item.Tenant != own })
+	}
+	if tenant == "" {
+		if list == nil {
+			list = []Usage{}
+		}
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+	for _, item := range list {
+		if item.Tenant == tenant {
+			writeJSON(w, http.StatusOK, item)
+			return
+		}
+	}
+	item := Usage{Tenant: tenant, Period: period}
+	if s.usage.enforce {
+		quota := s.usage.quota(tenant)
+		item.Quota = &quota
+	}
+	writeJSON(w, http.StatusOK, item)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+// Server 分词HTTP服务
+type Server struct {
+	engine *participle.Engine // 分词引擎
+	mux    *http.ServeMux     // 路由
+
+	workers  int           // 异步任务并发数
+	jobs     *jobs         // 异步任务
+	jobTTL   time.Duration // 已结束任务的保留时长
+	jobLimit int           // 保留的任务数上限
+
+	webhooks      []webhook            // webhook通知地址
+	batchInterval time.Duration        // 新词待审核批次间隔, 0表示不通知
+	reporter      *participle.Reporter // 新词学习报告
+	notifyWg      sync.WaitGroup       // 投递中的通知
+	onError       func(err error)      // 后台错误处理
+
+	auth  *auth  // API Key认证, nil表示不认证
+	usage *usage // 租户用量统计, nil表示不统计
+	audit *audit // 审计日志, nil表示不记录
+
+	cmu    sync.RWMutex
+	canary *canary // 灰度评估, nil表示未开启
+}
+
+// Option 服务配置项
+type Option func(*Server)
+
+// WithWorkers 设置异步任务并发数, 默认为2
+func WithWorkers(n int) Option {
+	return func(s *Server) { s.workers = n }
+}
+
+// New 创建HTTP服务并启动异步任务处理协程
+func New(engine *participle.Engine, opts ...Option) *Server {
+	s := &Server{engine: engine, mux: http.NewServeMux(), workers: 2}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.jobs = newJobs(s.workers, s.jobTTL, s.jobLimit, func(job Job) { s.notify(EventJobFinished, &job) })
+	if s.batchInterval > 0 {
+		s.reporter = engine.StartReporter(s.batchInterval, s.reportError, s.learnedBatchSink)
+	}
+	if s.usage != nil {
+		go s.usage.listener(s.reportError)
+	}
+
+	s.mux.HandleFunc("POST /jobs", s.handleCreateJob)
+	s.mux.HandleFunc("GET /jobs/{id}", s.handleGetJob)
+	s.mux.HandleFunc("DELETE /jobs/{id}", s.handleCancelJob)
+	s.mux.HandleFunc("GET /jobs/{id}/result", s.handleJobResult)
+	s.mux.HandleFunc("POST /segment", s.handleSegment)
+	s.mux.HandleFunc("GET /usage", s.handleUsage)
+	s.mux.HandleFunc("GET /usage/{tenant}", s.handleUsage)
+	s.mux.HandleFunc("GET /canary", s.handleCanary)
+	s.mux.HandleFunc("DELETE /canary", s.handleDeleteCanary)
+	s.mux.HandleFunc("POST /canary/promote", s.handlePromoteCanary)
+	return s
+}
+
+// ServeHTTP 实现http.Handler接口, 启用API Key认证时先认证再交由路由处理
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var next http.Handler = s.mux
+	r, ok := s.authenticate(r)
+	if !ok {
+		next = http.HandlerFunc(unauthorized)
+	}
+	if s.audit != nil {
+		s.serveAudit(w, r, next)
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+// Close 取消所有未完成的任务并等待处理协程退出
+// 启用新词批次通知时先通知最后一个批次, 并等待投递中的通知完成; 启用用量统计时写入剩余的用量
+func (s *Server) Close() {
+	s.jobs.close()
+	if s.reporter != nil {
+		s.reporter.Stop()
+	}
+	s.SetCanary(nil, 0)
+	if s.usage != nil {
+		if err := s.usage.close(); err != nil {
+			s.reportError(err)
+		}
+	}
+	s.notifyWg.Wait()
+}
+
+// segmentRequest 分词请求
+type segmentRequest struct {
+	Text string `json:"text"` // 待分词文本
+}
+
+// segmentResponse 分词响应
+type segmentResponse struct {
+	Tokens []string `json:"tokens"` // 分词结果
+}
+
+// handleSegment POST /segment 对文本分词, 启用用量统计时按文本字符数计入分词用量
+func (s *Server) handleSegment(w http.ResponseWriter, r *http.Request) {
+	var req segmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode segment request fail: %v", err))
+		return
+	}
+	if !s.chargeRequest(w, r, textChars(req.Text), 0) {
+		return
+	}
+	tokens := s.engine.Segment(req.Text)
+	if tokens == nil {
+		tokens = []string{}
+	}
+	s.mirrorCanary(req.Text, tokens)
+	writeJSON(w, http.StatusOK, segmentResponse{Tokens: tokens})
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON 写出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写出错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
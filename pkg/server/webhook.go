@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/miajio/nla/pkg/participle"
+)
+
+const (
+	EventJobFinished  = "job.finished"  // 异步任务结束(成功、失败或取消)
+	EventLearnedBatch = "learned.batch" // 新词待审核批次就绪
+)
+
+// WebhookEvent webhook通知内容
+type WebhookEvent struct {
+	Event string    `json:"event"` // 事件名称
+	Time  time.Time `json:"time"`  // 事件时间
+	Data  any       `json:"data"`  // 事件数据: *Job 或 *participle.LearnedReport
+}
+
+// webhook 通知地址
+type webhook struct {
+	url    string
+	secret string
+}
+
+// WithWebhook 添加webhook通知地址, 可多次调用
+// secret不为空时请求头X-NLA-Signature为"sha256="加请求体的HMAC-SHA256十六进制签名
+func WithWebhook(url, secret string) Option {
+	return func(s *Server) { s.webhooks = append(s.webhooks, webhook{url: url, secret: secret}) }
+}
+
+// WithLearnedBatches 每隔interval将期间学习到的新词作为一个待审核批次通知webhook
+func WithLearnedBatches(interval time.Duration) Option {
+	return func(s *Server) { s.batchInterval = interval }
+}
+
+// WithErrorHandler 设置后台错误(如webhook投递失败)处理函数
+func WithErrorHandler(handler func(err error)) Option {
+	return func(s *Server) { s.onError = handler }
+}
+
+// Sign 计算webhook请求体签名, 接收方可用于校验
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notify 异步通知所有webhook
+func (s *Server) notify(event string, data any) {
+	if len(s.webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(WebhookEvent{Event: event, Time: time.Now(), Data: data})
+	if err != nil {
+		s.reportError(err)
+		return
+	}
+
+	for _, hook := range s.webhooks {
+		s.notifyWg.Add(1)
+		go func() {
+			defer s.notifyWg.Done()
+			if err := hook.post(event, body); err != nil {
+				s.reportError(err)
+			}
+		}()
+	}
+}
+
+// post 投递一次通知
+func (h webhook) post(event string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-NLA-Event", event)
+	if h.secret != "" {
+		req.Header.Set("X-NLA-Signature", Sign(h.secret, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s response status: %s", h.url, resp.Status)
+	}
+	return nil
+}
+
+// learnedBatchSink 将新词学习报告作为待审核批次通知webhook
+func (s *Server) learnedBatchSink(report *participle.LearnedReport) error {
+	s.notify(EventLearnedBatch, report)
+	return nil
+}
+
+// reportError 交由错误处理函数处理后台错误
+func (s *Server) reportError(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}